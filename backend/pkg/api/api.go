@@ -13,11 +13,17 @@ import (
 type API struct {
 	Cfg *Config
 
-	Logger   *zap.Logger
+	Logger *zap.Logger
+
+	// KafkaSvc and OwlSvc belong to the cluster that is currently served. The API routes without cluster prefix are
+	// served by the default cluster.
 	KafkaSvc *kafka.Service
 	OwlSvc   *owl.Service
 	GitSvc   *git.Service
 
+	// Clusters contains all configured clusters in the order they have been configured
+	Clusters []*Cluster
+
 	Hooks *Hooks // Hooks to add additional functionality from the outside at different places (used by Kafka Owl Business)
 
 	version versionInfo
@@ -46,42 +52,76 @@ func New(cfg *Config) *API {
 		)
 	}
 
-	kafkaSvc, err := kafka.NewService(cfg.Kafka, logger, cfg.MetricsNamespace)
-	if err != nil {
-		logger.Fatal("failed to create kafka service", zap.Error(err))
+	clusterConfigs := cfg.ClusterConfigs()
+	clusters := make([]*Cluster, len(clusterConfigs))
+	for i, clusterCfg := range clusterConfigs {
+		clusterLogger := logger.With(zap.String("cluster", clusterCfg.Name))
+		clusters[i] = newCluster(clusterCfg, clusterLogger, cfg.MetricsNamespace)
+		if clusters[i].Err != nil {
+			clusterLogger.Error("failed to create cluster services, cluster will be unavailable", zap.Error(clusters[i].Err))
+		}
 	}
 
-	owlSvc, err := owl.NewService(cfg.Owl, logger, kafkaSvc)
-	if err != nil {
-		logger.Fatal("failed to create owl service", zap.Error(err))
-	}
-
-	return &API{
+	api := &API{
 		Cfg:      cfg,
 		Logger:   logger,
-		KafkaSvc: kafkaSvc,
-		OwlSvc:   owlSvc,
+		Clusters: clusters,
 		Hooks:    newDefaultHooks(),
 		version:  version,
 	}
+	defaultCluster := api.defaultCluster()
+	api.KafkaSvc = defaultCluster.KafkaSvc
+	api.OwlSvc = defaultCluster.OwlSvc
+
+	return api
 }
 
 // Start the API server and block
 func (api *API) Start() {
-	err := api.KafkaSvc.Start()
-	if err != nil {
-		api.Logger.Fatal("failed to start kafka service", zap.Error(err))
+	availableClusters := 0
+	for _, cluster := range api.Clusters {
+		cluster.start()
+		if !cluster.IsAvailable() {
+			api.Logger.Error("cluster is unavailable", zap.String("cluster", cluster.Name), zap.Error(cluster.Err))
+			continue
+		}
+		availableClusters++
 	}
-
-	err = api.OwlSvc.Start()
-	if err != nil {
-		api.Logger.Fatal("failed to start owl service", zap.Error(err))
+	if availableClusters == 0 {
+		api.Logger.Fatal("failed to start services for any of the configured clusters")
 	}
 
+	// The default cluster may have changed if it failed to start
+	defaultCluster := api.defaultCluster()
+	api.KafkaSvc = defaultCluster.KafkaSvc
+	api.OwlSvc = defaultCluster.OwlSvc
+
 	// Server
 	server := rest.NewServer(&api.Cfg.REST, api.Logger, api.routes())
-	err = server.Start()
+	err := server.Start()
 	if err != nil {
 		api.Logger.Fatal("REST Server returned an error", zap.Error(err))
 	}
 }
+
+// defaultCluster returns the cluster that serves the API routes without cluster prefix. This is the first available
+// cluster, so that these routes can still be served if the first configured cluster is unavailable.
+func (api *API) defaultCluster() *Cluster {
+	for _, cluster := range api.Clusters {
+		if cluster.IsAvailable() {
+			return cluster
+		}
+	}
+
+	return api.Clusters[0]
+}
+
+// forCluster returns a copy of the API that serves requests using the given cluster's services.
+func (api *API) forCluster(cluster *Cluster) *API {
+	clusterAPI := *api
+	clusterAPI.Logger = api.Logger.With(zap.String("cluster", cluster.Name))
+	clusterAPI.KafkaSvc = cluster.KafkaSvc
+	clusterAPI.OwlSvc = cluster.OwlSvc
+
+	return &clusterAPI
+}
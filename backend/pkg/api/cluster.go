@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"go.uber.org/zap"
+)
+
+// Cluster bundles all services that are required to serve requests for a single Kafka cluster.
+type Cluster struct {
+	Name     string
+	KafkaSvc *kafka.Service
+	OwlSvc   *owl.Service
+
+	// Err is set if the cluster's services could not be created or started. Requests for this cluster will be
+	// rejected, while all other clusters can still be served.
+	Err error
+}
+
+// IsAvailable returns true if all services of the cluster have been created and started successfully.
+func (c *Cluster) IsAvailable() bool {
+	return c.Err == nil
+}
+
+// newCluster creates all services for the given cluster config. Errors are not returned, but stored in the cluster so
+// that a single unreachable cluster does not prevent Kowl from starting.
+func newCluster(cfg ClusterConfig, logger *zap.Logger, metricsNamespace string) *Cluster {
+	cluster := &Cluster{Name: cfg.Name}
+
+	kafkaSvc, err := kafka.NewService(cfg.Kafka, logger, metricsNamespace, cfg.Name)
+	if err != nil {
+		cluster.Err = fmt.Errorf("failed to create kafka service: %w", err)
+		return cluster
+	}
+	cluster.KafkaSvc = kafkaSvc
+
+	owlSvc, err := owl.NewService(cfg.Owl, logger, kafkaSvc)
+	if err != nil {
+		cluster.Err = fmt.Errorf("failed to create owl service: %w", err)
+		return cluster
+	}
+	cluster.OwlSvc = owlSvc
+
+	return cluster
+}
+
+// start starts the (background) tasks of all the cluster's services.
+func (c *Cluster) start() {
+	if !c.IsAvailable() {
+		return
+	}
+
+	err := c.KafkaSvc.Start()
+	if err != nil {
+		c.Err = fmt.Errorf("failed to start kafka service: %w", err)
+		return
+	}
+
+	err = c.OwlSvc.Start()
+	if err != nil {
+		c.Err = fmt.Errorf("failed to start owl service: %w", err)
+		return
+	}
+}
@@ -34,6 +34,15 @@ type Config struct {
 	REST   rest.Config    `yaml:"server"`
 	Kafka  kafka.Config   `yaml:"kafka"`
 	Logger logging.Config `yaml:"logger"`
+
+	// Clusters can be used instead of the kafka and owl config blocks in order to serve multiple Kafka clusters
+	// from a single Kowl instance.
+	Clusters []ClusterConfig `yaml:"clusters"`
+
+	// kafkaConfigured and owlConfigured are true if any option of the top level kafka or owl config block has been
+	// set by the config file or environment variables. See setConfiguredBlocks.
+	kafkaConfigured bool
+	owlConfigured   bool
 }
 
 // RegisterFlags for all (sub)configs
@@ -52,19 +61,61 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("failed to validate loglevel input: %w", err)
 	}
 
-	err = c.Kafka.Validate()
-	if err != nil {
-		return fmt.Errorf("failed to validate Kafka config: %w", err)
+	// The top level kafka and owl config blocks would be silently ignored if clusters are configured
+	if len(c.Clusters) > 0 {
+		if c.kafkaConfigured {
+			return fmt.Errorf("kafka config must not be set if clusters are configured, configure kafka for each cluster instead")
+		}
+		if c.owlConfigured {
+			return fmt.Errorf("owl config must not be set if clusters are configured, configure owl for each cluster instead")
+		}
 	}
 
-	err = c.Owl.Validate()
-	if err != nil {
-		return fmt.Errorf("failed to validate Owl config: %w", err)
+	clusterNames := make(map[string]struct{})
+	for i, cluster := range c.ClusterConfigs() {
+		if _, exists := clusterNames[cluster.Name]; exists {
+			return fmt.Errorf("cluster name '%v' is not unique", cluster.Name)
+		}
+		clusterNames[cluster.Name] = struct{}{}
+
+		err = cluster.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate cluster config at index '%d': %w", i, err)
+		}
 	}
 
 	return nil
 }
 
+// ClusterConfigs returns the configs of all clusters that shall be served. If no clusters are configured, the top
+// level kafka and owl config blocks are used for a single cluster.
+func (c *Config) ClusterConfigs() []ClusterConfig {
+	if len(c.Clusters) > 0 {
+		return c.Clusters
+	}
+
+	return []ClusterConfig{{
+		Name:  defaultClusterName,
+		Kafka: c.Kafka,
+		Owl:   c.Owl,
+	}}
+}
+
+// setConfiguredBlocks tracks whether the top level kafka and owl config blocks are used, given the keys that have
+// been decoded into the config (as reported by mapstructure's metadata). Unknown keys, such as those of environment
+// variables which are injected by Kubernetes (e.g. KAFKA_SERVICE_HOST), are not decoded and hence not considered.
+func (c *Config) setConfiguredBlocks(decodedKeys []string) {
+	for _, key := range decodedKeys {
+		key = strings.ToLower(key)
+		if strings.HasPrefix(key, "kafka.") {
+			c.kafkaConfigured = true
+		}
+		if strings.HasPrefix(key, "owl.") {
+			c.owlConfigured = true
+		}
+	}
+}
+
 // SetDefaults for all root and child config structs
 func (c *Config) SetDefaults() {
 	c.ServeFrontend = true
@@ -104,6 +155,15 @@ func LoadConfig(logger *zap.Logger) (Config, error) {
 		}
 	}
 
+	// Defaults for cluster configs must be set before unmarshalling, because the decoder would create empty slice
+	// elements otherwise. Existing slice elements are decoded in place, so that their defaults are kept.
+	if clusters, ok := k.Get("clusters").([]interface{}); ok {
+		cfg.Clusters = make([]ClusterConfig, len(clusters))
+		for i := range cfg.Clusters {
+			cfg.Clusters[i].SetDefaults()
+		}
+	}
+
 	// 2. Unmarshal the config into our Config struct using the YAML and then ENV parser
 	// We could unmarshal the loaded koanf input after loading both providers, however we want to unmarshal the YAML
 	// config with `ErrorUnused` set to true, but unmarshal environment variables with `ErrorUnused` set to false (default).
@@ -141,10 +201,20 @@ func LoadConfig(logger *zap.Logger) (Config, error) {
 		return Config{}, fmt.Errorf("failed to unmarshal environment variables into config struct: %w", err)
 	}
 
-	err = k.Unmarshal("", &cfg)
+	var metadata mapstructure.Metadata
+	err = k.UnmarshalWithConf("", &cfg, koanf.UnmarshalConf{
+		DecoderConfig: &mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				mapstructure.StringToTimeDurationHookFunc()),
+			Metadata:         &metadata,
+			Result:           &cfg,
+			WeaklyTypedInput: true,
+		},
+	})
 	if err != nil {
 		return Config{}, err
 	}
+	cfg.setConfiguredBlocks(metadata.Keys)
 
 	// VCAP Specifications
 	type Cluster struct {
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+)
+
+// defaultClusterName is used for the cluster which is configured via the top level kafka and owl config blocks
+const defaultClusterName = "default"
+
+// clusterNameRegex restricts cluster names to characters which can be used in URL paths without escaping
+var clusterNameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// ClusterConfig contains all configs which are specific to a single Kafka cluster
+type ClusterConfig struct {
+	Name  string       `yaml:"name"`
+	Kafka kafka.Config `yaml:"kafka"`
+	Owl   owl.Config   `yaml:"owl"`
+}
+
+// SetDefaults for the cluster's Kafka and Owl config
+func (c *ClusterConfig) SetDefaults() {
+	c.Kafka.SetDefaults()
+	c.Owl.SetDefaults()
+}
+
+// Validate the cluster config
+func (c *ClusterConfig) Validate() error {
+	if !clusterNameRegex.MatchString(c.Name) {
+		return fmt.Errorf("cluster name '%v' is invalid, it must match the regex '%v'", c.Name, clusterNameRegex.String())
+	}
+
+	err := c.Kafka.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate Kafka config: %w", err)
+	}
+
+	err = c.Owl.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate Owl config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClusterConfig(name string) ClusterConfig {
+	cfg := ClusterConfig{Name: name}
+	cfg.SetDefaults()
+	cfg.Kafka.Brokers = []string{name + ":9092"}
+	return cfg
+}
+
+func TestConfig_ClusterConfigs(t *testing.T) {
+	var cfg Config
+	cfg.SetDefaults()
+	cfg.Kafka.Brokers = []string{"localhost:9092"}
+	cfg.Owl.LagExporter.Enabled = true
+	require.NoError(t, cfg.Validate())
+
+	// The top level kafka and owl config blocks are used for a single cluster if no clusters are configured
+	clusters := cfg.ClusterConfigs()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, defaultClusterName, clusters[0].Name)
+	assert.Equal(t, cfg.Kafka, clusters[0].Kafka)
+	assert.Equal(t, cfg.Owl, clusters[0].Owl)
+
+	cfg = Config{}
+	cfg.SetDefaults()
+	cfg.Clusters = []ClusterConfig{newClusterConfig("prod"), newClusterConfig("staging")}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, cfg.Clusters, cfg.ClusterConfigs())
+}
+
+func TestConfig_Validate_Clusters(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(cfg *Config)
+	}{
+		{"top level kafka brokers", func(cfg *Config) { cfg.setConfiguredBlocks([]string{"Kafka", "Kafka.Brokers"}) }},
+		{"top level schema registry", func(cfg *Config) { cfg.setConfiguredBlocks([]string{"Kafka", "Kafka.Schema", "Kafka.Schema.Enabled"}) }},
+		{"top level owl config", func(cfg *Config) { cfg.setConfiguredBlocks([]string{"Owl", "Owl.LagExporter", "Owl.LagExporter.Enabled"}) }},
+		{"duplicate cluster name", func(cfg *Config) { cfg.Clusters[1].Name = cfg.Clusters[0].Name }},
+		{"invalid cluster name", func(cfg *Config) { cfg.Clusters[0].Name = "prod/eu" }},
+		{"invalid cluster config", func(cfg *Config) { cfg.Clusters[1].Kafka.Brokers = nil }},
+	}
+
+	for _, table := range tt {
+		var cfg Config
+		cfg.SetDefaults()
+		cfg.Clusters = []ClusterConfig{newClusterConfig("prod"), newClusterConfig("staging")}
+		table.modify(&cfg)
+		assert.Error(t, cfg.Validate(), "expected an error for config with %v", table.name)
+	}
+}
+
+func TestConfig_SetConfiguredBlocks(t *testing.T) {
+	tt := []struct {
+		name            string
+		decodedKeys     []string
+		kafkaConfigured bool
+		owlConfigured   bool
+	}{
+		{"no keys", nil, false, false},
+		{"cluster options", []string{"Clusters", "Clusters[0]", "Clusters[0].Kafka", "Clusters[0].Kafka.Brokers", "Clusters[0].Owl.TimeLag.Enabled"}, false, false},
+		{"top level kafka option", []string{"Kafka", "Kafka.Brokers"}, true, false},
+		{"top level option set to its default", []string{"kafka", "kafka.clientId"}, true, false},
+		{"top level owl option", []string{"Owl", "Owl.LagHistory", "Owl.LagHistory.Enabled"}, false, true},
+		// Kubernetes injects environment variables such as KAFKA_SERVICE_HOST, which are not decoded
+		{"empty top level block", []string{"Kafka"}, false, false},
+	}
+
+	for _, table := range tt {
+		var cfg Config
+		cfg.setConfiguredBlocks(table.decodedKeys)
+		assert.Equal(t, table.kafkaConfigured, cfg.kafkaConfigured, "unexpected kafkaConfigured for %v", table.name)
+		assert.Equal(t, table.owlConfigured, cfg.owlConfigured, "unexpected owlConfigured for %v", table.name)
+	}
+}
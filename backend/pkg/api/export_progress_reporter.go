@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+const (
+	exportFormatJSONL = "jsonl"
+	exportFormatCSV   = "csv"
+)
+
+const (
+	exportColumnPartition = "partition"
+	exportColumnOffset    = "offset"
+	exportColumnTimestamp = "timestamp"
+	exportColumnKey       = "key"
+	exportColumnValue     = "value"
+	exportColumnHeaders   = "headers"
+)
+
+// exportTrailerError is sent as HTTP trailer if the export failed after the response body has already been started.
+const exportTrailerError = "X-Kowl-Export-Error"
+
+// exportMaxDuration is the upper bound for the duration of a single export. Exports are further limited by the HTTP
+// server's write timeout.
+const exportMaxDuration = 30 * time.Minute
+
+// exportFlushInterval is the number of messages after which the response writer will be flushed.
+const exportFlushInterval = 500
+
+var exportColumnsAll = []string{
+	exportColumnPartition,
+	exportColumnOffset,
+	exportColumnTimestamp,
+	exportColumnKey,
+	exportColumnValue,
+	exportColumnHeaders,
+}
+
+// exportProgressReporter implements kafka.IListMessagesProgress. Instead of sending messages and status updates via
+// a websocket it writes each message as JSON line or CSV row straight into a chunked HTTP response.
+type exportProgressReporter struct {
+	logger  *zap.Logger
+	writer  http.ResponseWriter
+	format  string
+	columns []string
+
+	csvWriter       *csv.Writer
+	messagesWritten int64
+	err             error
+	errorReported   bool
+}
+
+func newExportProgressReporter(logger *zap.Logger, w http.ResponseWriter, format string, columns []string) *exportProgressReporter {
+	p := &exportProgressReporter{
+		logger:  logger,
+		writer:  w,
+		format:  format,
+		columns: columns,
+	}
+	if format == exportFormatCSV {
+		p.csvWriter = csv.NewWriter(w)
+	}
+
+	return p
+}
+
+// Start writes the response headers (and the CSV header row) so that the download starts immediately.
+func (p *exportProgressReporter) Start(filename string) {
+	contentType := "application/x-ndjson"
+	if p.format == exportFormatCSV {
+		contentType = "text/csv"
+	}
+	p.writer.Header().Set("Content-Type", contentType)
+	p.writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	p.writer.Header().Set("Trailer", exportTrailerError)
+	p.writer.WriteHeader(http.StatusOK)
+
+	if p.format == exportFormatCSV {
+		p.err = p.csvWriter.Write(p.columns)
+	}
+	p.flush()
+}
+
+func (p *exportProgressReporter) OnPhase(name string) {
+	p.logger.Debug("export messages phase changed", zap.String("phase", name))
+}
+
+func (p *exportProgressReporter) OnMessageConsumed(size int64) {}
+
+func (p *exportProgressReporter) OnMessage(message *kafka.TopicMessage) {
+	if p.err != nil {
+		// The client is most likely gone, there's no point in writing any further messages
+		return
+	}
+
+	switch p.format {
+	case exportFormatCSV:
+		p.err = p.writeCSVRow(message)
+	default:
+		p.err = p.writeJSONLine(message)
+	}
+	if p.err != nil {
+		p.logger.Debug("failed to write exported message", zap.Error(p.err))
+		return
+	}
+
+	p.messagesWritten++
+	if p.messagesWritten%exportFlushInterval == 0 {
+		p.flush()
+	}
+}
+
+func (p *exportProgressReporter) OnComplete(elapsedMs int64, isCancelled bool) {
+	p.logger.Debug("export messages completed",
+		zap.Int64("elapsed_ms", elapsedMs),
+		zap.Bool("is_cancelled", isCancelled),
+		zap.Int64("messages_written", p.messagesWritten))
+	p.flush()
+}
+
+func (p *exportProgressReporter) OnError(message string) {
+	p.logger.Warn("failed to export messages", zap.String("error", message))
+	p.flush()
+
+	// The status code has already been sent, hence we can only report the error via the trailer
+	p.writer.Header().Set(exportTrailerError, message)
+	p.errorReported = true
+}
+
+func (p *exportProgressReporter) flush() {
+	if p.csvWriter != nil {
+		p.csvWriter.Flush()
+	}
+	if flusher, ok := p.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (p *exportProgressReporter) writeJSONLine(message *kafka.TopicMessage) error {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, column := range p.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		value, err := exportColumnJSON(message, column)
+		if err != nil {
+			return fmt.Errorf("failed to encode column '%v': %w", column, err)
+		}
+		buf.WriteString(strconv.Quote(column))
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteString("}\n")
+
+	_, err := p.writer.Write(buf.Bytes())
+	return err
+}
+
+func (p *exportProgressReporter) writeCSVRow(message *kafka.TopicMessage) error {
+	row := make([]string, len(p.columns))
+	for i, column := range p.columns {
+		value, err := exportColumnJSON(message, column)
+		if err != nil {
+			return fmt.Errorf("failed to encode column '%v': %w", column, err)
+		}
+
+		// Plain strings (e.g. text or base64 encoded binary payloads) shall not be quoted in CSV cells
+		var str string
+		if err := json.Unmarshal(value, &str); err == nil {
+			row[i] = str
+			continue
+		}
+		if string(value) == "null" {
+			row[i] = ""
+			continue
+		}
+		row[i] = string(value)
+	}
+
+	return p.csvWriter.Write(row)
+}
+
+// exportColumnJSON returns the JSON representation of a single column of the given message.
+func exportColumnJSON(message *kafka.TopicMessage, column string) ([]byte, error) {
+	switch column {
+	case exportColumnPartition:
+		return json.Marshal(message.PartitionID)
+	case exportColumnOffset:
+		return json.Marshal(message.Offset)
+	case exportColumnTimestamp:
+		return json.Marshal(message.Timestamp)
+	case exportColumnKey:
+		if message.Key == nil {
+			return []byte("null"), nil
+		}
+		return json.Marshal(&message.Key.Payload)
+	case exportColumnValue:
+		if message.Value == nil || message.IsValueNull {
+			return []byte("null"), nil
+		}
+		return json.Marshal(&message.Value.Payload)
+	case exportColumnHeaders:
+		type header struct {
+			Key   string          `json:"key"`
+			Value json.RawMessage `json:"value"`
+		}
+		headers := make([]header, len(message.Headers))
+		for i, h := range message.Headers {
+			value := []byte("null")
+			if h.Value != nil {
+				encoded, err := json.Marshal(&h.Value.Payload)
+				if err != nil {
+					return nil, err
+				}
+				value = encoded
+			}
+			headers[i] = header{Key: h.Key, Value: value}
+		}
+		return json.Marshal(headers)
+	default:
+		return nil, fmt.Errorf("unknown column")
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newExportTestPayload(encoding kafka.MessageEncoding, payload string) *kafka.DeserializedPayload {
+	return &kafka.DeserializedPayload{Payload: kafka.NormalizedPayload{Payload: []byte(payload), RecognizedEncoding: encoding}}
+}
+
+func TestExportProgressReporter_Formats(t *testing.T) {
+	messages := []*kafka.TopicMessage{
+		{
+			PartitionID: 0,
+			Offset:      10,
+			Timestamp:   1609459200000,
+			Key:         newExportTestPayload("text", `Doe, "Jane"`),
+			Value:       newExportTestPayload("json", `{"note":"line 1\nline 2"}`),
+			Headers: []kafka.MessageHeader{
+				{Key: "trace-id", Value: newExportTestPayload("text", "abc")},
+				{Key: "empty", Value: nil},
+			},
+		},
+		{
+			// Tombstone with a binary key
+			PartitionID: 2,
+			Offset:      7,
+			Timestamp:   1609459200001,
+			Key:         newExportTestPayload("binary", "\x00\x01"),
+			Value:       newExportTestPayload("none", ""),
+			IsValueNull: true,
+		},
+		{
+			// Record without key, whose text value spans multiple lines
+			PartitionID: 1,
+			Offset:      3,
+			Timestamp:   1609459200002,
+			Value:       newExportTestPayload("text", "first line\nsecond line"),
+		},
+	}
+
+	tt := []struct {
+		name     string
+		format   string
+		columns  []string
+		expected string
+	}{
+		{
+			name:    "csv with all columns",
+			format:  exportFormatCSV,
+			columns: exportColumnsAll,
+			expected: "partition,offset,timestamp,key,value,headers\n" +
+				`0,10,1609459200000,"Doe, ""Jane""","{""note"":""line 1\nline 2""}","[{""key"":""trace-id"",""value"":""abc""},{""key"":""empty"",""value"":null}]"` + "\n" +
+				"2,7,1609459200001,AAE=,,[]\n" +
+				"1,3,1609459200002,,\"first line\nsecond line\",[]\n",
+		},
+		{
+			name:    "csv columns in requested order",
+			format:  exportFormatCSV,
+			columns: []string{exportColumnValue, exportColumnOffset},
+			expected: "value,offset\n" +
+				`"{""note"":""line 1\nline 2""}",10` + "\n" +
+				",7\n" +
+				"\"first line\nsecond line\",3\n",
+		},
+		{
+			name:    "jsonl with all columns",
+			format:  exportFormatJSONL,
+			columns: exportColumnsAll,
+			expected: `{"partition":0,"offset":10,"timestamp":1609459200000,"key":"Doe, \"Jane\"","value":{"note":"line 1\nline 2"},"headers":[{"key":"trace-id","value":"abc"},{"key":"empty","value":null}]}` + "\n" +
+				`{"partition":2,"offset":7,"timestamp":1609459200001,"key":"AAE=","value":null,"headers":[]}` + "\n" +
+				`{"partition":1,"offset":3,"timestamp":1609459200002,"key":null,"value":"first line\nsecond line","headers":[]}` + "\n",
+		},
+		{
+			name:    "jsonl columns in requested order",
+			format:  exportFormatJSONL,
+			columns: []string{exportColumnKey, exportColumnPartition},
+			expected: `{"key":"Doe, \"Jane\"","partition":0}` + "\n" +
+				`{"key":"AAE=","partition":2}` + "\n" +
+				`{"key":null,"partition":1}` + "\n",
+		},
+	}
+
+	for _, table := range tt {
+		t.Run(table.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			reporter := newExportProgressReporter(zap.NewNop(), rec, table.format, table.columns)
+			reporter.Start("messages." + table.format)
+			for _, msg := range messages {
+				reporter.OnMessage(msg)
+			}
+			reporter.OnComplete(1, false)
+
+			assert.NoError(t, reporter.err)
+			assert.Equal(t, table.expected, rec.Body.String())
+			assert.Equal(t, `attachment; filename="messages.`+table.format+`"`, rec.Header().Get("Content-Disposition"))
+		})
+	}
+}
+
+func TestExportMessagesRequest_OK(t *testing.T) {
+	newRequest := func() exportMessagesRequest {
+		return exportMessagesRequest{StartOffset: -2, PartitionID: -1, MaxResults: 1000, Format: exportFormatCSV}
+	}
+
+	tt := []struct {
+		name    string
+		modify  func(req *exportMessagesRequest)
+		isValid bool
+	}{
+		{"defaults", func(req *exportMessagesRequest) {}, true},
+		{"column selection", func(req *exportMessagesRequest) { req.Columns = []string{exportColumnValue, exportColumnKey} }, true},
+		{"unknown column", func(req *exportMessagesRequest) { req.Columns = []string{exportColumnValue, "size"} }, false},
+		{"unknown format", func(req *exportMessagesRequest) { req.Format = "xlsx" }, false},
+		{"live tail", func(req *exportMessagesRequest) { req.StartOffset = -3 }, false},
+		{"too many results", func(req *exportMessagesRequest) { req.MaxResults = 1000001 }, false},
+		{"invalid filter code", func(req *exportMessagesRequest) { req.FilterInterpreterCode = "not base64!" }, false},
+	}
+
+	for _, table := range tt {
+		req := newRequest()
+		table.modify(&req)
+		if table.isValid {
+			assert.NoError(t, req.OK(), "expected request with %v to be valid", table.name)
+		} else {
+			assert.Error(t, req.OK(), "expected request with %v to be invalid", table.name)
+		}
+	}
+}
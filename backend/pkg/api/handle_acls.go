@@ -1,10 +1,17 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/schema"
 	"github.com/twmb/franz-go/pkg/kmsg"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/cloudhut/common/rest"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
@@ -38,7 +45,7 @@ func (g *getAclsOverviewRequest) OK() error {
 		return fmt.Errorf("resourceType filter is out of bounds")
 	}
 
-	if kmsg.ACLResourcePatternType(g.ResourcePatternTypeFilter).String() == kmsg.ACLResourcePatternTypeUnknown.String() {
+	if owl.ACLResourcePatternTypeName(kmsg.ACLResourcePatternType(g.ResourcePatternTypeFilter)) == owl.ACLResourcePatternTypeName(owl.ACLResourcePatternTypeUnknown) {
 		return fmt.Errorf("resourcePatternTypeFilter is out of bounds")
 	}
 
@@ -66,6 +73,20 @@ func (g *getAclsOverviewRequest) ToKafkaRequest() kmsg.DescribeACLsRequest {
 	}
 }
 
+// ToKafkaDeleteFilter returns a delete filter that matches the same ACLs as the describe filter
+func (g *getAclsOverviewRequest) ToKafkaDeleteFilter() kmsg.DeleteACLsRequestFilter {
+	filter := kmsg.NewDeleteACLsRequestFilter()
+	filter.ResourceType = kmsg.ACLResourceType(g.ResourceType)
+	filter.ResourceName = g.ResourceName
+	filter.ResourcePatternType = kmsg.ACLResourcePatternType(g.ResourcePatternTypeFilter)
+	filter.Principal = g.Principal
+	filter.Host = g.Host
+	filter.Operation = kmsg.ACLOperation(g.Operation)
+	filter.PermissionType = kmsg.ACLPermissionType(g.PermissionType)
+
+	return filter
+}
+
 func (api *API) handleGetACLsOverview() http.HandlerFunc {
 	// response represents the data which is returned for listing ACLs
 	type response struct {
@@ -135,3 +156,417 @@ func (api *API) handleGetACLsOverview() http.HandlerFunc {
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
 	}
 }
+
+const (
+	aclDocumentFormatYAML = "yaml"
+	aclDocumentFormatJSON = "json"
+)
+
+// maxACLDocumentSize is the maximum size of an ACL document that can be imported
+const maxACLDocumentSize = 10 * 1024 * 1024
+
+type createACLsRequest struct {
+	ACLs []owl.AclBinding `json:"acls"`
+}
+
+func (c *createACLsRequest) OK() error {
+	if len(c.ACLs) == 0 {
+		return fmt.Errorf("at least one ACL binding must be set")
+	}
+	for i, binding := range c.ACLs {
+		err := binding.OK()
+		if err != nil {
+			return fmt.Errorf("invalid ACL binding at index '%d': %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (api *API) handleCreateACLs() http.HandlerFunc {
+	type response struct {
+		Results []owl.CreateACLResult `json:"results"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse and validate request
+		var req createACLsRequest
+		err := rest.Decode(w, r, &req)
+		if err != nil {
+			var mr *rest.MalformedRequest
+			if errors.As(err, &mr) {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf(mr.Error()),
+					Status:   mr.Status,
+					Message:  mr.Message,
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to decode request payload: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to create ACLs
+		if !api.checkACLPermission(w, r, api.Hooks.Owl.CanCreateACL, "create") {
+			return
+		}
+
+		// 3. Create ACLs
+		results, err := api.OwlSvc.CreateACLs(r.Context(), req.ACLs)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not create ACLs: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		res := response{
+			Results: results,
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+func (api *API) handleDeleteACLs() http.HandlerFunc {
+	type response struct {
+		DeletedACLs []owl.DeleteACLResult `json:"deletedAcls"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse filter from url parameters. All ACLs matching the filter will be deleted.
+		decoder := schema.NewDecoder()
+		req := &getAclsOverviewRequest{}
+		err := decoder.Decode(req, r.URL.Query())
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Failed to parse request parameters",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		err = req.OK()
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to validate request parameters: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to delete ACLs
+		if !api.checkACLPermission(w, r, api.Hooks.Owl.CanDeleteACL, "delete") {
+			return
+		}
+
+		// 3. Delete ACLs
+		deleted, err := api.OwlSvc.DeleteACLs(r.Context(), []kmsg.DeleteACLsRequestFilter{req.ToKafkaDeleteFilter()})
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not delete ACLs: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		res := response{
+			DeletedACLs: deleted,
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+type aclDocumentFormatRequest struct {
+	Format string `schema:"format"`
+	DryRun bool   `schema:"dryRun"`
+}
+
+func (a *aclDocumentFormatRequest) OK() error {
+	if a.Format != aclDocumentFormatYAML && a.Format != aclDocumentFormatJSON {
+		return fmt.Errorf("format must be either '%v' or '%v'", aclDocumentFormatYAML, aclDocumentFormatJSON)
+	}
+
+	return nil
+}
+
+func (api *API) handleExportACLs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse filter and format from url parameters. By default all ACLs will be exported.
+		decoder := schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		req := &getAclsOverviewRequest{
+			ResourceType:              int8(kmsg.ACLResourceTypeAny),
+			ResourcePatternTypeFilter: int(owl.ACLResourcePatternTypeAny),
+			Operation:                 int(kmsg.ACLOperationAny),
+			PermissionType:            int(kmsg.ACLPermissionTypeAny),
+		}
+		formatReq := &aclDocumentFormatRequest{Format: aclDocumentFormatYAML}
+		err := decoder.Decode(req, r.URL.Query())
+		if err == nil {
+			err = decoder.Decode(formatReq, r.URL.Query())
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Failed to parse request parameters",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		err = req.OK()
+		if err == nil {
+			err = formatReq.OK()
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to validate request parameters: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to list ACLs
+		if !api.checkACLPermission(w, r, api.Hooks.Owl.CanListACLs, "list") {
+			return
+		}
+
+		// 3. Export ACLs
+		doc, err := api.OwlSvc.ExportACLs(r.Context(), req.ToKafkaRequest())
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not list ACLs",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		var encoded []byte
+		contentType := "application/json"
+		if formatReq.Format == aclDocumentFormatYAML {
+			contentType = "application/x-yaml"
+			encoded, err = yaml.Marshal(doc)
+		} else {
+			encoded, err = json.MarshalIndent(doc, "", "  ")
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not encode ACL document",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"acls.%v\"", formatReq.Format))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(encoded)
+	}
+}
+
+func (api *API) handleImportACLs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse request parameters. The format defaults to the request's content type.
+		decoder := schema.NewDecoder()
+		formatReq := &aclDocumentFormatRequest{Format: aclDocumentFormatJSON}
+		if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+			formatReq.Format = aclDocumentFormatYAML
+		}
+		err := decoder.Decode(formatReq, r.URL.Query())
+		if err == nil {
+			err = formatReq.OK()
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request parameters: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Parse and validate ACL document
+		payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxACLDocumentSize))
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to read request body: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		var doc owl.AclDocument
+		if formatReq.Format == aclDocumentFormatYAML {
+			err = yaml.UnmarshalStrict(payload, &doc)
+		} else {
+			decoder := json.NewDecoder(bytes.NewReader(payload))
+			decoder.DisallowUnknownFields()
+			err = decoder.Decode(&doc)
+		}
+		if err == nil {
+			err = doc.OK()
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse ACL document: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 3. Check if logged in user is allowed to create and delete ACLs
+		if !api.checkACLPermission(w, r, api.Hooks.Owl.CanCreateACL, "create") {
+			return
+		}
+		if !api.checkACLPermission(w, r, api.Hooks.Owl.CanDeleteACL, "delete") {
+			return
+		}
+
+		// 4. Apply ACLs or return the diff if it's a dry run
+		res, err := api.OwlSvc.ApplyACLs(r.Context(), doc, formatReq.DryRun)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not apply ACLs: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+type simulateACLRequest struct {
+	Principal    string `schema:"principal"`
+	Host         string `schema:"host"`
+	Operation    string `schema:"operation"`
+	ResourceType string `schema:"resourceType"`
+	ResourceName string `schema:"resourceName"`
+}
+
+func (s *simulateACLRequest) ToOwlRequest() owl.AclSimulationRequest {
+	return owl.AclSimulationRequest{
+		Principal:    s.Principal,
+		Host:         s.Host,
+		Operation:    s.Operation,
+		ResourceType: s.ResourceType,
+		ResourceName: s.ResourceName,
+	}
+}
+
+func (api *API) handleSimulateACL() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse and validate request from url parameters
+		decoder := schema.NewDecoder()
+		req := &simulateACLRequest{}
+		err := decoder.Decode(req, r.URL.Query())
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Failed to parse request parameters",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		simulationReq := req.ToOwlRequest()
+		err = simulationReq.OK()
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to validate request parameters: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to list ACLs
+		if !api.checkACLPermission(w, r, api.Hooks.Owl.CanListACLs, "list") {
+			return
+		}
+
+		// 3. Simulate ACL evaluation
+		res, err := api.OwlSvc.SimulateACL(r.Context(), simulationReq)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not simulate ACL evaluation",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+// checkACLPermission runs the given ACL hook. If the requester is not allowed to perform the action an error response
+// will be sent and false is returned.
+func (api *API) checkACLPermission(w http.ResponseWriter, r *http.Request, hook func(ctx context.Context) (bool, *rest.Error), action string) bool {
+	isAllowed, restErr := hook(r.Context())
+	if restErr != nil {
+		rest.SendRESTError(w, r, api.Logger, restErr)
+		return false
+	}
+	if !isAllowed {
+		rest.SendRESTError(w, r, api.Logger, &rest.Error{
+			Err:      fmt.Errorf("requester is not allowed to %v ACLs", action),
+			Status:   http.StatusForbidden,
+			Message:  fmt.Sprintf("You are not allowed to %v ACLs", action),
+			IsSilent: true,
+		})
+		return false
+	}
+
+	return true
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/cloudhut/common/rest"
@@ -56,3 +57,42 @@ func (api *API) handleClusterConfig() http.HandlerFunc {
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, response)
 	}
 }
+
+func (api *API) handleGetClusters() http.HandlerFunc {
+	type clusterStatus struct {
+		Name        string `json:"name"`
+		IsAvailable bool   `json:"isAvailable"`
+		Error       string `json:"error,omitempty"`
+	}
+	type response struct {
+		Clusters []clusterStatus `json:"clusters"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		clusters := make([]clusterStatus, len(api.Clusters))
+		for i, cluster := range api.Clusters {
+			clusters[i] = clusterStatus{
+				Name:        cluster.Name,
+				IsAvailable: cluster.IsAvailable(),
+			}
+			if cluster.Err != nil {
+				clusters[i].Error = cluster.Err.Error()
+			}
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, response{Clusters: clusters})
+	}
+}
+
+// handleClusterUnavailable responds to all requests for a cluster whose services could not be started.
+func (api *API) handleClusterUnavailable(cluster *Cluster) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := &rest.Error{
+			Err:      cluster.Err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Cluster '%v' is unavailable: %v", cluster.Name, cluster.Err.Error()),
+			IsSilent: true,
+		}
+		rest.SendRESTError(w, r, api.Logger, restErr)
+	}
+}
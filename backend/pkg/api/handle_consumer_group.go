@@ -1,10 +1,28 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/cloudhut/common/rest"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+)
+
+const (
+	// consumerGroupActionResetOffsets must be returned by the AllowedConsumerGroupActions hook in order to edit the
+	// group offsets.
+	consumerGroupActionResetOffsets = "resetConsumerGroupOffsets"
+
+	// consumerGroupActionDeleteGroup must be returned by the AllowedConsumerGroupActions hook in order to delete the
+	// whole group.
+	consumerGroupActionDeleteGroup = "deleteConsumerGroup"
+
+	// consumerGroupActionDeleteOffsets must be returned by the AllowedConsumerGroupActions hook in order to delete
+	// single group offsets.
+	consumerGroupActionDeleteOffsets = "deleteConsumerGroupOffsets"
 )
 
 // GetConsumerGroupsResponse represents the data which is returned for listing topics
@@ -52,3 +70,356 @@ func (api *API) handleGetConsumerGroups() http.HandlerFunc {
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, response)
 	}
 }
+
+func (api *API) handleGetConsumerGroup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+
+		// 1. Check if logged in user is allowed to see the group
+		canSee, restErr := api.Hooks.Owl.CanSeeConsumerGroup(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		if !canSee {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view the consumer group"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view this consumer group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Describe group
+		group, restErr := api.OwlSvc.GetConsumerGroupDetails(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		group.AllowedActions, restErr = api.Hooks.Owl.AllowedConsumerGroupActions(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, group)
+	}
+}
+
+type patchConsumerGroupOffsetsRequest struct {
+	// DryRun returns the offsets that would be committed without actually committing them
+	DryRun bool `json:"dryRun"`
+
+	Topics []struct {
+		TopicName  string `json:"topicName"`
+		Partitions []struct {
+			PartitionID int32 `json:"partitionId"`
+
+			// Mode is one of: earliest, latest, timestamp, offset, shiftBy
+			Mode      string `json:"mode"`
+			Timestamp int64  `json:"timestamp"` // Unix timestamp in ms (mode timestamp)
+			Offset    int64  `json:"offset"`    // mode offset
+			ShiftBy   int64  `json:"shiftBy"`   // mode shiftBy
+		} `json:"partitions"`
+	} `json:"topics"`
+}
+
+func (p *patchConsumerGroupOffsetsRequest) OK() error {
+	if len(p.Topics) == 0 {
+		return fmt.Errorf("at least one topic and partition must be set")
+	}
+	for _, topic := range p.Topics {
+		if topic.TopicName == "" {
+			return fmt.Errorf("topic name must be set")
+		}
+		if len(topic.Partitions) == 0 {
+			return fmt.Errorf("topic '%v' has no partitions set whose offsets shall be reset", topic.TopicName)
+		}
+		for _, partition := range topic.Partitions {
+			switch partition.Mode {
+			case owl.ResetOffsetsModeEarliest, owl.ResetOffsetsModeLatest, owl.ResetOffsetsModeTimestamp, owl.ResetOffsetsModeShiftBy:
+			case owl.ResetOffsetsModeOffset:
+				if partition.Offset < 0 {
+					return fmt.Errorf("offset for partition '%v' in topic '%v' must not be negative", partition.PartitionID, topic.TopicName)
+				}
+			default:
+				return fmt.Errorf("unknown mode '%v' for partition '%v' in topic '%v'", partition.Mode, partition.PartitionID, topic.TopicName)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (api *API) handlePatchConsumerGroupOffsets() http.HandlerFunc {
+	type response struct {
+		GroupID string                                       `json:"groupId"`
+		DryRun  bool                                         `json:"dryRun"`
+		Topics  []owl.ResetConsumerGroupOffsetsTopicResponse `json:"topics"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+
+		// 1. Parse and validate request
+		var req patchConsumerGroupOffsetsRequest
+		err := rest.Decode(w, r, &req)
+		if err != nil {
+			var mr *rest.MalformedRequest
+			if errors.As(err, &mr) {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf(mr.Error()),
+					Status:   mr.Status,
+					Message:  mr.Message,
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to decode request payload: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to reset the group offsets
+		if !api.checkConsumerGroupAction(w, r, groupID, consumerGroupActionResetOffsets) {
+			return
+		}
+
+		// 3. Reset offsets
+		topics := make([]owl.ResetConsumerGroupOffsetsTopic, len(req.Topics))
+		for i, topic := range req.Topics {
+			partitions := make([]owl.ResetConsumerGroupOffsetsPartition, len(topic.Partitions))
+			for j, partition := range topic.Partitions {
+				partitions[j] = owl.ResetConsumerGroupOffsetsPartition{
+					PartitionID: partition.PartitionID,
+					Mode:        partition.Mode,
+					Timestamp:   partition.Timestamp,
+					Offset:      partition.Offset,
+					ShiftBy:     partition.ShiftBy,
+				}
+			}
+			topics[i] = owl.ResetConsumerGroupOffsetsTopic{
+				TopicName:  topic.TopicName,
+				Partitions: partitions,
+			}
+		}
+		resetReq := owl.ResetConsumerGroupOffsetsRequest{
+			GroupID: groupID,
+			DryRun:  req.DryRun,
+			Topics:  topics,
+		}
+		resetRes, restErr := api.OwlSvc.ResetConsumerGroupOffsets(r.Context(), resetReq)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		res := response{
+			GroupID: groupID,
+			DryRun:  req.DryRun,
+			Topics:  resetRes,
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+func (api *API) handleDeleteConsumerGroup() http.HandlerFunc {
+	type response struct {
+		GroupID string `json:"groupId"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+
+		// 1. Check if logged in user is allowed to delete the group
+		if !api.checkConsumerGroupAction(w, r, groupID, consumerGroupActionDeleteGroup) {
+			return
+		}
+
+		// 2. Delete group
+		err := api.OwlSvc.DeleteConsumerGroup(r.Context(), groupID)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to delete consumer group: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		res := response{GroupID: groupID}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+type deleteConsumerGroupOffsetsRequest struct {
+	Topics []struct {
+		TopicName    string  `json:"topicName"`
+		PartitionIDs []int32 `json:"partitionIds"`
+	} `json:"topics"`
+}
+
+func (d *deleteConsumerGroupOffsetsRequest) OK() error {
+	if len(d.Topics) == 0 {
+		return fmt.Errorf("at least one topic and partition must be set")
+	}
+	for _, topic := range d.Topics {
+		if topic.TopicName == "" {
+			return fmt.Errorf("topic name must be set")
+		}
+		if len(topic.PartitionIDs) == 0 {
+			return fmt.Errorf("topic '%v' has no partitions set whose offsets shall be deleted", topic.TopicName)
+		}
+	}
+
+	return nil
+}
+
+func (api *API) handleDeleteConsumerGroupOffsets() http.HandlerFunc {
+	type response struct {
+		Topics []owl.DeleteConsumerGroupOffsetsResponse `json:"topics"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+
+		// 1. Parse and validate request
+		var req deleteConsumerGroupOffsetsRequest
+		err := rest.Decode(w, r, &req)
+		if err != nil {
+			var mr *rest.MalformedRequest
+			if errors.As(err, &mr) {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf(mr.Error()),
+					Status:   mr.Status,
+					Message:  mr.Message,
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to decode request payload: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to delete group offsets
+		if !api.checkConsumerGroupAction(w, r, groupID, consumerGroupActionDeleteOffsets) {
+			return
+		}
+
+		// 3. Delete group offsets
+		topics := make([]owl.DeleteConsumerGroupOffsetsTopic, len(req.Topics))
+		for i, topic := range req.Topics {
+			topics[i] = owl.DeleteConsumerGroupOffsetsTopic{
+				TopicName:    topic.TopicName,
+				PartitionIDs: topic.PartitionIDs,
+			}
+		}
+		deleteRes, err := api.OwlSvc.DeleteConsumerGroupOffsets(r.Context(), groupID, topics)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to delete consumer group offsets: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		res := response{Topics: deleteRes}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+// defaultLagHistoryWindow is used if the lag history is requested without a window
+const defaultLagHistoryWindow = time.Hour
+
+func (api *API) handleGetConsumerGroupLagHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+
+		// 1. Parse and validate request
+		window := defaultLagHistoryWindow
+		if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+			parsed, err := time.ParseDuration(windowStr)
+			if err != nil || parsed <= 0 {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf("invalid window '%v' given", windowStr),
+					Status:   http.StatusBadRequest,
+					Message:  "The window must be a positive duration such as '30m' or '1h'",
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+			window = parsed
+		}
+
+		// 2. Check if logged in user is allowed to see the group
+		canSee, restErr := api.Hooks.Owl.CanSeeConsumerGroup(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		if !canSee {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view the consumer group"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view this consumer group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 3. Get lag history
+		history := api.OwlSvc.GetConsumerGroupLagHistory(groupID, window)
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, history)
+	}
+}
+
+// checkConsumerGroupAction checks whether the requester can see the consumer group and is allowed to run the given
+// action on it. If that's not the case an error response will be sent and false is returned.
+func (api *API) checkConsumerGroupAction(w http.ResponseWriter, r *http.Request, groupID string, action string) bool {
+	canSee, restErr := api.Hooks.Owl.CanSeeConsumerGroup(r.Context(), groupID)
+	if restErr != nil {
+		rest.SendRESTError(w, r, api.Logger, restErr)
+		return false
+	}
+	allowedActions, restErr := api.Hooks.Owl.AllowedConsumerGroupActions(r.Context(), groupID)
+	if restErr != nil {
+		rest.SendRESTError(w, r, api.Logger, restErr)
+		return false
+	}
+	if !canSee || !isActionAllowed(allowedActions, action) {
+		rest.SendRESTError(w, r, api.Logger, &rest.Error{
+			Err:      fmt.Errorf("requester has no permissions to run action '%v' on consumer group", action),
+			Status:   http.StatusForbidden,
+			Message:  "You don't have permissions to run this action on the consumer group",
+			IsSilent: false,
+		})
+		return false
+	}
+
+	return true
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
-	"github.com/cloudhut/common/rest"
 	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
 )
 
 func (api *API) handleLivenessProbe() http.HandlerFunc {
@@ -20,22 +22,44 @@ func (api *API) handleLivenessProbe() http.HandlerFunc {
 }
 
 func (api *API) handleStartupProbe() http.HandlerFunc {
+	type clusterHealth struct {
+		Name      string `json:"name"`
+		IsKafkaOk bool   `json:"isKafkaOk"`
+		// SchemaRegistry contains the health of each registry url, if a schema registry is configured
+		SchemaRegistry []schema.URLHealth `json:"schemaRegistry,omitempty"`
+	}
 	type response struct {
-		IsHTTPOk  bool `json:"isHttpOk"`
-		IsKafkaOk bool `json:"isKafkaOk"`
+		IsHTTPOk  bool            `json:"isHttpOk"`
+		IsKafkaOk bool            `json:"isKafkaOk"`
+		Clusters  []clusterHealth `json:"clusters"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Check Kafka connectivity
+		// Check Kafka connectivity of all clusters. Unavailable clusters do not affect the overall health, because
+		// they do not prevent the other clusters from being served.
 		isKafkaOK := false
-		err := api.KafkaSvc.IsHealthy(r.Context())
-		if err == nil {
-			isKafkaOK = true
+		clusters := make([]clusterHealth, len(api.Clusters))
+		for i, cluster := range api.Clusters {
+			clusters[i] = clusterHealth{Name: cluster.Name}
+			if !cluster.IsAvailable() {
+				continue
+			}
+			err := cluster.KafkaSvc.IsHealthy(r.Context())
+			if err == nil {
+				clusters[i].IsKafkaOk = true
+				isKafkaOK = true
+			}
+
+			// Inaccessible registry urls are reported only, as requests fail over to the other urls
+			if cluster.KafkaSvc.SchemaService != nil {
+				clusters[i].SchemaRegistry, _ = cluster.KafkaSvc.SchemaService.CheckConnectivity(r.Context())
+			}
 		}
 
 		res := &response{
 			IsHTTPOk:  true,
 			IsKafkaOk: isKafkaOK,
+			Clusters:  clusters,
 		}
 
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
@@ -1,10 +1,15 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/cloudhut/common/rest"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
 	"github.com/go-chi/chi"
-	"net/http"
 )
 
 func (api *API) handleGetSchemaOverview() http.HandlerFunc {
@@ -49,7 +54,8 @@ func (api *API) handleGetSchemaDetails() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		subject := chi.URLParam(r, "subject")
 		version := chi.URLParam(r, "version")
-		schemaDetails, err := api.OwlSvc.GetSchemaDetails(r.Context(), subject, version)
+		compareToVersion := r.URL.Query().Get("compareTo")
+		schemaDetails, err := api.OwlSvc.GetSchemaDetails(r.Context(), subject, version, compareToVersion)
 		if err != nil {
 			if err == owl.ErrSchemaRegistryNotConfigured {
 				rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
@@ -74,3 +80,414 @@ func (api *API) handleGetSchemaDetails() http.HandlerFunc {
 		})
 	}
 }
+
+// sendSchemaRegistryError sends an error response for a failed schema registry request. Errors returned by the
+// schema registry carry an error code whose first three digits are the HTTP status (e.g. 40401 - subject not found),
+// these are forwarded so that the frontend can tell apart invalid requests from an unavailable registry.
+func (api *API) sendSchemaRegistryError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if err == owl.ErrSchemaRegistryNotConfigured {
+		rest.SendRESTError(w, r, api.Logger, &rest.Error{
+			Err:      err,
+			Status:   http.StatusBadRequest,
+			Message:  "No schema registry is configured",
+			IsSilent: true,
+		})
+		return
+	}
+
+	status := http.StatusServiceUnavailable
+	var registryErr *schema.RestError
+	if errors.As(err, &registryErr) {
+		if registryStatus := registryErr.ErrorCode / 100; registryStatus >= 400 && registryStatus < 500 {
+			status = registryStatus
+		}
+	}
+	rest.SendRESTError(w, r, api.Logger, &rest.Error{
+		Err:      err,
+		Status:   status,
+		Message:  fmt.Sprintf("%v: %v", message, err.Error()),
+		IsSilent: false,
+	})
+}
+
+// checkSchemaPermission sends an error response and returns false if the hook returned an error or did not allow
+// the action.
+func (api *API) checkSchemaPermission(w http.ResponseWriter, r *http.Request, isAllowed bool, restErr *rest.Error, action string) bool {
+	if restErr != nil {
+		rest.SendRESTError(w, r, api.Logger, restErr)
+		return false
+	}
+	if !isAllowed {
+		rest.SendRESTError(w, r, api.Logger, &rest.Error{
+			Err:      fmt.Errorf("requester is not allowed to %v", action),
+			Status:   http.StatusForbidden,
+			Message:  fmt.Sprintf("You are not allowed to %v", action),
+			IsSilent: true,
+		})
+		return false
+	}
+
+	return true
+}
+
+// decodeSchemaRequest decodes and validates the request body. If that fails an error response is sent and false
+// is returned.
+func (api *API) decodeSchemaRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	err := rest.Decode(w, r, req)
+	if err != nil {
+		var mr *rest.MalformedRequest
+		if errors.As(err, &mr) {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      fmt.Errorf(mr.Error()),
+				Status:   mr.Status,
+				Message:  mr.Message,
+				IsSilent: false,
+			})
+			return false
+		}
+
+		rest.SendRESTError(w, r, api.Logger, &rest.Error{
+			Err:      err,
+			Status:   http.StatusInternalServerError,
+			Message:  fmt.Sprintf("Failed to decode request payload: %v", err.Error()),
+			IsSilent: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+type registerSchemaRequest struct {
+	Schema string `json:"schema"`
+	// SchemaType is optional and defaults to Avro
+	SchemaType string                   `json:"schemaType"`
+	References []schema.SchemaReference `json:"references"`
+}
+
+func (r *registerSchemaRequest) OK() error {
+	if r.Schema == "" {
+		return fmt.Errorf("schema must be set")
+	}
+
+	switch r.SchemaType {
+	case "", schema.SchemaTypeAvro, schema.SchemaTypeProtobuf, schema.SchemaTypeJSON:
+	default:
+		return fmt.Errorf("schemaType must be one of %v, %v, %v", schema.SchemaTypeAvro, schema.SchemaTypeProtobuf, schema.SchemaTypeJSON)
+	}
+
+	for _, reference := range r.References {
+		if reference.Name == "" || reference.Subject == "" || reference.Version <= 0 {
+			return fmt.Errorf("references must have a name, subject and version")
+		}
+	}
+
+	return nil
+}
+
+func (r *registerSchemaRequest) toRegistryRequest() schema.RegisterSchemaRequest {
+	return schema.RegisterSchemaRequest{
+		Schema:     r.Schema,
+		SchemaType: r.SchemaType,
+		References: r.References,
+	}
+}
+
+func (api *API) handleRegisterSchema() http.HandlerFunc {
+	type response struct {
+		Subject  string `json:"subject"`
+		SchemaID int    `json:"schemaId"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject := chi.URLParam(r, "subject")
+
+		// 1. Parse and validate request
+		var req registerSchemaRequest
+		if !api.decodeSchemaRequest(w, r, &req) {
+			return
+		}
+
+		// 2. Check if logged in user is allowed to register schemas for this subject
+		isAllowed, restErr := api.Hooks.Owl.CanRegisterSchema(r.Context(), subject)
+		if !api.checkSchemaPermission(w, r, isAllowed, restErr, "register schemas for this subject") {
+			return
+		}
+
+		// 3. Register schema
+		res, err := api.OwlSvc.RegisterSchema(r.Context(), subject, req.toRegistryRequest())
+		if err != nil {
+			api.sendSchemaRegistryError(w, r, err, "Failed to register schema")
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Subject:  subject,
+			SchemaID: res.SchemaID,
+		})
+	}
+}
+
+func (api *API) handleDeleteSubject() http.HandlerFunc {
+	type response struct {
+		Subject         string `json:"subject"`
+		Permanent       bool   `json:"permanent"`
+		DeletedVersions []int  `json:"deletedVersions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject := chi.URLParam(r, "subject")
+		permanent := r.URL.Query().Get("permanent") == "true"
+
+		// 1. Check if logged in user is allowed to delete schemas of this subject
+		isAllowed, restErr := api.Hooks.Owl.CanDeleteSchema(r.Context(), subject)
+		if !api.checkSchemaPermission(w, r, isAllowed, restErr, "delete schemas of this subject") {
+			return
+		}
+
+		// 2. Delete subject
+		res, err := api.OwlSvc.DeleteSubject(r.Context(), subject, permanent)
+		if err != nil {
+			api.sendSchemaRegistryError(w, r, err, "Failed to delete subject")
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Subject:         subject,
+			Permanent:       permanent,
+			DeletedVersions: res.Versions,
+		})
+	}
+}
+
+func (api *API) handleDeleteSubjectVersion() http.HandlerFunc {
+	type response struct {
+		Subject        string `json:"subject"`
+		Permanent      bool   `json:"permanent"`
+		DeletedVersion int    `json:"deletedVersion"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject := chi.URLParam(r, "subject")
+		version := chi.URLParam(r, "version")
+		permanent := r.URL.Query().Get("permanent") == "true"
+
+		// 1. Check if logged in user is allowed to delete schemas of this subject
+		isAllowed, restErr := api.Hooks.Owl.CanDeleteSchema(r.Context(), subject)
+		if !api.checkSchemaPermission(w, r, isAllowed, restErr, "delete schemas of this subject") {
+			return
+		}
+
+		// 2. Delete subject version
+		res, err := api.OwlSvc.DeleteSubjectVersion(r.Context(), subject, version, permanent)
+		if err != nil {
+			api.sendSchemaRegistryError(w, r, err, "Failed to delete subject version")
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Subject:        subject,
+			Permanent:      permanent,
+			DeletedVersion: res.Version,
+		})
+	}
+}
+
+// schemaCompatibilityLevels are all compatibility levels supported by the schema registry
+var schemaCompatibilityLevels = []string{
+	"BACKWARD", "BACKWARD_TRANSITIVE", "FORWARD", "FORWARD_TRANSITIVE", "FULL", "FULL_TRANSITIVE", "NONE",
+}
+
+type putSchemaConfigRequest struct {
+	Compatibility string `json:"compatibility"`
+}
+
+func (p *putSchemaConfigRequest) OK() error {
+	for _, level := range schemaCompatibilityLevels {
+		if p.Compatibility == level {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("compatibility must be one of %v", strings.Join(schemaCompatibilityLevels, ", "))
+}
+
+func (api *API) handlePutSchemaConfig() http.HandlerFunc {
+	type response struct {
+		Compatibility string `json:"compatibility"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse and validate request
+		var req putSchemaConfigRequest
+		if !api.decodeSchemaRequest(w, r, &req) {
+			return
+		}
+
+		// 2. Check if logged in user is allowed to edit the global compatibility level
+		isAllowed, restErr := api.Hooks.Owl.CanEditSchemaCompatibility(r.Context(), "")
+		if !api.checkSchemaPermission(w, r, isAllowed, restErr, "edit the global compatibility level") {
+			return
+		}
+
+		// 3. Set compatibility level
+		res, err := api.OwlSvc.PutSchemaRegistryConfig(r.Context(), req.Compatibility)
+		if err != nil {
+			api.sendSchemaRegistryError(w, r, err, "Failed to set global compatibility level")
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Compatibility: res.Compatibility,
+		})
+	}
+}
+
+func (api *API) handlePutSchemaSubjectConfig() http.HandlerFunc {
+	type response struct {
+		Subject       string `json:"subject"`
+		Compatibility string `json:"compatibility"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject := chi.URLParam(r, "subject")
+
+		// 1. Parse and validate request
+		var req putSchemaConfigRequest
+		if !api.decodeSchemaRequest(w, r, &req) {
+			return
+		}
+
+		// 2. Check if logged in user is allowed to edit the subject's compatibility level
+		isAllowed, restErr := api.Hooks.Owl.CanEditSchemaCompatibility(r.Context(), subject)
+		if !api.checkSchemaPermission(w, r, isAllowed, restErr, "edit the compatibility level of this subject") {
+			return
+		}
+
+		// 3. Set compatibility level
+		res, err := api.OwlSvc.PutSchemaRegistrySubjectConfig(r.Context(), subject, req.Compatibility)
+		if err != nil {
+			api.sendSchemaRegistryError(w, r, err, "Failed to set compatibility level for subject")
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Subject:       subject,
+			Compatibility: res.Compatibility,
+		})
+	}
+}
+
+// schemaRegistryModes are all modes supported by the schema registry
+var schemaRegistryModes = []string{"IMPORT", "READONLY", "READWRITE"}
+
+type putSchemaModeRequest struct {
+	Mode string `json:"mode"`
+}
+
+func (p *putSchemaModeRequest) OK() error {
+	for _, mode := range schemaRegistryModes {
+		if p.Mode == mode {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("mode must be one of %v", strings.Join(schemaRegistryModes, ", "))
+}
+
+func (api *API) handlePutSchemaMode() http.HandlerFunc {
+	type response struct {
+		Mode string `json:"mode"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse and validate request
+		var req putSchemaModeRequest
+		if !api.decodeSchemaRequest(w, r, &req) {
+			return
+		}
+
+		// 2. Check if logged in user is allowed to edit the mode
+		isAllowed, restErr := api.Hooks.Owl.CanEditSchemaRegistryMode(r.Context())
+		if !api.checkSchemaPermission(w, r, isAllowed, restErr, "edit the schema registry mode") {
+			return
+		}
+
+		// 3. Set mode
+		res, err := api.OwlSvc.PutSchemaRegistryMode(r.Context(), req.Mode)
+		if err != nil {
+			api.sendSchemaRegistryError(w, r, err, "Failed to set schema registry mode")
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Mode: res.Mode,
+		})
+	}
+}
+
+func (api *API) handleCheckSchemaCompatibility() http.HandlerFunc {
+	type response struct {
+		Subject      string   `json:"subject"`
+		Version      string   `json:"version"`
+		IsCompatible bool     `json:"isCompatible"`
+		Messages     []string `json:"messages"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject := chi.URLParam(r, "subject")
+		version := chi.URLParam(r, "version")
+
+		// 1. Parse and validate request
+		var req registerSchemaRequest
+		if !api.decodeSchemaRequest(w, r, &req) {
+			return
+		}
+
+		// 2. Check compatibility
+		res, err := api.OwlSvc.CheckSchemaCompatibility(r.Context(), subject, version, req.toRegistryRequest())
+		if err != nil {
+			api.sendSchemaRegistryError(w, r, err, "Failed to check schema compatibility")
+			return
+		}
+
+		messages := res.Messages
+		if messages == nil {
+			messages = []string{}
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Subject:      subject,
+			Version:      version,
+			IsCompatible: res.IsCompatible,
+			Messages:     messages,
+		})
+	}
+}
+
+// handlePurgeSchemaCache removes all cached schemas and subject metadata of all clusters, so that changes in the
+// schema registry become visible immediately.
+func (api *API) handlePurgeSchemaCache() http.HandlerFunc {
+	type clusterPurge struct {
+		Name          string `json:"name"`
+		PurgedEntries int    `json:"purgedEntries"`
+	}
+	type response struct {
+		Clusters []clusterPurge `json:"clusters"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		clusters := make([]clusterPurge, 0, len(api.Clusters))
+		for _, cluster := range api.Clusters {
+			if !cluster.IsAvailable() || cluster.KafkaSvc.SchemaService == nil {
+				continue
+			}
+			clusters = append(clusters, clusterPurge{
+				Name:          cluster.Name,
+				PurgedEntries: cluster.KafkaSvc.SchemaService.PurgeCache(),
+			})
+		}
+
+		api.Logger.Info("purged schema registry cache")
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{Clusters: clusters})
+	}
+}
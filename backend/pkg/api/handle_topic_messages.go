@@ -3,12 +3,17 @@ package api
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"github.com/gorilla/schema"
+	"go.uber.org/zap"
 
 	"github.com/cloudhut/common/rest"
 )
@@ -27,6 +32,8 @@ type ListMessagesRequest struct {
 	PartitionID           int32  `json:"partitionId"`    // -1 for all partition ids
 	MaxResults            int    `json:"maxResults"`
 	FilterInterpreterCode string `json:"filterInterpreterCode"` // Base64 encoded code
+	KeyEncoding           string `json:"keyEncoding"`           // Forces the key encoding (e.g. int64be), empty for automatic detection
+	ValueEncoding         string `json:"valueEncoding"`         // Forces the value encoding (e.g. protobuf), empty for automatic detection
 }
 
 func (l *ListMessagesRequest) OK() error {
@@ -53,6 +60,19 @@ func (l *ListMessagesRequest) OK() error {
 	return nil
 }
 
+// validateEncodingHints checks that the optionally requested key and value encodings are supported. Which encodings
+// are supported depends on the decoders that have been registered for the cluster.
+func (api *API) validateEncodingHints(keyEncoding string, valueEncoding string) error {
+	if keyEncoding != "" && !api.KafkaSvc.IsSupportedEncoding(keyEncoding) {
+		return fmt.Errorf("unsupported key encoding '%v'", keyEncoding)
+	}
+	if valueEncoding != "" && !api.KafkaSvc.IsSupportedEncoding(valueEncoding) {
+		return fmt.Errorf("unsupported value encoding '%v'", valueEncoding)
+	}
+
+	return nil
+}
+
 func (l *ListMessagesRequest) DecodeInterpreterCode() (string, error) {
 	code, err := base64.StdEncoding.DecodeString(l.FilterInterpreterCode)
 	if err != nil {
@@ -106,6 +126,11 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 			sendError(fmt.Sprintf("Failed to validate list message request: %v", err))
 			return
 		}
+		err = api.validateEncodingHints(req.KeyEncoding, req.ValueEncoding)
+		if err != nil {
+			sendError(fmt.Sprintf("Failed to validate list message request: %v", err))
+			return
+		}
 
 		// Check if logged in user is allowed to list messages for the given request
 		canViewMessages, restErr := api.Hooks.Owl.CanViewTopicMessages(r.Context(), req.TopicName)
@@ -140,6 +165,8 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 			StartTimestamp:        req.StartTimestamp,
 			MessageCount:          req.MaxResults,
 			FilterInterpreterCode: interpreterCode,
+			KeyEncoding:           req.KeyEncoding,
+			ValueEncoding:         req.ValueEncoding,
 		}
 		api.Hooks.Owl.PrintListMessagesAuditLog(r, &listReq)
 
@@ -168,3 +195,360 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 		}
 	}
 }
+
+type produceRecordsRequest struct {
+	// Partitioner is used for all records that have no explicit partition. Supported partitioners are stickyKey
+	// (default), sticky and roundRobin.
+	Partitioner string `json:"partitioner"`
+
+	// Compression for the produced record batches: uncompressed (default), gzip, snappy, lz4 or zstd
+	Compression string `json:"compression"`
+
+	Records []produceRecordsRequestRecord `json:"records"`
+}
+
+func (p *produceRecordsRequest) OK() error {
+	if len(p.Records) == 0 {
+		return fmt.Errorf("at least one record must be set")
+	}
+	for i, record := range p.Records {
+		err := record.OK()
+		if err != nil {
+			return fmt.Errorf("failed to validate record with index '%d': %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+type produceRecordsRequestRecord struct {
+	// PartitionID the record shall be produced to. If it's not set or -1 the partitioner chooses the partition.
+	PartitionID *int32                        `json:"partitionId"`
+	Key         *produceRecordsRequestPayload `json:"key"`
+	Value       *produceRecordsRequestPayload `json:"value"` // null produces a tombstone
+	Headers     []struct {
+		Key   string                       `json:"key"`
+		Value produceRecordsRequestPayload `json:"value"`
+	} `json:"headers"`
+}
+
+func (p *produceRecordsRequestRecord) OK() error {
+	if p.PartitionID != nil && *p.PartitionID < -1 {
+		return fmt.Errorf("partitionId is smaller than -1")
+	}
+	for _, header := range p.Headers {
+		if header.Key == "" {
+			return fmt.Errorf("header keys must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// partitionID returns the requested partition id or -1 if the partitioner shall choose the partition.
+func (p *produceRecordsRequestRecord) partitionID() int32 {
+	if p.PartitionID == nil {
+		return -1
+	}
+	return *p.PartitionID
+}
+
+type produceRecordsRequestPayload struct {
+	// Encoding of the given data: none, text, json, binary (base64 encoded), avro or protobuf
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+
+	// SchemaID is the schema registry's schema id which will be used for avro payloads
+	SchemaID uint32 `json:"schemaId"`
+}
+
+func (p *produceRecordsRequestPayload) toKafkaPayload() *kafka.ProducePayload {
+	if p == nil {
+		return nil
+	}
+
+	return &kafka.ProducePayload{
+		Encoding: p.Encoding,
+		Data:     p.Data,
+		SchemaID: p.SchemaID,
+	}
+}
+
+func (api *API) handleProduceRecords() http.HandlerFunc {
+	type response struct {
+		TopicName string                      `json:"topicName"`
+		Records   []owl.ProduceRecordResponse `json:"records"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		// 1. Parse and validate request
+		var req produceRecordsRequest
+		err := rest.Decode(w, r, &req)
+		if err != nil {
+			var mr *rest.MalformedRequest
+			if errors.As(err, &mr) {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf(mr.Error()),
+					Status:   mr.Status,
+					Message:  mr.Message,
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, logger, restErr)
+				return
+			}
+
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to decode request payload: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to produce records to the given topic
+		canProduce, restErr := api.Hooks.Owl.CanProduceToTopic(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canProduce {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to produce records to the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to produce records to that topic",
+				IsSilent: false,
+			})
+			return
+		}
+
+		// 3. Produce records
+		records := make([]owl.ProduceRecord, len(req.Records))
+		for i, record := range req.Records {
+			headers := make([]owl.ProduceRecordHeader, len(record.Headers))
+			for j, header := range record.Headers {
+				headers[j] = owl.ProduceRecordHeader{
+					Key:   header.Key,
+					Value: *header.Value.toKafkaPayload(),
+				}
+			}
+			records[i] = owl.ProduceRecord{
+				PartitionID: record.partitionID(),
+				Key:         record.Key.toKafkaPayload(),
+				Value:       record.Value.toKafkaPayload(),
+				Headers:     headers,
+			}
+		}
+
+		produceReq := owl.ProduceRecordsRequest{
+			TopicName:   topicName,
+			Partitioner: req.Partitioner,
+			Compression: req.Compression,
+			Records:     records,
+		}
+		produced, restErr := api.OwlSvc.ProduceRecords(r.Context(), produceReq)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		res := response{
+			TopicName: topicName,
+			Records:   produced,
+		}
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+type exportMessagesRequest struct {
+	StartOffset           int64    `schema:"startOffset"`    // -1 for recent (newest - results), -2 for oldest offset, -3 for newest, -4 for timestamp
+	StartTimestamp        int64    `schema:"startTimestamp"` // Start offset by unix timestamp in ms (only considered if start offset is set to -4)
+	PartitionID           int32    `schema:"partitionId"`    // -1 for all partition ids
+	MaxResults            int      `schema:"maxResults"`
+	FilterInterpreterCode string   `schema:"filterInterpreterCode"` // Base64 encoded code
+	Format                string   `schema:"format"`                // jsonl or csv
+	Columns               []string `schema:"columns"`               // Defaults to all columns
+	KeyEncoding           string   `schema:"keyEncoding"`           // Forces the key encoding, empty for automatic detection
+	ValueEncoding         string   `schema:"valueEncoding"`         // Forces the value encoding, empty for automatic detection
+}
+
+func (e *exportMessagesRequest) OK() error {
+	if e.StartOffset < -4 {
+		return fmt.Errorf("start offset is smaller than -4")
+	}
+
+	if e.StartOffset == owl.StartOffsetNewest {
+		return fmt.Errorf("exports can not follow the newest messages (live tail)")
+	}
+
+	if e.PartitionID < -1 {
+		return fmt.Errorf("partitionID is smaller than -1")
+	}
+
+	if e.MaxResults <= 0 || e.MaxResults > 1000000 {
+		return fmt.Errorf("max results must be between 1 and 1000000")
+	}
+
+	if e.Format != exportFormatJSONL && e.Format != exportFormatCSV {
+		return fmt.Errorf("format must be either '%v' or '%v'", exportFormatJSONL, exportFormatCSV)
+	}
+
+	for _, column := range e.Columns {
+		isKnown := false
+		for _, knownColumn := range exportColumnsAll {
+			if column == knownColumn {
+				isKnown = true
+				break
+			}
+		}
+		if !isKnown {
+			return fmt.Errorf("unknown column '%v'", column)
+		}
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(e.FilterInterpreterCode); err != nil {
+		return fmt.Errorf("failed to decode interpreter code %w", err)
+	}
+
+	return nil
+}
+
+func (api *API) handleExportMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		// 1. Parse and validate request parameters
+		req := &exportMessagesRequest{
+			StartOffset: owl.StartOffsetOldest,
+			PartitionID: -1, // All partitions
+			MaxResults:  1000,
+			Format:      exportFormatJSONL,
+		}
+		decoder := schema.NewDecoder()
+		err := decoder.Decode(req, r.URL.Query())
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Failed to parse request parameters",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		err = req.OK()
+		if err == nil {
+			err = api.validateEncodingHints(req.KeyEncoding, req.ValueEncoding)
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to validate request parameters: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if len(req.Columns) == 0 {
+			req.Columns = exportColumnsAll
+		}
+
+		// 2. Check if logged in user is allowed to export messages for the given request
+		canViewMessages, restErr := api.Hooks.Owl.CanViewTopicMessages(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canViewMessages {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view messages in the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view messages in this topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		interpreterCode, _ := base64.StdEncoding.DecodeString(req.FilterInterpreterCode) // Error has been checked in validation function
+		if len(interpreterCode) > 0 {
+			canUseMessageSearchFilters, restErr := api.Hooks.Owl.CanUseMessageSearchFilters(r.Context(), topicName)
+			if restErr != nil {
+				rest.SendRESTError(w, r, logger, restErr)
+				return
+			}
+			if !canUseMessageSearchFilters {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf("requester has no permissions to use message filters in the requested topic"),
+					Status:   http.StatusForbidden,
+					Message:  "You don't have permissions to use message filters in this topic",
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, logger, restErr)
+				return
+			}
+		}
+
+		listReq := owl.ListMessageRequest{
+			TopicName:             topicName,
+			PartitionID:           req.PartitionID,
+			StartOffset:           req.StartOffset,
+			StartTimestamp:        req.StartTimestamp,
+			MessageCount:          req.MaxResults,
+			FilterInterpreterCode: string(interpreterCode),
+			KeyEncoding:           req.KeyEncoding,
+			ValueEncoding:         req.ValueEncoding,
+		}
+		api.Hooks.Owl.PrintListMessagesAuditLog(r, &listReq)
+
+		// 3. Stream the messages into the response
+		filename := fmt.Sprintf("%v.%v", topicName, req.Format)
+		api.streamExport(w, r, logger, req.Format, req.Columns, filename, func(ctx context.Context, progress kafka.IListMessagesProgress) error {
+			return api.OwlSvc.ListMessages(ctx, listReq, progress)
+		})
+	}
+}
+
+// streamExport writes all messages which are passed to the progress reporter by listMessages into the response.
+// The HTTP server closes the connection once its write timeout has passed, hence the export is stopped shortly
+// before that. This way the client is still told via the error trailer that the export has been truncated.
+func (api *API) streamExport(w http.ResponseWriter, r *http.Request, logger *zap.Logger, format string, columns []string, filename string, listMessages func(ctx context.Context, progress kafka.IListMessagesProgress) error) {
+	timeout := api.exportTimeout()
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	progress := newExportProgressReporter(logger, w, format, columns)
+	progress.Start(filename)
+
+	err := listMessages(ctx, progress)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("export has been truncated because it did not complete within %v. The export duration is limited by the server's write timeout", timeout)
+	}
+
+	// Errors that occur while consuming are reported by ListMessages itself, all others (e.g. unknown topic) are not
+	if err != nil && !progress.errorReported {
+		progress.OnError(err.Error())
+	}
+}
+
+// exportTimeout returns how long a single export may take. The server's write timeout applies to the whole response,
+// so we leave a tenth of it to write the trailer.
+func (api *API) exportTimeout() time.Duration {
+	writeTimeout := api.Cfg.REST.HTTPServerWriteTimeout
+	if writeTimeout <= 0 {
+		return exportMaxDuration
+	}
+
+	timeout := writeTimeout - writeTimeout/10
+	if timeout > exportMaxDuration {
+		return exportMaxDuration
+	}
+	return timeout
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProduceRecordsRequest_PartitionID(t *testing.T) {
+	body := `{"records": [
+		{"value": {"encoding": "text", "data": "partitioner"}},
+		{"partitionId": -1, "value": {"encoding": "text", "data": "explicit partitioner"}},
+		{"partitionId": 0, "value": {"encoding": "text", "data": "first partition"}},
+		{"partitionId": 3, "value": {"encoding": "text", "data": "fourth partition"}}
+	]}`
+
+	var req produceRecordsRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+	require.NoError(t, req.OK())
+
+	partitionIDs := make([]int32, len(req.Records))
+	for i, record := range req.Records {
+		partitionIDs[i] = record.partitionID()
+	}
+	assert.Equal(t, []int32{-1, -1, 0, 3}, partitionIDs, "records without partitionId must use the partitioner")
+
+	err := json.Unmarshal([]byte(`{"records": [{"partitionId": -2}]}`), &req)
+	require.NoError(t, err)
+	assert.Error(t, req.OK(), "expected an error for partition ids smaller than -1")
+}
+
+func newExportTestMessage(partitionID int32, offset int64, key string, value string) *kafka.TopicMessage {
+	return &kafka.TopicMessage{
+		PartitionID: partitionID,
+		Offset:      offset,
+		Timestamp:   1609459200000,
+		Key:         &kafka.DeserializedPayload{Payload: kafka.NormalizedPayload{Payload: []byte(key), RecognizedEncoding: "text"}},
+		Value:       &kafka.DeserializedPayload{Payload: kafka.NormalizedPayload{Payload: []byte(value), RecognizedEncoding: "json"}},
+	}
+}
+
+func TestAPI_StreamExport(t *testing.T) {
+	messages := []*kafka.TopicMessage{
+		newExportTestMessage(0, 10, "user-1", `{"name":"Jane"}`),
+		newExportTestMessage(1, 20, "user-2", `{"name":"John"}`),
+	}
+	sendMessages := func(ctx context.Context, progress kafka.IListMessagesProgress) error {
+		for _, msg := range messages {
+			progress.OnMessage(msg)
+		}
+		progress.OnComplete(1, false)
+		return nil
+	}
+
+	tt := []struct {
+		name         string
+		format       string
+		columns      []string
+		listMessages func(ctx context.Context, progress kafka.IListMessagesProgress) error
+		contentType  string
+		body         string
+		trailerError string
+	}{
+		{
+			name:         "jsonl",
+			format:       exportFormatJSONL,
+			columns:      []string{exportColumnOffset, exportColumnKey, exportColumnValue},
+			listMessages: sendMessages,
+			contentType:  "application/x-ndjson",
+			body: `{"offset":10,"key":"user-1","value":{"name":"Jane"}}` + "\n" +
+				`{"offset":20,"key":"user-2","value":{"name":"John"}}` + "\n",
+		},
+		{
+			name:         "csv",
+			format:       exportFormatCSV,
+			columns:      []string{exportColumnPartition, exportColumnKey, exportColumnValue},
+			listMessages: sendMessages,
+			contentType:  "text/csv",
+			body:         "partition,key,value\n0,user-1,\"{\"\"name\"\":\"\"Jane\"\"}\"\n1,user-2,\"{\"\"name\"\":\"\"John\"\"}\"\n",
+		},
+		{
+			name:    "error before consuming",
+			format:  exportFormatJSONL,
+			columns: exportColumnsAll,
+			listMessages: func(ctx context.Context, progress kafka.IListMessagesProgress) error {
+				return fmt.Errorf("failed to get partitions: UNKNOWN_TOPIC_OR_PARTITION")
+			},
+			contentType:  "application/x-ndjson",
+			trailerError: "failed to get partitions: UNKNOWN_TOPIC_OR_PARTITION",
+		},
+		{
+			name:    "error while consuming",
+			format:  exportFormatCSV,
+			columns: []string{exportColumnOffset},
+			listMessages: func(ctx context.Context, progress kafka.IListMessagesProgress) error {
+				progress.OnMessage(messages[0])
+				progress.OnError("broker went away")
+				return nil
+			},
+			contentType:  "text/csv",
+			body:         "offset\n10\n",
+			trailerError: "broker went away",
+		},
+	}
+
+	for _, table := range tt {
+		t.Run(table.name, func(t *testing.T) {
+			api := newTestAPI(nil)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				api.streamExport(w, r, api.Logger, table.format, table.columns, "test."+table.format, table.listMessages)
+			}))
+			defer srv.Close()
+
+			res, err := http.Get(srv.URL)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, table.contentType, res.Header.Get("Content-Type"))
+			assert.Equal(t, table.body, string(body))
+			assert.Equal(t, table.trailerError, res.Trailer.Get(exportTrailerError))
+		})
+	}
+}
+
+func TestAPI_StreamExport_WriteTimeout(t *testing.T) {
+	api := newTestAPI(nil)
+	api.Cfg.REST.HTTPServerWriteTimeout = time.Second
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.streamExport(w, r, api.Logger, exportFormatJSONL, exportColumnsAll, "test.jsonl", func(ctx context.Context, progress kafka.IListMessagesProgress) error {
+			// Consume until the export is stopped, like an export of a huge topic would do
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}))
+	srv.Config.WriteTimeout = api.Cfg.REST.HTTPServerWriteTimeout
+	srv.Start()
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	_, err = ioutil.ReadAll(res.Body)
+	require.NoError(t, err, "expected the export to end before the server's write timeout")
+
+	assert.Contains(t, res.Trailer.Get(exportTrailerError), "export has been truncated")
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	_ "context"
+	"errors"
 	"fmt"
 	"net/http"
 	_ "time"
@@ -11,6 +12,18 @@ import (
 	"github.com/cloudhut/common/rest"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
 	"github.com/go-chi/chi"
+	"github.com/gorilla/schema"
+)
+
+const (
+	// topicActionCreate must be returned by the AllowedTopicActions hook in order to create a topic
+	topicActionCreate = "createTopic"
+
+	// topicActionDelete must be returned by the AllowedTopicActions hook in order to delete a topic
+	topicActionDelete = "deleteTopic"
+
+	// topicActionCreatePartitions must be returned by the AllowedTopicActions hook in order to add partitions to a topic
+	topicActionCreatePartitions = "createPartitions"
 )
 
 func (api *API) handleGetTopics() http.HandlerFunc {
@@ -199,3 +212,288 @@ func (api *API) handleGetTopicConsumers() http.HandlerFunc {
 		rest.SendResponse(w, r, logger, http.StatusOK, res)
 	}
 }
+
+type createTopicRequest struct {
+	TopicName string `json:"topicName"`
+
+	// PartitionCount and ReplicationFactor use the broker defaults if they are not set or -1. Both must not be set if
+	// replica assignments are given.
+	PartitionCount     int32 `json:"partitionCount"`
+	ReplicationFactor  int16 `json:"replicationFactor"`
+	ReplicaAssignments []struct {
+		PartitionID int32   `json:"partitionId"`
+		Replicas    []int32 `json:"replicas"`
+	} `json:"replicaAssignments"`
+
+	Configs []struct {
+		Name  string  `json:"name"`
+		Value *string `json:"value"`
+	} `json:"configs"`
+
+	ValidateOnly bool `json:"validateOnly"`
+}
+
+func (c *createTopicRequest) OK() error {
+	if c.TopicName == "" {
+		return fmt.Errorf("topic name is required")
+	}
+
+	if c.PartitionCount < -1 {
+		return fmt.Errorf("partition count must be -1 (broker default) or greater than 0")
+	}
+
+	if c.ReplicationFactor < -1 {
+		return fmt.Errorf("replication factor must be -1 (broker default) or greater than 0")
+	}
+
+	if len(c.ReplicaAssignments) > 0 && (c.PartitionCount > 0 || c.ReplicationFactor > 0) {
+		return fmt.Errorf("partition count and replication factor must not be set if replica assignments are given")
+	}
+
+	for _, assignment := range c.ReplicaAssignments {
+		if len(assignment.Replicas) == 0 {
+			return fmt.Errorf("replica assignment for partition '%d' has no replicas", assignment.PartitionID)
+		}
+	}
+
+	for _, config := range c.Configs {
+		if config.Name == "" {
+			return fmt.Errorf("config names must not be empty")
+		}
+	}
+
+	return nil
+}
+
+func (api *API) handleCreateTopic() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. Parse and validate request
+		var req createTopicRequest
+		err := rest.Decode(w, r, &req)
+		if err != nil {
+			var mr *rest.MalformedRequest
+			if errors.As(err, &mr) {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf(mr.Error()),
+					Status:   mr.Status,
+					Message:  mr.Message,
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to decode request payload: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		logger := api.Logger.With(zap.String("topic_name", req.TopicName))
+
+		// 2. Check if logged in user is allowed to create the topic
+		if !api.checkTopicAction(w, r, req.TopicName, topicActionCreate) {
+			return
+		}
+
+		// 3. Create topic
+		createReq := owl.CreateTopicRequest{
+			TopicName:          req.TopicName,
+			PartitionCount:     -1,
+			ReplicationFactor:  -1,
+			ReplicaAssignments: make([]owl.CreateTopicRequestReplicaAssignment, len(req.ReplicaAssignments)),
+			Configs:            make([]owl.CreateTopicRequestConfig, len(req.Configs)),
+			ValidateOnly:       req.ValidateOnly,
+		}
+		if req.PartitionCount > 0 {
+			createReq.PartitionCount = req.PartitionCount
+		}
+		if req.ReplicationFactor > 0 {
+			createReq.ReplicationFactor = req.ReplicationFactor
+		}
+		for i, assignment := range req.ReplicaAssignments {
+			createReq.ReplicaAssignments[i] = owl.CreateTopicRequestReplicaAssignment{
+				PartitionID: assignment.PartitionID,
+				Replicas:    assignment.Replicas,
+			}
+		}
+		for i, config := range req.Configs {
+			createReq.Configs[i] = owl.CreateTopicRequestConfig{
+				Name:  config.Name,
+				Value: config.Value,
+			}
+		}
+
+		res, restErr := api.OwlSvc.CreateTopic(r.Context(), createReq)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		status := http.StatusCreated
+		if req.ValidateOnly {
+			status = http.StatusOK
+		}
+		rest.SendResponse(w, r, logger, status, res)
+	}
+}
+
+type deleteTopicRequest struct {
+	ValidateOnly bool `schema:"validateOnly"`
+}
+
+func (api *API) handleDeleteTopic() http.HandlerFunc {
+	type response struct {
+		TopicName    string `json:"topicName"`
+		ValidateOnly bool   `json:"validateOnly"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		// 1. Parse request from url parameters
+		decoder := schema.NewDecoder()
+		req := &deleteTopicRequest{}
+		err := decoder.Decode(req, r.URL.Query())
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Failed to parse request parameters",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to delete the topic
+		if !api.checkTopicAction(w, r, topicName, topicActionDelete) {
+			return
+		}
+
+		// 3. Delete topic
+		restErr := api.OwlSvc.DeleteTopic(r.Context(), topicName, req.ValidateOnly)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		res := response{
+			TopicName:    topicName,
+			ValidateOnly: req.ValidateOnly,
+		}
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+type createPartitionsRequest struct {
+	// PartitionCount is the new total number of partitions
+	PartitionCount int32 `json:"partitionCount"`
+
+	// Assignments optionally contains the replicas for each new partition
+	Assignments  [][]int32 `json:"assignments"`
+	ValidateOnly bool      `json:"validateOnly"`
+}
+
+func (c *createPartitionsRequest) OK() error {
+	if c.PartitionCount <= 0 {
+		return fmt.Errorf("partition count must be greater than 0")
+	}
+
+	for i, replicas := range c.Assignments {
+		if len(replicas) == 0 {
+			return fmt.Errorf("assignment with index '%d' has no replicas", i)
+		}
+	}
+
+	return nil
+}
+
+func (api *API) handleCreatePartitions() http.HandlerFunc {
+	type response struct {
+		TopicName      string `json:"topicName"`
+		PartitionCount int32  `json:"partitionCount"`
+		ValidateOnly   bool   `json:"validateOnly"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		// 1. Parse and validate request
+		var req createPartitionsRequest
+		err := rest.Decode(w, r, &req)
+		if err != nil {
+			var mr *rest.MalformedRequest
+			if errors.As(err, &mr) {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf(mr.Error()),
+					Status:   mr.Status,
+					Message:  mr.Message,
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, logger, restErr)
+				return
+			}
+
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Failed to decode request payload: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// 2. Check if logged in user is allowed to add partitions
+		if !api.checkTopicAction(w, r, topicName, topicActionCreatePartitions) {
+			return
+		}
+
+		// 3. Create partitions
+		createReq := owl.CreatePartitionsRequest{
+			TopicName:      topicName,
+			PartitionCount: req.PartitionCount,
+			Assignments:    req.Assignments,
+			ValidateOnly:   req.ValidateOnly,
+		}
+		restErr := api.OwlSvc.CreatePartitions(r.Context(), createReq)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		res := response{
+			TopicName:      topicName,
+			PartitionCount: req.PartitionCount,
+			ValidateOnly:   req.ValidateOnly,
+		}
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+// checkTopicAction checks whether the requester is allowed to run the given action on a topic. If that's not the
+// case an error response will be sent and false is returned.
+func (api *API) checkTopicAction(w http.ResponseWriter, r *http.Request, topicName string, action string) bool {
+	allowedActions, restErr := api.Hooks.Owl.AllowedTopicActions(r.Context(), topicName)
+	if restErr != nil {
+		rest.SendRESTError(w, r, api.Logger, restErr)
+		return false
+	}
+	if !isActionAllowed(allowedActions, action) {
+		rest.SendRESTError(w, r, api.Logger, &rest.Error{
+			Err:      fmt.Errorf("requester has no permissions to run action '%v' on topic", action),
+			Status:   http.StatusForbidden,
+			Message:  "You don't have permissions to run this action on the topic",
+			IsSilent: false,
+		})
+		return false
+	}
+
+	return true
+}
@@ -38,6 +38,7 @@ type OwlHooks interface {
 	CanViewTopicPartitions(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanViewTopicConfig(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanViewTopicMessages(ctx context.Context, topicName string) (bool, *rest.Error)
+	CanProduceToTopic(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanUseMessageSearchFilters(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanViewTopicConsumers(ctx context.Context, topicName string) (bool, *rest.Error)
 	AllowedTopicActions(ctx context.Context, topicName string) ([]string, *rest.Error)
@@ -45,6 +46,15 @@ type OwlHooks interface {
 
 	// ACL Hooks
 	CanListACLs(ctx context.Context) (bool, *rest.Error)
+	CanCreateACL(ctx context.Context) (bool, *rest.Error)
+	CanDeleteACL(ctx context.Context) (bool, *rest.Error)
+
+	// Schema Registry Hooks
+	CanRegisterSchema(ctx context.Context, subject string) (bool, *rest.Error)
+	CanDeleteSchema(ctx context.Context, subject string) (bool, *rest.Error)
+	// CanEditSchemaCompatibility is called with an empty subject for editing the global compatibility level
+	CanEditSchemaCompatibility(ctx context.Context, subject string) (bool, *rest.Error)
+	CanEditSchemaRegistryMode(ctx context.Context) (bool, *rest.Error)
 
 	// ConsumerGroup Hooks
 	CanSeeConsumerGroup(ctx context.Context, groupName string) (bool, *rest.Error)
@@ -84,6 +94,9 @@ func (*defaultHooks) CanViewTopicConfig(_ context.Context, _ string) (bool, *res
 func (*defaultHooks) CanViewTopicMessages(_ context.Context, _ string) (bool, *rest.Error) {
 	return true, nil
 }
+func (*defaultHooks) CanProduceToTopic(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
 func (*defaultHooks) CanUseMessageSearchFilters(_ context.Context, _ string) (bool, *rest.Error) {
 	return true, nil
 }
@@ -98,6 +111,24 @@ func (*defaultHooks) PrintListMessagesAuditLog(_ *http.Request, _ *owl.ListMessa
 func (*defaultHooks) CanListACLs(_ context.Context) (bool, *rest.Error) {
 	return true, nil
 }
+func (*defaultHooks) CanCreateACL(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanDeleteACL(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanRegisterSchema(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanDeleteSchema(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanEditSchemaCompatibility(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanEditSchemaRegistryMode(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
 func (*defaultHooks) CanSeeConsumerGroup(_ context.Context, _ string) (bool, *rest.Error) {
 	return true, nil
 }
@@ -111,3 +142,15 @@ func (*defaultHooks) CanPatchPartitionReassignments(_ context.Context) (bool, *r
 func (*defaultHooks) CanPatchConfigs(_ context.Context) (bool, *rest.Error) {
 	return true, nil
 }
+
+// isActionAllowed returns true if the requested action is part of the actions returned by one of the Allowed*Actions
+// hooks. The action "all" is considered as wild card.
+func isActionAllowed(allowedActions []string, action string) bool {
+	for _, allowedAction := range allowedActions {
+		if allowedAction == "all" || allowedAction == action {
+			return true
+		}
+	}
+
+	return false
+}
@@ -49,6 +49,7 @@ func (api *API) routes() *chi.Mux {
 			r.Handle("/admin/metrics", promhttp.Handler())
 			r.Handle("/admin/health", api.handleLivenessProbe())
 			r.Handle("/admin/startup", api.handleStartupProbe())
+			r.Post("/admin/schema-registry/cache/purge", api.handlePurgeSchemaCache())
 
 			// Path must be prefixed with /debug otherwise it will be overridden, see: https://golang.org/pkg/net/http/pprof/
 			r.Mount("/debug", chimiddleware.Profiler())
@@ -60,23 +61,17 @@ func (api *API) routes() *chi.Mux {
 			api.Hooks.Route.ConfigAPIRouter(r)
 
 			r.Route("/api", func(r chi.Router) {
-				r.Get("/api-versions", api.handleGetAPIVersions())
-				r.Get("/cluster/config", api.handleClusterConfig())
-				r.Get("/cluster", api.handleDescribeCluster())
-				r.Get("/topics", api.handleGetTopics())
-				r.Get("/acls", api.handleGetACLsOverview())
-				r.Get("/topics/{topicName}/partitions", api.handleGetPartitions())
-				r.Get("/topics/{topicName}/configuration", api.handleGetTopicConfig())
-				r.Get("/topics/{topicName}/consumers", api.handleGetTopicConsumers())
-				r.Get("/topics/{topicName}/documentation", api.handleGetTopicDocumentation())
-				r.Get("/operations/topic-details", api.handleGetAllTopicDetails())
-				r.Get("/operations/reassign-partitions", api.handleGetPartitionReassignments())
-				r.Patch("/operations/reassign-partitions", api.handlePatchPartitionAssignments())
-				r.Patch("/operations/configs", api.handlePatchConfigs())
-				r.Get("/consumer-groups", api.handleGetConsumerGroups())
-				r.Get("/kowl/endpoints", api.handleGetEndpoints())
-				r.Get("/schemas", api.handleGetSchemaOverview())
-				r.Get("/schemas/subjects/{subject}/versions/{version}", api.handleGetSchemaDetails())
+				r.Get("/clusters", api.handleGetClusters())
+				r.Handle("/clusters/*", rest.HandleNotFound(api.Logger)) // Unknown cluster names
+
+				// Routes without cluster prefix are served by the default cluster
+				api.mountClusterRoutes(r, api.defaultCluster())
+				for _, cluster := range api.Clusters {
+					cluster := cluster
+					r.Route("/clusters/"+cluster.Name, func(r chi.Router) {
+						api.mountClusterRoutes(r, cluster)
+					})
+				}
 			})
 		})
 
@@ -104,8 +99,75 @@ func (api *API) routes() *chi.Mux {
 	baseRouter.Group(func(wsRouter chi.Router) {
 		api.Hooks.Route.ConfigWsRouter(wsRouter)
 
-		wsRouter.Get("/api/topics/{topicName}/messages", api.handleGetMessages())
+		api.mountClusterWsRoutes(wsRouter, "/api", api.defaultCluster())
+		for _, cluster := range api.Clusters {
+			api.mountClusterWsRoutes(wsRouter, "/api/clusters/"+cluster.Name, cluster)
+		}
 	})
 
 	return baseRouter
 }
+
+// mountClusterRoutes registers all routes which serve the given cluster's data. If the cluster is unavailable all
+// requests will be responded with an error instead.
+func (api *API) mountClusterRoutes(r chi.Router, cluster *Cluster) {
+	if !cluster.IsAvailable() {
+		r.Handle("/*", api.handleClusterUnavailable(cluster))
+		return
+	}
+	api.forCluster(cluster).clusterRoutes(r)
+}
+
+// mountClusterWsRoutes registers all websocket routes which serve the given cluster's data under the given prefix.
+// Websocket routes can not be mounted as sub router, because the /api path is already mounted by the API routes.
+func (api *API) mountClusterWsRoutes(r chi.Router, prefix string, cluster *Cluster) {
+	if !cluster.IsAvailable() {
+		r.Handle(prefix+"/topics/{topicName}/messages", api.handleClusterUnavailable(cluster))
+		return
+	}
+	clusterAPI := api.forCluster(cluster)
+	r.Get(prefix+"/topics/{topicName}/messages", clusterAPI.handleGetMessages())
+}
+
+// clusterRoutes are all API routes which serve the data of a single cluster.
+func (api *API) clusterRoutes(r chi.Router) {
+	r.Get("/api-versions", api.handleGetAPIVersions())
+	r.Get("/cluster/config", api.handleClusterConfig())
+	r.Get("/cluster", api.handleDescribeCluster())
+	r.Get("/topics", api.handleGetTopics())
+	r.Post("/topics", api.handleCreateTopic())
+	r.Delete("/topics/{topicName}", api.handleDeleteTopic())
+	r.Get("/acls", api.handleGetACLsOverview())
+	r.Post("/acls", api.handleCreateACLs())
+	r.Delete("/acls", api.handleDeleteACLs())
+	r.Get("/acls/export", api.handleExportACLs())
+	r.Post("/acls/import", api.handleImportACLs())
+	r.Get("/acls/simulate", api.handleSimulateACL())
+	r.Get("/topics/{topicName}/partitions", api.handleGetPartitions())
+	r.Post("/topics/{topicName}/partitions", api.handleCreatePartitions())
+	r.Get("/topics/{topicName}/configuration", api.handleGetTopicConfig())
+	r.Get("/topics/{topicName}/consumers", api.handleGetTopicConsumers())
+	r.Get("/topics/{topicName}/documentation", api.handleGetTopicDocumentation())
+	r.Post("/topics/{topicName}/messages", api.handleProduceRecords())
+	r.Get("/topics/{topicName}/messages/export", api.handleExportMessages())
+	r.Get("/operations/topic-details", api.handleGetAllTopicDetails())
+	r.Get("/operations/reassign-partitions", api.handleGetPartitionReassignments())
+	r.Patch("/operations/reassign-partitions", api.handlePatchPartitionAssignments())
+	r.Patch("/operations/configs", api.handlePatchConfigs())
+	r.Get("/consumer-groups", api.handleGetConsumerGroups())
+	r.Get("/consumer-groups/{groupId}", api.handleGetConsumerGroup())
+	r.Delete("/consumer-groups/{groupId}", api.handleDeleteConsumerGroup())
+	r.Get("/consumer-groups/{groupId}/lag-history", api.handleGetConsumerGroupLagHistory())
+	r.Patch("/consumer-groups/{groupId}/offsets", api.handlePatchConsumerGroupOffsets())
+	r.Delete("/consumer-groups/{groupId}/offsets", api.handleDeleteConsumerGroupOffsets())
+	r.Get("/kowl/endpoints", api.handleGetEndpoints())
+	r.Get("/schemas", api.handleGetSchemaOverview())
+	r.Get("/schemas/subjects/{subject}/versions/{version}", api.handleGetSchemaDetails())
+	r.Post("/schemas/subjects/{subject}/versions", api.handleRegisterSchema())
+	r.Delete("/schemas/subjects/{subject}", api.handleDeleteSubject())
+	r.Delete("/schemas/subjects/{subject}/versions/{version}", api.handleDeleteSubjectVersion())
+	r.Post("/schemas/compatibility/subjects/{subject}/versions/{version}", api.handleCheckSchemaCompatibility())
+	r.Put("/schemas/config", api.handlePutSchemaConfig())
+	r.Put("/schemas/config/{subject}", api.handlePutSchemaSubjectConfig())
+	r.Put("/schemas/mode", api.handlePutSchemaMode())
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestAPI(clusters []*Cluster) *API {
+	var cfg Config
+	cfg.SetDefaults()
+	cfg.ServeFrontend = false
+	cfg.MetricsNamespace = "kowl_test"
+
+	return &API{
+		Cfg:      &cfg,
+		Logger:   zap.NewNop(),
+		Clusters: clusters,
+		Hooks:    newDefaultHooks(),
+	}
+}
+
+func TestAPI_DefaultCluster(t *testing.T) {
+	first := &Cluster{Name: "first", Err: fmt.Errorf("connection refused")}
+	second := &Cluster{Name: "second"}
+	third := &Cluster{Name: "third"}
+
+	api := newTestAPI([]*Cluster{first, second, third})
+	assert.Equal(t, second, api.defaultCluster(), "expected the first available cluster")
+
+	second.Err = fmt.Errorf("connection refused")
+	third.Err = fmt.Errorf("connection refused")
+	assert.Equal(t, first, api.defaultCluster(), "expected the first cluster if no cluster is available")
+}
+
+func TestAPI_ClusterRoutes(t *testing.T) {
+	api := newTestAPI([]*Cluster{
+		{Name: "prod", Err: fmt.Errorf("connection refused")},
+		{Name: "staging", Err: fmt.Errorf("sasl authentication failed")},
+	})
+	router := api.routes()
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := request("/api/clusters")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var clustersRes struct {
+		Clusters []struct {
+			Name        string `json:"name"`
+			IsAvailable bool   `json:"isAvailable"`
+		} `json:"clusters"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &clustersRes))
+	require.Len(t, clustersRes.Clusters, 2)
+	assert.Equal(t, "prod", clustersRes.Clusters[0].Name)
+	assert.False(t, clustersRes.Clusters[0].IsAvailable)
+
+	// Requests for unavailable clusters must be answered with the cluster's error
+	rec = request("/api/clusters/staging/topics")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "sasl authentication failed")
+
+	rec = request("/api/topics")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "connection refused", "expected routes without prefix to be served by the first cluster")
+
+	rec = request("/api/clusters/unknown/topics")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
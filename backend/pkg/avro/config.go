@@ -0,0 +1,61 @@
+package avro
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/filesystem"
+	"github.com/cloudhut/kowl/backend/pkg/git"
+)
+
+// Config for deserializing Avro messages that have been serialized without a schema registry, using .avsc schema
+// files from a git repository or the local filesystem.
+type Config struct {
+	Enabled    bool              `yaml:"enabled"`
+	Git        git.Config        `yaml:"git"`
+	FileSystem filesystem.Config `yaml:"fileSystem"`
+
+	// Mappings define what Avro types shall be used for each Kafka topic.
+	Mappings []ConfigTopicMapping `yaml:"mappings"`
+}
+
+// RegisterFlags registers all nested config flags.
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	c.Git.RegisterFlagsWithPrefix(f, "kafka.avro.")
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if !c.Git.Enabled && !c.FileSystem.Enabled {
+		return fmt.Errorf("avro deserializer is enabled, but git and filesystem are disabled. At least one source for schemas must be configured")
+	}
+
+	if len(c.Mappings) == 0 {
+		return fmt.Errorf("avro deserializer is enabled, but no topic mappings have been configured")
+	}
+	for _, mapping := range c.Mappings {
+		if mapping.TopicName == "" {
+			return fmt.Errorf("avro topic mappings must specify a topic name")
+		}
+	}
+
+	err := c.Git.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate git config: %w", err)
+	}
+
+	err = c.FileSystem.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate filesystem config: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) SetDefaults() {
+	c.Git.SetDefaults()
+	c.FileSystem.SetDefaults()
+}
@@ -0,0 +1,11 @@
+package avro
+
+type ConfigTopicMapping struct {
+	TopicName string `yaml:"topicName"`
+
+	// KeyRecordType is the Avro type's full name (namespace and name) that shall be used for a Kafka record's key
+	KeyRecordType string `yaml:"keyRecordType"`
+
+	// ValueRecordType is the Avro type's full name (namespace and name) that shall be used for a Kafka record's value
+	ValueRecordType string `yaml:"valueRecordType"`
+}
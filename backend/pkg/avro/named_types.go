@@ -0,0 +1,227 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+// namedTypes contains all named Avro types (records, errors, enums and fixed) of a set of .avsc files. .avsc files
+// often reference types which are defined in other files, hence goavro can't compile a single file on its own.
+// Therefore all named types are collected by their full name first, so that a self-contained schema can be assembled
+// for each type afterwards.
+type namedTypes map[string]map[string]interface{}
+
+// parseNamedTypes parses the given .avsc files and returns all named types that are defined in them. A file may
+// contain a single schema or an array (union) of schemas.
+func parseNamedTypes(files map[string][]byte) (namedTypes, map[string]error) {
+	types := make(namedTypes)
+	errorsByPath := make(map[string]error)
+	for path, payload := range files {
+		var schema interface{}
+		err := json.Unmarshal(payload, &schema)
+		if err != nil {
+			errorsByPath[path] = fmt.Errorf("failed to parse avro schema: %w", err)
+			continue
+		}
+
+		fileTypes := make(namedTypes)
+		_, err = fileTypes.collect(schema, "")
+		if err != nil {
+			errorsByPath[path] = err
+			continue
+		}
+		for name, definition := range fileTypes {
+			types[name] = definition
+		}
+	}
+
+	return types, errorsByPath
+}
+
+// collect registers all named types within the schema and returns the schema, where all names are fully qualified
+// and all named type definitions have been replaced by a reference to their full name.
+func (t namedTypes) collect(schema interface{}, namespace string) (interface{}, error) {
+	switch s := schema.(type) {
+	case string:
+		return qualifyName(s, namespace), nil
+	case []interface{}:
+		union := make([]interface{}, len(s))
+		for i, member := range s {
+			collected, err := t.collect(member, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union[i] = collected
+		}
+		return union, nil
+	case map[string]interface{}:
+		return t.collectComplexType(s, namespace)
+	default:
+		return nil, fmt.Errorf("invalid avro schema of type %T", schema)
+	}
+}
+
+func (t namedTypes) collectComplexType(schema map[string]interface{}, namespace string) (interface{}, error) {
+	res := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		res[key] = value
+	}
+
+	typeName, isString := schema["type"].(string)
+	if !isString {
+		// Type is a nested schema, e.g. {"type": {"type": "array", "items": "string"}}
+		collected, err := t.collect(schema["type"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		res["type"] = collected
+		return res, nil
+	}
+
+	switch typeName {
+	case "record", "error", "enum", "fixed":
+		name, _ := schema["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("avro %v type must have a name", typeName)
+		}
+		if !strings.Contains(name, ".") {
+			if ns, ok := schema["namespace"].(string); ok {
+				namespace = ns
+			}
+		}
+		fullName := qualifyName(name, namespace)
+		res["name"] = fullName
+		delete(res, "namespace")
+
+		if typeName == "record" || typeName == "error" {
+			// Names within the record are relative to the record's namespace
+			childNamespace := ""
+			if i := strings.LastIndex(fullName, "."); i >= 0 {
+				childNamespace = fullName[:i]
+			}
+			fields, _ := schema["fields"].([]interface{})
+			collectedFields := make([]interface{}, len(fields))
+			for i, field := range fields {
+				fieldMap, ok := field.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid field in avro record '%v'", fullName)
+				}
+				collectedField := make(map[string]interface{}, len(fieldMap))
+				for key, value := range fieldMap {
+					collectedField[key] = value
+				}
+				fieldType, err := t.collect(fieldMap["type"], childNamespace)
+				if err != nil {
+					return nil, fmt.Errorf("invalid type of field '%v' in avro record '%v': %w", fieldMap["name"], fullName, err)
+				}
+				collectedField["type"] = fieldType
+				collectedFields[i] = collectedField
+			}
+			res["fields"] = collectedFields
+		}
+
+		t[fullName] = res
+		return fullName, nil
+	case "array":
+		items, err := t.collect(schema["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		res["items"] = items
+	case "map":
+		values, err := t.collect(schema["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		res["values"] = values
+	}
+
+	return res, nil
+}
+
+// schemaFor assembles a self-contained schema for the named type with the given full name. Each referenced named
+// type is defined at its first occurrence, so that goavro can compile the schema without any other files.
+func (t namedTypes) schemaFor(fullName string) (string, error) {
+	if _, exists := t[fullName]; !exists {
+		return "", fmt.Errorf("avro type '%v' is not defined in any schema file", fullName)
+	}
+
+	schema := t.expand(fullName, make(map[string]bool))
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal avro schema: %w", err)
+	}
+
+	return string(schemaBytes), nil
+}
+
+func (t namedTypes) expand(schema interface{}, defined map[string]bool) interface{} {
+	switch s := schema.(type) {
+	case string:
+		definition, isNamedType := t[s]
+		if !isNamedType || defined[s] {
+			return s
+		}
+		defined[s] = true
+		return t.expand(definition, defined)
+	case []interface{}:
+		union := make([]interface{}, len(s))
+		for i, member := range s {
+			union[i] = t.expand(member, defined)
+		}
+		return union
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(s))
+		for key, value := range s {
+			res[key] = value
+		}
+
+		typeName, isString := s["type"].(string)
+		if !isString {
+			res["type"] = t.expand(s["type"], defined)
+			return res
+		}
+		switch typeName {
+		case "record", "error":
+			fields, _ := s["fields"].([]interface{})
+			expandedFields := make([]interface{}, len(fields))
+			for i, field := range fields {
+				fieldMap := field.(map[string]interface{})
+				expandedField := make(map[string]interface{}, len(fieldMap))
+				for key, value := range fieldMap {
+					expandedField[key] = value
+				}
+				expandedField["type"] = t.expand(fieldMap["type"], defined)
+				expandedFields[i] = expandedField
+			}
+			res["fields"] = expandedFields
+		case "array":
+			res["items"] = t.expand(s["items"], defined)
+		case "map":
+			res["values"] = t.expand(s["values"], defined)
+		}
+		return res
+	default:
+		return schema
+	}
+}
+
+// qualifyName returns the full name of a type that is referenced within the given namespace.
+func qualifyName(name string, namespace string) string {
+	if primitiveTypes[name] || strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
@@ -0,0 +1,200 @@
+package avro
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cloudhut/kowl/backend/pkg/filesystem"
+	"github.com/cloudhut/kowl/backend/pkg/git"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/linkedin/goavro/v2"
+	"go.uber.org/zap"
+)
+
+// Service decodes Avro messages which have been serialized without a schema registry, that is the payload is the
+// plain Avro binary encoding of the record. The schemas are loaded from .avsc files and mapped to topics via config.
+type Service struct {
+	cfg    Config
+	logger *zap.Logger
+
+	mappingsByTopic map[string]ConfigTopicMapping
+	gitSvc          *git.Service
+	fsSvc           *filesystem.Service
+
+	registryMutex sync.RWMutex
+	codecsByName  map[string]*goavro.Codec
+}
+
+func NewService(cfg Config, logger *zap.Logger) (*Service, error) {
+	// Index by full filepath so that we support .avsc files with the same filename in different directories
+	cfg.Git.IndexByFullFilepath = true
+	gitSvc, err := git.NewService(cfg.Git, logger, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new git service: %w", err)
+	}
+	fsSvc := filesystem.NewService(cfg.FileSystem, logger, nil)
+
+	mappingsByTopic := make(map[string]ConfigTopicMapping)
+	for _, mapping := range cfg.Mappings {
+		mappingsByTopic[mapping.TopicName] = mapping
+	}
+
+	return &Service{
+		cfg:    cfg,
+		logger: logger,
+
+		mappingsByTopic: mappingsByTopic,
+		gitSvc:          gitSvc,
+		fsSvc:           fsSvc,
+
+		// codecs have to be created afterwards
+		codecsByName: make(map[string]*goavro.Codec),
+	}, nil
+}
+
+func (s *Service) Start() error {
+	err := s.gitSvc.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start git service: %w", err)
+	}
+
+	err = s.fsSvc.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start filesystem service: %w", err)
+	}
+
+	err = s.createCodecRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to create avro codecs: %w", err)
+	}
+
+	// Both sources are periodically refreshed. If there are any file changes the codecs will be rebuilt.
+	s.gitSvc.OnFilesUpdatedHook = s.tryCreateCodecRegistry
+	s.fsSvc.OnFilesUpdatedHook = s.tryCreateCodecRegistry
+
+	return nil
+}
+
+// UnmarshalPayload decodes the binary Avro payload using the Avro type that is mapped to the given topic and record
+// property. It returns the Go native form of the record along with its JSON representation.
+func (s *Service) UnmarshalPayload(payload []byte, topicName string, property proto.RecordPropertyType) (interface{}, []byte, error) {
+	codec, err := s.getCodec(topicName, property)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	native, remaining, err := codec.NativeFromBinary(payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode avro payload: %w", err)
+	}
+	if len(remaining) > 0 {
+		// The payload is most likely encoded with a different schema
+		return nil, nil, fmt.Errorf("failed to decode avro payload: %d bytes left after decoding", len(remaining))
+	}
+
+	jsonBytes, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal avro record to JSON: %w", err)
+	}
+
+	return native, jsonBytes, nil
+}
+
+func (s *Service) getCodec(topicName string, property proto.RecordPropertyType) (*goavro.Codec, error) {
+	mapping, exists := s.mappingsByTopic[topicName]
+	if !exists {
+		return nil, fmt.Errorf("no avro type found for the given topic. Check your configured avro mappings")
+	}
+
+	typeName := mapping.ValueRecordType
+	if property == proto.RecordKey {
+		typeName = mapping.KeyRecordType
+	}
+	if typeName == "" {
+		return nil, fmt.Errorf("no avro type mapping found for the record %v of topic '%v'", recordPropertyName(property), topicName)
+	}
+
+	s.registryMutex.RLock()
+	defer s.registryMutex.RUnlock()
+	codec, exists := s.codecsByName[typeName]
+	if !exists {
+		// If this happens the user should already know that because we check the existence of all mapped types
+		// when we create the codecs. A log message is printed if a mapped type can't be found.
+		return nil, fmt.Errorf("avro type '%v' could not be found in the schema files", typeName)
+	}
+
+	return codec, nil
+}
+
+func (s *Service) tryCreateCodecRegistry() {
+	err := s.createCodecRegistry()
+	if err != nil {
+		s.logger.Error("failed to update avro codecs", zap.Error(err))
+	}
+}
+
+// createCodecRegistry compiles a codec for each Avro type that is referenced in the topic mappings.
+func (s *Service) createCodecRegistry() error {
+	files := make(map[string][]byte)
+	for path, file := range s.gitSvc.GetFilesByFilename() {
+		files["git/"+path] = file.Payload
+	}
+	for path, file := range s.fsSvc.GetFilesByPath() {
+		files["filesystem/"+path] = file.Payload
+	}
+	s.logger.Debug("fetched .avsc files from git and filesystem",
+		zap.Int("fetched_avro_files", len(files)))
+
+	types, errorsByPath := parseNamedTypes(files)
+	for path, err := range errorsByPath {
+		s.logger.Warn("failed to parse avro schema file", zap.String("file", path), zap.Error(err))
+	}
+
+	codecsByName := make(map[string]*goavro.Codec)
+	for _, mapping := range s.cfg.Mappings {
+		for _, typeName := range []string{mapping.KeyRecordType, mapping.ValueRecordType} {
+			if typeName == "" {
+				continue
+			}
+			if _, exists := codecsByName[typeName]; exists {
+				continue
+			}
+
+			codec, err := compileCodec(types, typeName)
+			if err != nil {
+				s.logger.Info("avro type from configured topic mapping can not be used",
+					zap.String("topic_name", mapping.TopicName),
+					zap.String("avro_type", typeName),
+					zap.Error(err))
+				continue
+			}
+			codecsByName[typeName] = codec
+		}
+	}
+
+	s.registryMutex.Lock()
+	defer s.registryMutex.Unlock()
+	s.codecsByName = codecsByName
+
+	return nil
+}
+
+func compileCodec(types namedTypes, typeName string) (*goavro.Codec, error) {
+	schema, err := types.schemaFor(typeName)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile avro schema: %w", err)
+	}
+
+	return codec, nil
+}
+
+func recordPropertyName(property proto.RecordPropertyType) string {
+	if property == proto.RecordKey {
+		return "key"
+	}
+	return "value"
+}
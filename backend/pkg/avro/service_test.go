@@ -0,0 +1,127 @@
+package avro
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const customerSchema = `{
+  "type": "record",
+  "name": "Customer",
+  "namespace": "com.shop",
+  "fields": [
+    {"name": "name", "type": "string"},
+    {"name": "tier", "type": {"type": "enum", "name": "Tier", "symbols": ["GOLD", "SILVER"]}}
+  ]
+}`
+
+const orderSchema = `{
+  "type": "record",
+  "name": "Order",
+  "namespace": "com.shop",
+  "fields": [
+    {"name": "id", "type": "string"},
+    {"name": "customer", "type": "Customer"},
+    {"name": "previousTier", "type": ["null", "com.shop.Tier"], "default": null},
+    {"name": "items", "type": {"type": "array", "items": {
+      "type": "record",
+      "name": "Item",
+      "namespace": "com.shop.order",
+      "fields": [{"name": "sku", "type": "string"}, {"name": "tier", "type": "com.shop.Tier"}]
+    }}}
+  ]
+}`
+
+func TestNamedTypes_SchemaFor(t *testing.T) {
+	types, errorsByPath := parseNamedTypes(map[string][]byte{
+		"customer.avsc": []byte(customerSchema),
+		"order.avsc":    []byte(orderSchema),
+		"invalid.avsc":  []byte(`{"type": "record"`),
+	})
+	assert.Len(t, errorsByPath, 1)
+	assert.Contains(t, errorsByPath, "invalid.avsc")
+
+	for _, name := range []string{"com.shop.Customer", "com.shop.Tier", "com.shop.Order", "com.shop.order.Item"} {
+		assert.Contains(t, types, name)
+	}
+
+	// The order schema references the customer and tier types from the other file, hence it can only be compiled
+	// after the named types have been resolved.
+	schema, err := types.schemaFor("com.shop.Order")
+	require.NoError(t, err)
+	codec, err := goavro.NewCodec(schema)
+	require.NoError(t, err)
+
+	order := map[string]interface{}{
+		"id":           "order-1",
+		"customer":     map[string]interface{}{"name": "ann", "tier": "GOLD"},
+		"previousTier": goavro.Union("com.shop.Tier", "SILVER"),
+		"items": []interface{}{
+			map[string]interface{}{"sku": "x1", "tier": "SILVER"},
+		},
+	}
+	binary, err := codec.BinaryFromNative(nil, order)
+	require.NoError(t, err)
+	decoded, remaining, err := codec.NativeFromBinary(binary)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+	assert.Equal(t, "ann", decoded.(map[string]interface{})["customer"].(map[string]interface{})["name"])
+
+	_, err = types.schemaFor("com.shop.Unknown")
+	assert.Error(t, err)
+}
+
+func TestService_UnmarshalPayload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avro-schemas")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shop"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shop", "customer.avsc"), []byte(customerSchema), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shop", "order.avsc"), []byte(orderSchema), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a schema"), 0644))
+
+	cfg := Config{
+		Enabled: true,
+		Mappings: []ConfigTopicMapping{
+			{TopicName: "customers", ValueRecordType: "com.shop.Customer"},
+			{TopicName: "orders", ValueRecordType: "com.shop.Order", KeyRecordType: "com.shop.Missing"},
+		},
+	}
+	cfg.SetDefaults()
+	cfg.FileSystem.Enabled = true
+	cfg.FileSystem.Paths = []string{dir}
+	cfg.FileSystem.RefreshInterval = 0
+	cfg.FileSystem.AllowedFileExtensions = []string{"avsc"}
+	require.NoError(t, cfg.Validate())
+
+	svc, err := NewService(cfg, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start())
+
+	// Customer "ann" (length 3, zigzag 0x06) with tier SILVER (enum index 1, zigzag 0x02)
+	payload := []byte{0x06, 'a', 'n', 'n', 0x02}
+	native, jsonBytes, err := svc.UnmarshalPayload(payload, "customers", proto.RecordValue)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "ann", "tier": "SILVER"}, native)
+	assert.JSONEq(t, `{"name": "ann", "tier": "SILVER"}`, string(jsonBytes))
+
+	_, _, err = svc.UnmarshalPayload(append(payload, 0x00), "customers", proto.RecordValue)
+	assert.Error(t, err, "expected an error if bytes are left after decoding")
+
+	_, _, err = svc.UnmarshalPayload(payload, "customers", proto.RecordKey)
+	assert.Error(t, err, "expected an error if no key type is mapped")
+
+	_, _, err = svc.UnmarshalPayload(payload, "orders", proto.RecordKey)
+	assert.Error(t, err, "expected an error if the mapped type does not exist")
+
+	_, _, err = svc.UnmarshalPayload(payload, "unknown-topic", proto.RecordValue)
+	assert.Error(t, err)
+}
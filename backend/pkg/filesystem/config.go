@@ -0,0 +1,46 @@
+package filesystem
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config for the filesystem service, which reads files from local directories.
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Paths are the directories which shall be searched for files, including their subdirectories.
+	Paths []string `yaml:"paths"`
+
+	// RefreshInterval specifies how often the directories shall be read again to pick up file changes. Set to 0 to
+	// read them only once at startup.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+
+	// AllowedFileExtensions specifies file extensions that shall be picked up. If at least one is specified all other
+	// file extensions will be ignored.
+	AllowedFileExtensions []string `yaml:"-"`
+
+	// Max file size which will be considered. Files exceeding this size will be ignored and logged.
+	MaxFileSize int64 `yaml:"-"`
+}
+
+// Validate the filesystem config
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("filesystem is enabled but no paths are configured")
+	}
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("refresh interval must not be negative")
+	}
+
+	return nil
+}
+
+// SetDefaults for the filesystem config
+func (c *Config) SetDefaults() {
+	c.RefreshInterval = time.Minute
+	c.MaxFileSize = 500 * 1000 // 500KB
+}
@@ -0,0 +1,191 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service reads files from local directories and keeps them in memory. The directories are read periodically, so
+// that changed files (e.g. from a mounted config map) are picked up without a restart.
+type Service struct {
+	Cfg    Config
+	logger *zap.Logger
+
+	mutex       sync.RWMutex
+	filesByPath map[string]File
+
+	// OnFilesUpdatedHook will be called whenever the files have changed
+	OnFilesUpdatedHook func()
+}
+
+type File struct {
+	Path     string
+	Filename string
+
+	// TrimmedFilename is the filename without the recognized file extension
+	TrimmedFilename string
+
+	Payload []byte
+}
+
+// NewService creates a new filesystem service
+func NewService(cfg Config, logger *zap.Logger, onFilesUpdatedHook func()) *Service {
+	return &Service{
+		Cfg:                cfg,
+		logger:             logger.With(zap.String("source", "filesystem")),
+		filesByPath:        make(map[string]File),
+		OnFilesUpdatedHook: onFilesUpdatedHook,
+	}
+}
+
+// Start reads all files once and returns an error if the directories can not be read. Afterwards the directories are
+// read periodically in the background.
+func (s *Service) Start() error {
+	if !s.Cfg.Enabled {
+		return nil
+	}
+
+	_, err := s.loadFiles()
+	if err != nil {
+		return err
+	}
+	if s.OnFilesUpdatedHook != nil {
+		s.OnFilesUpdatedHook()
+	}
+
+	if s.Cfg.RefreshInterval > 0 {
+		go s.refreshFiles(context.Background())
+	}
+
+	return nil
+}
+
+func (s *Service) refreshFiles(ctx context.Context) {
+	ticker := time.NewTicker(s.Cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			hasChanged, err := s.loadFiles()
+			if err != nil {
+				s.logger.Error("failed to read files from filesystem", zap.Error(err))
+				continue
+			}
+			if hasChanged && s.OnFilesUpdatedHook != nil {
+				s.OnFilesUpdatedHook()
+			}
+		}
+	}
+}
+
+// loadFiles reads all files and replaces the cached files. It returns true if any of the files has changed.
+func (s *Service) loadFiles() (bool, error) {
+	files := make(map[string]File)
+	for _, path := range s.Cfg.Paths {
+		err := s.readFiles(path, files)
+		if err != nil {
+			return false, fmt.Errorf("failed to read files in '%v': %w", path, err)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	hasChanged := len(files) != len(s.filesByPath)
+	for path, file := range files {
+		existing, exists := s.filesByPath[path]
+		if !exists || !bytes.Equal(existing.Payload, file.Payload) {
+			hasChanged = true
+			break
+		}
+	}
+	s.filesByPath = files
+	if hasChanged {
+		s.logger.Info("read files from filesystem", zap.Int("read_files", len(files)))
+	}
+
+	return hasChanged, nil
+}
+
+// readFiles recursively reads all files with an allowed file extension in the given directory. Files are keyed by
+// their path relative to the directory, so that they can be imported by each other.
+func (s *Service) readFiles(root string, res map[string]File) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		isValid, trimmedFilename := s.isValidFileExtension(info.Name())
+		if !isValid {
+			return nil
+		}
+		if s.Cfg.MaxFileSize > 0 && info.Size() > s.Cfg.MaxFileSize {
+			s.logger.Warn("skipped file because it exceeds the max file size",
+				zap.String("path", path),
+				zap.Int64("file_size", info.Size()),
+				zap.Int64("max_file_size", s.Cfg.MaxFileSize))
+			return nil
+		}
+
+		payload, err := ioutil.ReadFile(path)
+		if err != nil {
+			s.logger.Error("failed to read file from filesystem. file will be skipped",
+				zap.String("path", path), zap.Error(err))
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		res[relativePath] = File{
+			Path:            relativePath,
+			Filename:        info.Name(),
+			TrimmedFilename: trimmedFilename,
+			Payload:         payload,
+		}
+
+		return nil
+	})
+}
+
+// isValidFileExtension returns whether the given filename has one of the allowed file extensions, along with the
+// filename without the extension.
+func (s *Service) isValidFileExtension(filename string) (bool, string) {
+	extension := filepath.Ext(filename)
+	trimmedFilename := strings.TrimSuffix(filename, extension)
+	if len(s.Cfg.AllowedFileExtensions) == 0 {
+		return true, trimmedFilename
+	}
+
+	for _, allowed := range s.Cfg.AllowedFileExtensions {
+		if extension == "."+allowed {
+			return true, trimmedFilename
+		}
+	}
+	return false, trimmedFilename
+}
+
+// GetFilesByPath returns all files keyed by their path relative to the configured directory.
+func (s *Service) GetFilesByPath() map[string]File {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.filesByPath
+}
@@ -21,25 +21,32 @@ type clientHooks struct {
 	bytesReceived         prometheus.Counter
 }
 
-func newClientHooks(logger *zap.Logger, metricsNamespace string) *clientHooks {
+// newClientHooks creates the client hooks for a single cluster. The metrics are labeled with the cluster's name, so
+// that hooks for multiple clusters can be registered at the same time.
+func newClientHooks(logger *zap.Logger, metricsNamespace string, clusterName string) *clientHooks {
+	constLabels := prometheus.Labels{"cluster": clusterName}
 	requestSentCount := promauto.NewCounter(prometheus.CounterOpts{
-		Namespace: metricsNamespace,
-		Subsystem: "kafka",
-		Name:      "requests_sent_total"})
+		Namespace:   metricsNamespace,
+		Subsystem:   "kafka",
+		Name:        "requests_sent_total",
+		ConstLabels: constLabels})
 	bytesSent := promauto.NewCounter(prometheus.CounterOpts{
-		Namespace: metricsNamespace,
-		Subsystem: "kafka",
-		Name:      "sent_bytes",
+		Namespace:   metricsNamespace,
+		Subsystem:   "kafka",
+		Name:        "sent_bytes",
+		ConstLabels: constLabels,
 	})
 
 	requestsReceivedCount := promauto.NewCounter(prometheus.CounterOpts{
-		Namespace: metricsNamespace,
-		Subsystem: "kafka",
-		Name:      "requests_received_total"})
+		Namespace:   metricsNamespace,
+		Subsystem:   "kafka",
+		Name:        "requests_received_total",
+		ConstLabels: constLabels})
 	bytesReceived := promauto.NewCounter(prometheus.CounterOpts{
-		Namespace: metricsNamespace,
-		Subsystem: "kafka",
-		Name:      "received_bytes",
+		Namespace:   metricsNamespace,
+		Subsystem:   "kafka",
+		Name:        "received_bytes",
+		ConstLabels: constLabels,
 	})
 
 	return &clientHooks{
@@ -0,0 +1,23 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// CommitConsumerGroupOffsets commits the given offsets for a group. Kafka only accepts these commits from outside of
+// the group (no generation and no member id) if the group has no active members.
+func (s *Service) CommitConsumerGroupOffsets(ctx context.Context, group string, topics []kmsg.OffsetCommitRequestTopic) (*kmsg.OffsetCommitResponse, error) {
+	req := kmsg.NewOffsetCommitRequest()
+	req.Group = group
+	req.Topics = topics
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit group offsets for group '%v': %w", group, err)
+	}
+
+	return res, nil
+}
@@ -3,6 +3,7 @@ package kafka
 import (
 	"flag"
 	"fmt"
+	"github.com/cloudhut/kowl/backend/pkg/avro"
 	"github.com/cloudhut/kowl/backend/pkg/proto"
 	"github.com/cloudhut/kowl/backend/pkg/schema"
 )
@@ -18,9 +19,16 @@ type Config struct {
 	// Schema Registry
 	Schema   schema.Config `yaml:"schemaRegistry"`
 	Protobuf proto.Config  `yaml:"protobuf"`
+	Avro     avro.Config   `yaml:"avro"`
 
 	TLS  TLSConfig  `yaml:"tls"`
 	SASL SASLConfig `yaml:"sasl"`
+
+	ConsumerPool  ConsumerPoolConfig  `yaml:"consumerPool"`
+	MessageSearch MessageSearchConfig `yaml:"messageSearch"`
+
+	// TopicEncodings force the encodings which shall be used for decoding messages of specific topics
+	TopicEncodings []TopicEncodingConfig `yaml:"topicEncodings"`
 }
 
 // RegisterFlags registers all nested config flags.
@@ -28,6 +36,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.TLS.RegisterFlags(f)
 	c.SASL.RegisterFlags(f)
 	c.Protobuf.RegisterFlags(f)
+	c.Avro.RegisterFlags(f)
 	c.Schema.RegisterFlags(f)
 }
 
@@ -47,11 +56,33 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("failed to validate protobuf config: %w", err)
 	}
 
+	err = c.Avro.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate avro config: %w", err)
+	}
+
 	err = c.SASL.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate sasl config: %w", err)
 	}
 
+	err = c.ConsumerPool.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate consumer pool config: %w", err)
+	}
+
+	err = c.MessageSearch.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate message search config: %w", err)
+	}
+
+	for i, topicEncoding := range c.TopicEncodings {
+		err = topicEncoding.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate topic encoding config at index '%d': %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -60,6 +91,10 @@ func (c *Config) SetDefaults() {
 	c.ClientID = "kowl"
 	c.ClusterVersion = "1.0.0"
 
+	c.Schema.SetDefaults()
 	c.SASL.SetDefaults()
 	c.Protobuf.SetDefaults()
+	c.Avro.SetDefaults()
+	c.ConsumerPool.SetDefaults()
+	c.MessageSearch.SetDefaults()
 }
@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConsumerPoolConfig configures the pool of Kafka clients which are reused for consuming messages.
+type ConsumerPoolConfig struct {
+	// MaxClients is the maximum number of clients that may exist at the same time. If all clients are in use,
+	// further message searches wait until a client is returned to the pool.
+	MaxClients int `yaml:"maxClients"`
+
+	// IdleTimeout is the duration after which an unused client will be closed and removed from the pool.
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+}
+
+// Validate the consumer pool config
+func (c *ConsumerPoolConfig) Validate() error {
+	if c.MaxClients <= 0 {
+		return fmt.Errorf("max clients must be greater than 0")
+	}
+	if c.IdleTimeout <= 0 {
+		return fmt.Errorf("idle timeout must be greater than 0")
+	}
+
+	return nil
+}
+
+// SetDefaults for the consumer pool config
+func (c *ConsumerPoolConfig) SetDefaults() {
+	c.MaxClients = 10
+	c.IdleTimeout = 5 * time.Minute
+}
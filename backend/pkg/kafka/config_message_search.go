@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// MessageSearchConfig configures how messages are processed when searching the messages of a topic.
+type MessageSearchConfig struct {
+	// WorkerCount is the number of workers per message search which deserialize messages and run the filter code
+	WorkerCount int `yaml:"workerCount"`
+
+	// FilterTimeout is the maximum duration the filter code may run for a single message
+	FilterTimeout time.Duration `yaml:"filterTimeout"`
+}
+
+// Validate the message search config
+func (c *MessageSearchConfig) Validate() error {
+	if c.WorkerCount <= 0 {
+		return fmt.Errorf("worker count must be greater than 0")
+	}
+	if c.FilterTimeout <= 0 {
+		return fmt.Errorf("filter timeout must be greater than 0")
+	}
+
+	return nil
+}
+
+// SetDefaults for the message search config
+func (c *MessageSearchConfig) SetDefaults() {
+	c.WorkerCount = 4
+	c.FilterTimeout = 400 * time.Millisecond
+}
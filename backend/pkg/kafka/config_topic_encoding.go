@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// TopicEncodingConfig forces the encodings of the keys, values and headers in all topics which match the topic name
+// or pattern. The encodings of these records will no longer be detected, which prevents misclassifications such as
+// numeric strings that are valid JSON as well.
+type TopicEncodingConfig struct {
+	// TopicName must match the topic name exactly. Alternatively TopicPattern can be set to a regex, which must match
+	// the whole topic name.
+	TopicName    string `yaml:"topicName"`
+	TopicPattern string `yaml:"topicPattern"`
+
+	// Key, Value and Headers are the encodings which shall be used for decoding. Empty encodings will be detected.
+	// The encodings are validated once the deserializer has been created, because decoders can be registered.
+	Key     string `yaml:"key"`
+	Value   string `yaml:"value"`
+	Headers string `yaml:"headers"`
+}
+
+// Validate the topic encoding config
+func (c *TopicEncodingConfig) Validate() error {
+	if (c.TopicName == "") == (c.TopicPattern == "") {
+		return fmt.Errorf("either topic name or topic pattern must be set")
+	}
+	if c.TopicPattern != "" {
+		_, err := compileTopicPattern(c.TopicPattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compileTopicPattern compiles the regex so that it only matches whole topic names.
+func compileTopicPattern(pattern string) (*regexp.Regexp, error) {
+	regex, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile topic pattern '%v': %w", pattern, err)
+	}
+
+	return regex, nil
+}
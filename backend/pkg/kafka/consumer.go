@@ -6,8 +6,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/cloudhut/kowl/backend/pkg/interpreter"
-	"github.com/dop251/goja"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"go.uber.org/zap"
 )
@@ -31,8 +29,8 @@ type TopicMessage struct {
 	IsTransactional bool   `json:"isTransactional"`
 
 	Headers []MessageHeader      `json:"headers"`
-	Key     *deserializedPayload `json:"key"`
-	Value   *deserializedPayload `json:"value"`
+	Key     *DeserializedPayload `json:"key"`
+	Value   *DeserializedPayload `json:"value"`
 
 	IsValueNull bool `json:"isValueNull"` // true = tombstone
 
@@ -46,7 +44,7 @@ type TopicMessage struct {
 // a byte array, but keys are supposed to be strings only. Value however can be encoded in any format.
 type MessageHeader struct {
 	Key   string               `json:"key"`
-	Value *deserializedPayload `json:"value"`
+	Value *DeserializedPayload `json:"value"`
 }
 
 // PartitionConsumeRequest is a partitionID along with it's calculated start and end offset.
@@ -66,6 +64,11 @@ type TopicConsumeRequest struct {
 	MaxMessageCount       int
 	Partitions            map[int32]*PartitionConsumeRequest
 	FilterInterpreterCode string
+
+	// KeyEncoding and ValueEncoding force the encodings which shall be used for decoding the messages. If not set the
+	// encodings configured for the topic will be used or detected otherwise.
+	KeyEncoding   string
+	ValueEncoding string
 }
 
 type interpreterArguments struct {
@@ -78,31 +81,46 @@ type interpreterArguments struct {
 }
 
 func (s *Service) FetchMessages(ctx context.Context, progress IListMessagesProgress, consumeRequest TopicConsumeRequest) error {
-	// 1. Create new kgo client
-	client, err := s.NewKgoClient()
+	// 1. Compile the filter code once, so that it can be shared by all workers
+	filterProgram, err := compileFilterCode(consumeRequest.FilterInterpreterCode)
+	if err != nil {
+		s.Logger.Error("failed to setup interpreter", zap.Error(err))
+		progress.OnError(fmt.Sprintf("failed to setup interpreter: %v", err.Error()))
+		return err
+	}
+
+	// 2. Acquire kgo client from the pool, it will be released once the consumer go routine has stopped
+	client, err := s.consumerPool.Acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create new kafka client: %w", err)
+		return fmt.Errorf("failed to acquire kafka client: %w", err)
 	}
 
-	// 2. Create consumer workers
+	// 3. Create consumer workers
 	jobs := make(chan *kgo.Record, 100)
 	resultsCh := make(chan *TopicMessage, 100)
 	workerCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	encodings := recordEncodings{
+		Key:   MessageEncoding(consumeRequest.KeyEncoding),
+		Value: MessageEncoding(consumeRequest.ValueEncoding),
+	}
 	wg := sync.WaitGroup{}
-	workerCount := 4
-	for i := 0; i < workerCount; i++ {
+	for i := 0; i < s.Config.MessageSearch.WorkerCount; i++ {
 		// Setup JavaScript interpreter
-		isMessageOK, err := s.setupInterpreter(consumeRequest.FilterInterpreterCode)
+		isMessageOK, releaseInterpreter, err := setupInterpreter(filterProgram, s.Config.MessageSearch.FilterTimeout)
 		if err != nil {
 			s.Logger.Error("failed to setup interpreter", zap.Error(err))
 			progress.OnError(fmt.Sprintf("failed to setup interpreter: %v", err.Error()))
+			s.consumerPool.Release(client)
 			return err
 		}
 
 		wg.Add(1)
-		go s.startMessageWorker(workerCtx, &wg, isMessageOK, jobs, resultsCh)
+		go func() {
+			defer releaseInterpreter()
+			s.startMessageWorker(workerCtx, &wg, isMessageOK, encodings, jobs, resultsCh)
+		}()
 	}
 	// Close the results channel once all workers have finished processing jobs and therefore no senders are left anymore
 	go func() {
@@ -110,11 +128,11 @@ func (s *Service) FetchMessages(ctx context.Context, progress IListMessagesProgr
 		close(resultsCh)
 	}()
 
-	// 3. Start go routine that consumes messages from Kafka and produces these records on the jobs channel so that these
+	// 4. Start go routine that consumes messages from Kafka and produces these records on the jobs channel so that these
 	// can be decoded by our workers.
 	go s.consumeKafkaMessages(workerCtx, client, consumeRequest, jobs)
 
-	// 4. Receive decoded messages until our request is satisfied. Once that's the case we will cancel the context
+	// 5. Receive decoded messages until our request is satisfied. Once that's the case we will cancel the context
 	// that propagate to all the launched go routines.
 	messageCount := 0
 	messageCountByPartition := make(map[int32]int64)
@@ -146,7 +164,7 @@ func (s *Service) FetchMessages(ctx context.Context, progress IListMessagesProgr
 
 func (s *Service) consumeKafkaMessages(ctx context.Context, client *kgo.Client, consumeReq TopicConsumeRequest, jobs chan<- *kgo.Record) {
 	defer close(jobs)
-	defer client.Close()
+	defer s.consumerPool.Release(client)
 
 	// Assign partitions with right start offsets
 	partitionOffsets := make(map[string]map[int32]kgo.Offset)
@@ -177,7 +195,11 @@ func (s *Service) consumeKafkaMessages(ctx context.Context, client *kgo.Client,
 			// Iterate on all messages from this poll
 			for !iter.Done() {
 				record := iter.Next()
-				partitionReq := consumeReq.Partitions[record.Partition]
+				partitionReq, exists := consumeReq.Partitions[record.Partition]
+				if !exists || record.Topic != consumeReq.TopicName || record.Offset < partitionReq.StartOffset {
+					// Pooled clients might still return a record from a prior assignment, these must be skipped
+					continue
+				}
 
 				if record.Offset > partitionReq.EndOffset {
 					// reached end offset within this partition, we strive to fulfil the consume request so that we achieve
@@ -196,70 +218,6 @@ func (s *Service) consumeKafkaMessages(ctx context.Context, client *kgo.Client,
 	}
 }
 
-type isMessageOkFunc = func(args interpreterArguments) (bool, error)
-
-// SetupInterpreter initializes the JavaScript interpreter along with the given JS code. It returns a wrapper function
-// which accepts all Kafka message properties (offset, key, value, ...) and returns true (message shall be returned) or false
-// (message shall be filtered).
-func (s *Service) setupInterpreter(interpreterCode string) (isMessageOkFunc, error) {
-	// In case there's no code for the interpreter let's return a dummy function which always allows all messages
-	if interpreterCode == "" {
-		return func(args interpreterArguments) (bool, error) { return true, nil }, nil
-	}
-
-	vm := goja.New()
-	code := fmt.Sprintf(`var isMessageOk = function() {%s}`, interpreterCode)
-	_, err := vm.RunString(code)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compile given interpreter code: %w", err)
-	}
-
-	// Make find() function available inside of the JavaScript VM
-	_, err = vm.RunString(interpreter.FindFunction)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compile findFunction: %w", err)
-	}
-
-	// We use named return parameter here because this way we can return a error message in recover().
-	// Returning a proper error is important because we want to stop the consumer for this partition
-	// if we exceed the execution timeout.
-	isMessageOk := func(args interpreterArguments) (isOk bool, err error) {
-		// 1. Setup timeout check. If execution takes longer than 400ms the VM will be killed
-		// Ctx is used to notify the below go routine once we are done
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		// Send interrupt signal to VM if execution has taken too long
-		go func() {
-			timer := time.NewTimer(400 * time.Millisecond)
-
-			select {
-			case <-timer.C:
-				vm.Interrupt("timeout after 400ms")
-				return
-			case <-ctx.Done():
-				return
-			}
-		}()
-
-		// Call Javascript function and check if it could be evaluated and whether it returned true or false
-		vm.Set("partitionID", args.PartitionID)
-		vm.Set("offset", args.Offset)
-		vm.Set("timestamp", args.Timestamp)
-		vm.Set("key", args.Key)
-		vm.Set("value", args.Value)
-		vm.Set("headers", args.HeadersByKey)
-		isOkRes, err := vm.RunString("isMessageOk()")
-		if err != nil {
-			return false, fmt.Errorf("failed to evaluate javascript code: %w", err)
-		}
-
-		return isOkRes.ToBoolean(), nil
-	}
-
-	return isMessageOk, nil
-}
-
 func compressionTypeDisplayname(compressionType uint8) string {
 	switch compressionType {
 	case 0:
@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+)
+
+// consumerClientPool is a bounded pool of Kafka clients which are used to consume messages. Creating a new client for
+// each message search is expensive, because every client has to bootstrap the cluster metadata and authenticate
+// against the brokers (which may take several seconds when using Kerberos). Pooled clients are assigned the requested
+// partitions when they are acquired and unassigned again once they are returned to the pool.
+type consumerClientPool struct {
+	logger      *zap.Logger
+	newClient   func() (*kgo.Client, error)
+	idleTimeout time.Duration
+
+	// slots limits the number of clients which can be in use at the same time. Because new clients are only created
+	// if there is no idle client, this also limits the total number of clients.
+	slots chan struct{}
+
+	mutex sync.Mutex
+	idle  []*pooledConsumerClient // The most recently returned client is at the end
+
+	inUseGauge prometheus.Gauge
+	idleGauge  prometheus.Gauge
+}
+
+type pooledConsumerClient struct {
+	client   *kgo.Client
+	lastUsed time.Time
+}
+
+func newConsumerClientPool(cfg ConsumerPoolConfig, logger *zap.Logger, metricsNamespace string, clusterName string, newClient func() (*kgo.Client, error)) *consumerClientPool {
+	constLabels := prometheus.Labels{"cluster": clusterName}
+	inUseGauge := promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   "kafka",
+		Name:        "consumer_pool_clients_in_use",
+		Help:        "Number of pooled Kafka clients which are currently used to consume messages",
+		ConstLabels: constLabels,
+	})
+	idleGauge := promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   "kafka",
+		Name:        "consumer_pool_clients_idle",
+		Help:        "Number of pooled Kafka clients which are idle and can be reused",
+		ConstLabels: constLabels,
+	})
+
+	return &consumerClientPool{
+		logger:      logger,
+		newClient:   newClient,
+		idleTimeout: cfg.IdleTimeout,
+		slots:       make(chan struct{}, cfg.MaxClients),
+		idle:        make([]*pooledConsumerClient, 0, cfg.MaxClients),
+		inUseGauge:  inUseGauge,
+		idleGauge:   idleGauge,
+	}
+}
+
+// Acquire returns an idle client or creates a new one if there is none. If the maximum number of clients is in use,
+// Acquire blocks until a client has been released or the context is done. Acquired clients must be released.
+func (p *consumerClientPool) Acquire(ctx context.Context) (*kgo.Client, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mutex.Lock()
+	if len(p.idle) > 0 {
+		pooled := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.idleGauge.Set(float64(len(p.idle)))
+		p.mutex.Unlock()
+
+		p.inUseGauge.Inc()
+		return pooled.client, nil
+	}
+	p.mutex.Unlock()
+
+	client, err := p.newClient()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	p.inUseGauge.Inc()
+
+	return client, nil
+}
+
+// Release unassigns all partitions of the given client and returns it to the pool. The client must not be used
+// anymore by the caller.
+func (p *consumerClientPool) Release(client *kgo.Client) {
+	// Assigning no partitions removes the prior assignment along with all buffered fetches
+	client.AssignPartitions()
+
+	p.mutex.Lock()
+	p.idle = append(p.idle, &pooledConsumerClient{client: client, lastUsed: time.Now()})
+	p.idleGauge.Set(float64(len(p.idle)))
+	p.mutex.Unlock()
+
+	p.inUseGauge.Dec()
+	<-p.slots
+}
+
+// Start periodically closes clients which have been idle for longer than the idle timeout until the context is done.
+func (p *consumerClientPool) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.evictIdleClients()
+		}
+	}
+}
+
+// evictIdleClients closes and removes all clients which have been idle for longer than the idle timeout.
+func (p *consumerClientPool) evictIdleClients() {
+	p.mutex.Lock()
+	remaining := make([]*pooledConsumerClient, 0, cap(p.idle))
+	evicted := make([]*pooledConsumerClient, 0)
+	for _, pooled := range p.idle {
+		if time.Since(pooled.lastUsed) >= p.idleTimeout {
+			evicted = append(evicted, pooled)
+			continue
+		}
+		remaining = append(remaining, pooled)
+	}
+	p.idle = remaining
+	p.idleGauge.Set(float64(len(p.idle)))
+	p.mutex.Unlock()
+
+	// Closing clients may take a while, therefore we do this without holding the lock
+	for _, pooled := range evicted {
+		pooled.client.Close()
+	}
+	if len(evicted) > 0 {
+		p.logger.Debug("closed idle kafka consumer clients", zap.Int("evicted_clients", len(evicted)))
+	}
+}
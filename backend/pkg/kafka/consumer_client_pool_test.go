@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+)
+
+func newTestConsumerClientPool(t *testing.T, maxClients int, idleTimeout time.Duration) (*consumerClientPool, *int) {
+	createdClients := 0
+	cfg := ConsumerPoolConfig{MaxClients: maxClients, IdleTimeout: idleTimeout}
+	pool := newConsumerClientPool(cfg, zap.NewNop(), "test", t.Name(), func() (*kgo.Client, error) {
+		createdClients++
+		return kgo.NewClient(kgo.SeedBrokers("localhost:9092"))
+	})
+
+	return pool, &createdClients
+}
+
+func TestConsumerClientPool_ReusesReleasedClients(t *testing.T) {
+	pool, createdClients := newTestConsumerClientPool(t, 2, time.Minute)
+
+	client, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	pool.Release(client)
+
+	reused, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, client, reused)
+	assert.Equal(t, 1, *createdClients)
+	pool.Release(reused)
+}
+
+func TestConsumerClientPool_BlocksWhenExhausted(t *testing.T) {
+	pool, createdClients := newTestConsumerClientPool(t, 1, time.Minute)
+
+	client, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = pool.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Once the client is released it can be acquired by the waiting caller
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pool.Release(client)
+	}()
+	reused, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, client, reused)
+	assert.Equal(t, 1, *createdClients)
+	pool.Release(reused)
+}
+
+func TestConsumerClientPool_EvictsIdleClients(t *testing.T) {
+	pool, createdClients := newTestConsumerClientPool(t, 2, 10*time.Millisecond)
+
+	client, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	pool.Release(client)
+
+	time.Sleep(20 * time.Millisecond)
+	pool.evictIdleClients()
+	assert.Empty(t, pool.idle)
+
+	newClient, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.NotSame(t, client, newClient)
+	assert.Equal(t, 2, *createdClients)
+	pool.Release(newClient)
+}
@@ -9,12 +9,12 @@ import (
 	"time"
 )
 
-func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, isMessageOK isMessageOkFunc, jobs <-chan *kgo.Record, resultsCh chan<- *TopicMessage) {
+func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, isMessageOK isMessageOkFunc, encodings recordEncodings, jobs <-chan *kgo.Record, resultsCh chan<- *TopicMessage) {
 	defer wg.Done()
 
 	for record := range jobs {
 		// Run Interpreter filter and check if message passes the filter
-		deserializedRec := s.Deserializer.DeserializeRecord(record)
+		deserializedRec := s.deserializeRecord(record, encodings)
 
 		headersByKey := make(map[string]interface{}, len(deserializedRec.Headers))
 		headers := make([]MessageHeader, 0)
@@ -65,3 +65,31 @@ func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, is
 		}
 	}
 }
+
+// deserializeRecord deserializes the record and recovers from panics, so that a single malformed record can't crash
+// the whole process. The key, value and headers of such records are returned as binary along with the panic.
+func (s *Service) deserializeRecord(record *kgo.Record, encodings recordEncodings) (deserializedRec *deserializedRecord) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.Logger.Error("recovered from panic while deserializing record",
+				zap.String("topic_name", record.Topic),
+				zap.Int32("partition_id", record.Partition),
+				zap.Int64("offset", record.Offset),
+				zap.Any("panic", r))
+
+			decodeError := fmt.Sprintf("failed to deserialize record: %v", r)
+			deserializedRec = &deserializedRecord{
+				Key:     binaryPayload(record.Key),
+				Value:   binaryPayload(record.Value),
+				Headers: make(map[string]*DeserializedPayload),
+			}
+			deserializedRec.Key.DecodeError = decodeError
+			deserializedRec.Value.DecodeError = decodeError
+			for _, header := range record.Headers {
+				deserializedRec.Headers[header.Key] = binaryPayload(header.Value)
+			}
+		}
+	}()
+
+	return s.Deserializer.DeserializeRecord(record, encodings)
+}
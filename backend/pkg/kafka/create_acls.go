@@ -0,0 +1,21 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// CreateACLs creates one or more ACL bindings.
+func (s *Service) CreateACLs(ctx context.Context, creations []kmsg.CreateACLsRequestCreation) (*kmsg.CreateACLsResponse, error) {
+	req := kmsg.NewCreateACLsRequest()
+	req.Creations = creations
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ACLs: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,23 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// CreatePartitions increases the partition count of the given topics. If validateOnly is true the request will only
+// be validated by the brokers without actually creating the partitions.
+func (s *Service) CreatePartitions(ctx context.Context, topics []kmsg.CreatePartitionsRequestTopic, validateOnly bool) (*kmsg.CreatePartitionsResponse, error) {
+	req := kmsg.NewCreatePartitionsRequest()
+	req.Topics = topics
+	req.ValidateOnly = validateOnly
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create partitions: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,23 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// CreateTopics creates the given topics. If validateOnly is true the request will only be validated by the brokers
+// without actually creating the topics.
+func (s *Service) CreateTopics(ctx context.Context, topics []kmsg.CreateTopicsRequestTopic, validateOnly bool) (*kmsg.CreateTopicsResponse, error) {
+	req := kmsg.NewCreateTopicsRequest()
+	req.Topics = topics
+	req.ValidateOnly = validateOnly
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topics: %w", err)
+	}
+
+	return res, nil
+}
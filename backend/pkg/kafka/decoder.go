@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// Decoder decodes payloads of a single encoding into a Go native form. The deserializer tries all registered decoders
+// to detect a payload's encoding, unless an encoding has been requested for the payload.
+type Decoder interface {
+	// Encoding is the name of the encoding, which can be requested to force this decoder.
+	Encoding() MessageEncoding
+
+	// Priority determines the order in which decoders are tried during detection. Decoders with a lower priority are
+	// tried first, hence decoders with specific detection rules (e.g. magic bytes) should have a lower priority than
+	// decoders which accept almost any payload.
+	Priority() int
+
+	// Detect returns whether the payload may be encoded with this decoder's encoding. It should be cheap, because it's
+	// called for every payload. Decoders which can't tell (e.g. numbers) return false and are only used if their
+	// encoding has been requested.
+	Detect(payload []byte) bool
+
+	// Decode decodes the payload. The returned payload's Object is passed to the JavaScript filter, therefore it must
+	// consist of native types (maps, slices, strings, numbers, ...) only.
+	Decode(payload []byte, topicName string, recordType proto.RecordPropertyType) (*DeserializedPayload, error)
+}
+
+// decoderRegistry holds all decoders by encoding and sorted by their priority.
+type decoderRegistry struct {
+	byEncoding map[MessageEncoding]Decoder
+	byPriority []Decoder
+}
+
+func newDecoderRegistry() *decoderRegistry {
+	return &decoderRegistry{
+		byEncoding: make(map[MessageEncoding]Decoder),
+		byPriority: make([]Decoder, 0),
+	}
+}
+
+func (r *decoderRegistry) register(decoder Decoder) error {
+	encoding := decoder.Encoding()
+	if encoding == "" {
+		return fmt.Errorf("decoder must have an encoding")
+	}
+	if _, exists := r.byEncoding[encoding]; exists {
+		return fmt.Errorf("a decoder for encoding '%v' has already been registered", encoding)
+	}
+
+	r.byEncoding[encoding] = decoder
+	r.byPriority = append(r.byPriority, decoder)
+	sort.SliceStable(r.byPriority, func(i, j int) bool {
+		return r.byPriority[i].Priority() < r.byPriority[j].Priority()
+	})
+
+	return nil
+}
+
+func (r *decoderRegistry) get(encoding MessageEncoding) (Decoder, bool) {
+	decoder, exists := r.byEncoding[encoding]
+	return decoder, exists
+}
+
+// decode decodes the payload with the given decoder. Panics are recovered and returned as error, so that a single
+// malformed payload that trips a decoder can't crash the whole process.
+func decode(decoder Decoder, payload []byte, topicName string, recordType proto.RecordPropertyType) (deserialized *DeserializedPayload, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			deserialized = nil
+			err = fmt.Errorf("decoder panicked: %v", r)
+		}
+	}()
+
+	return decoder.Decode(payload, topicName, recordType)
+}
+
+// encodings returns the encodings of all registered decoders sorted by their priority.
+func (r *decoderRegistry) encodings() []MessageEncoding {
+	encodings := make([]MessageEncoding, len(r.byPriority))
+	for i, decoder := range r.byPriority {
+		encodings[i] = decoder.Encoding()
+	}
+	return encodings
+}
+
+// normalizeDecodedValue converts a decoded value into a form that can be marshalled to JSON and is understood by the
+// JavaScript filter. Maps with non-string keys become string keyed maps, times become RFC 3339 strings and big
+// integers become strings. Types which are specific to a decoder can be converted by the given convert function,
+// which returns false if it doesn't handle the value's type.
+func normalizeDecodedValue(value interface{}, convert func(interface{}) (interface{}, bool)) interface{} {
+	if convert != nil {
+		if converted, ok := convert(value); ok {
+			return converted
+		}
+	}
+
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			res[fmt.Sprint(normalizeDecodedValue(key, convert))] = normalizeDecodedValue(val, convert)
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			res[key] = normalizeDecodedValue(val, convert)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, val := range v {
+			res[i] = normalizeDecodedValue(val, convert)
+		}
+		return res
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case big.Int:
+		return v.String()
+	case *big.Int:
+		return v.String()
+	default:
+		return value
+	}
+}
@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/avro"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/linkedin/goavro/v2"
+)
+
+// avroOCFMagic are the first bytes of each Avro object container file
+var avroOCFMagic = []byte("Obj\x01")
+
+// avroDecoder decodes Avro payloads, which are either object container files, in the schema registry wire format or
+// encoded with one of the configured .avsc schemas.
+type avroDecoder struct {
+	schemaSvc *schema.Service
+	avroSvc   *avro.Service
+}
+
+func (d *avroDecoder) Encoding() MessageEncoding {
+	return messageEncodingAvro
+}
+
+func (d *avroDecoder) Priority() int {
+	return 300
+}
+
+func (d *avroDecoder) Detect(payload []byte) bool {
+	if bytes.HasPrefix(payload, avroOCFMagic) {
+		return true
+	}
+	if _, ok := schemaRegistrySchemaID(d.schemaSvc, payload, schema.SchemaTypeAvro); ok {
+		return true
+	}
+	return d.avroSvc != nil
+}
+
+func (d *avroDecoder) Decode(payload []byte, topicName string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if bytes.HasPrefix(payload, avroOCFMagic) {
+		deserialized, err := deserializeAvroOCFPayload(payload)
+		if err == nil || d.avroSvc == nil {
+			return deserialized, err
+		}
+	}
+
+	if schemaID, ok := schemaRegistrySchemaID(d.schemaSvc, payload, schema.SchemaTypeAvro); ok {
+		deserialized, err := deserializeAvroPayload(d.schemaSvc, payload, schemaID)
+		if err == nil || d.avroSvc == nil {
+			return deserialized, err
+		}
+	}
+
+	if d.avroSvc == nil {
+		return nil, fmt.Errorf("payload is neither an avro schema registry payload nor an object container file")
+	}
+	return d.deserializeLocalAvroPayload(payload, topicName, recordType)
+}
+
+// deserializeLocalAvroPayload decodes an Avro payload with the Avro type that is mapped to the topic.
+func (d *avroDecoder) deserializeLocalAvroPayload(payload []byte, topicName string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	native, jsonBytes, err := d.avroSvc.UnmarshalPayload(payload, topicName, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingAvro,
+		},
+		Object:             native,
+		RecognizedEncoding: messageEncodingAvro,
+		Size:               len(payload),
+	}, nil
+}
+
+// deserializeAvroPayload decodes an Avro payload in the schema registry wire format.
+func deserializeAvroPayload(schemaSvc *schema.Service, payload []byte, schemaID uint32) (*DeserializedPayload, error) {
+	codec, err := schemaSvc.GetAvroSchemaByID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := codec.NativeFromBinary(payload[5:])
+	if err != nil {
+		return nil, err
+	}
+	normalized, _ := codec.TextualFromNative(nil, native)
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            normalized,
+			RecognizedEncoding: messageEncodingAvro,
+		},
+		Object:             native,
+		RecognizedEncoding: messageEncodingAvro,
+		SchemaID:           schemaID,
+		AvroSchemaID:       schemaID,
+		Size:               len(payload),
+	}, nil
+}
+
+// deserializeAvroOCFPayload decodes all records of an Avro object container file using the file's embedded schema.
+// The records are returned as array, because a container file may contain any number of records.
+func deserializeAvroOCFPayload(payload []byte) (*DeserializedPayload, error) {
+	reader, err := goavro.NewOCFReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]interface{}, 0)
+	for reader.Scan() {
+		record, err := reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if reader.Err() != nil {
+		return nil, reader.Err()
+	}
+
+	// Encode each record with the embedded schema, so that the JSON representation matches the Avro schema registry
+	// payloads (e.g. for unions)
+	textualRecords := make([]json.RawMessage, len(records))
+	for i, record := range records {
+		textual, err := reader.Codec().TextualFromNative(nil, record)
+		if err != nil {
+			return nil, err
+		}
+		textualRecords[i] = textual
+	}
+	normalized, err := json.Marshal(textualRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            normalized,
+			RecognizedEncoding: messageEncodingAvro,
+		},
+		Object:             records,
+		RecognizedEncoding: messageEncodingAvro,
+		Size:               len(payload),
+	}, nil
+}
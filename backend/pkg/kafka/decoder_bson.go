@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// bsonDecoder decodes BSON documents (see http://bsonspec.org/spec.html). Payloads are detected if they start with
+// the document's length, which must match the payload size, and end with the document's terminating null byte.
+type bsonDecoder struct{}
+
+func (d *bsonDecoder) Encoding() MessageEncoding {
+	return messageEncodingBSON
+}
+
+func (d *bsonDecoder) Priority() int {
+	return 510
+}
+
+func (d *bsonDecoder) Detect(payload []byte) bool {
+	if len(payload) < 5 {
+		return false
+	}
+	return int(binary.LittleEndian.Uint32(payload)) == len(payload) && payload[len(payload)-1] == 0x00
+}
+
+// Decode converts the document into relaxed MongoDB extended JSON, so that BSON types without a JSON counterpart
+// (e.g. ObjectIds or dates) are represented like in the mongo shell.
+func (d *bsonDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	doc := bson.Raw(payload)
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to decode bson payload: %w", err)
+	}
+
+	jsonBytes, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert bson payload to extended JSON: %w", err)
+	}
+
+	// Numbers are decoded as json.Number, so that int64 values beyond 2^53 don't lose their precision in the object
+	// which is passed to the JavaScript filter.
+	dec := json.NewDecoder(bytes.NewReader(jsonBytes))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to decode extended JSON of bson payload: %w", err)
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingBSON,
+		},
+		Object:             normalizeDecodedValue(obj, convertJSONNumber),
+		RecognizedEncoding: messageEncodingBSON,
+		Size:               len(payload),
+	}, nil
+}
+
+// convertJSONNumber converts a json.Number into an int64 if it is an integer and into a float64 otherwise.
+func convertJSONNumber(value interface{}) (interface{}, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil, false
+	}
+	if i, err := n.Int64(); err == nil {
+		return i, true
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return n.String(), true
+	}
+	return f, true
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cborSelfDescribeTag is the encoded CBOR tag 55799, which may prefix any CBOR payload to mark it as such.
+var cborSelfDescribeTag = []byte{0xd9, 0xd9, 0xf7}
+
+// cborDecoder decodes CBOR payloads. Payloads are detected if they start with the self-describe tag or if their top
+// level value is a map.
+type cborDecoder struct{}
+
+func (d *cborDecoder) Encoding() MessageEncoding {
+	return messageEncodingCBOR
+}
+
+func (d *cborDecoder) Priority() int {
+	return 520
+}
+
+func (d *cborDecoder) Detect(payload []byte) bool {
+	if bytes.HasPrefix(payload, cborSelfDescribeTag) {
+		return true
+	}
+	if len(payload) == 0 {
+		return false
+	}
+	// Major type 5 (map) with a length of 1 to 23 entries, 1 to 8 byte length or indefinite length
+	first := payload[0]
+	return first >= 0xa1 && (first <= 0xbb || first == 0xbf)
+}
+
+func (d *cborDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	dec := cbor.NewDecoder(bytes.NewReader(payload))
+	var obj interface{}
+	err := dec.Decode(&obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cbor payload: %w", err)
+	}
+	if dec.NumBytesRead() != len(payload) {
+		return nil, fmt.Errorf("failed to decode cbor payload: %d bytes left after decoding", len(payload)-dec.NumBytesRead())
+	}
+
+	return newJSONPayload(messageEncodingCBOR, normalizeDecodedValue(obj, convertCBORValue), payload)
+}
+
+// convertCBORValue converts tags, which are not known to the CBOR library, into an object with the tag number and
+// the tag's content.
+func convertCBORValue(value interface{}) (interface{}, bool) {
+	tag, isTag := value.(cbor.Tag)
+	if !isTag {
+		return nil, false
+	}
+
+	return map[string]interface{}{
+		"tag":   tag.Number,
+		"value": normalizeDecodedValue(tag.Content, convertCBORValue),
+	}, true
+}
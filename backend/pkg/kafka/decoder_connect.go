@@ -0,0 +1,225 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// The Kafka Connect decoders decode the records of Kafka Connect's internal topics (offset.storage.topic,
+// config.storage.topic and status.storage.topic). The keys are parsed into their components, such as the connector
+// name and task id. The values are JSON objects, which are written by Connect's JsonConverter.
+
+// ConnectOffsetKey is the key of a record in the connect offsets topic. It identifies a source partition of a
+// source connector.
+type ConnectOffsetKey struct {
+	Connector string      `json:"connector"`
+	Partition interface{} `json:"partition"`
+}
+
+// ConnectConfigKey is the key of a record in the connect configs topic.
+type ConnectConfigKey struct {
+	// Type is one of connector, task, commit, targetState, taskCountRecord, sessionKey or loggerLevel
+	Type      string `json:"type"`
+	Connector string `json:"connector,omitempty"`
+	Task      *int32 `json:"task,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ConnectStatusKey is the key of a record in the connect status topic.
+type ConnectStatusKey struct {
+	// Type is one of connector, task or topic
+	Type      string `json:"type"`
+	Connector string `json:"connector"`
+	Task      *int32 `json:"task,omitempty"`
+	Topic     string `json:"topic,omitempty"`
+}
+
+// connectOffsetsDecoder decodes the records of the connect offsets topic.
+type connectOffsetsDecoder struct{}
+
+func (d *connectOffsetsDecoder) Encoding() MessageEncoding {
+	return messageEncodingConnectOffsets
+}
+
+func (d *connectOffsetsDecoder) Priority() int {
+	return 800
+}
+
+// Detect always returns false, the decoder is selected by the topic encodings instead.
+func (d *connectOffsetsDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *connectOffsetsDecoder) Decode(payload []byte, _ string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	obj, err := decodeConnectJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	if recordType != proto.RecordKey {
+		return newTypedPayload(messageEncodingConnectOffsets, obj, payload)
+	}
+
+	// The key is an array which consists of the connector name and the source partition
+	arr, ok := obj.([]interface{})
+	if !ok || len(arr) != 2 {
+		return nil, fmt.Errorf("connect offset key must be an array with the connector name and the source partition")
+	}
+	connector, ok := arr[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("connect offset key must start with the connector name")
+	}
+
+	return newTypedPayload(messageEncodingConnectOffsets, ConnectOffsetKey{Connector: connector, Partition: arr[1]}, payload)
+}
+
+// connectConfigsDecoder decodes the records of the connect configs topic.
+type connectConfigsDecoder struct{}
+
+func (d *connectConfigsDecoder) Encoding() MessageEncoding {
+	return messageEncodingConnectConfigs
+}
+
+func (d *connectConfigsDecoder) Priority() int {
+	return 800
+}
+
+// Detect always returns false, the decoder is selected by the topic encodings instead.
+func (d *connectConfigsDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *connectConfigsDecoder) Decode(payload []byte, _ string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if recordType != proto.RecordKey {
+		obj, err := decodeConnectJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return newTypedPayload(messageEncodingConnectConfigs, obj, payload)
+	}
+
+	key, err := parseConnectConfigKey(string(payload))
+	if err != nil {
+		return nil, err
+	}
+	return newTypedPayload(messageEncodingConnectConfigs, key, payload)
+}
+
+func parseConnectConfigKey(key string) (ConnectConfigKey, error) {
+	// The order matters, because some prefixes are prefixes of others (e.g. task- and task-count-record-)
+	switch {
+	case key == "session-key":
+		return ConnectConfigKey{Type: "sessionKey"}, nil
+	case strings.HasPrefix(key, "target-state-"):
+		return ConnectConfigKey{Type: "targetState", Connector: strings.TrimPrefix(key, "target-state-")}, nil
+	case strings.HasPrefix(key, "task-count-record-"):
+		return ConnectConfigKey{Type: "taskCountRecord", Connector: strings.TrimPrefix(key, "task-count-record-")}, nil
+	case strings.HasPrefix(key, "logger-cluster-"):
+		return ConnectConfigKey{Type: "loggerLevel", Namespace: strings.TrimPrefix(key, "logger-cluster-")}, nil
+	case strings.HasPrefix(key, "connector-"):
+		return ConnectConfigKey{Type: "connector", Connector: strings.TrimPrefix(key, "connector-")}, nil
+	case strings.HasPrefix(key, "commit-"):
+		return ConnectConfigKey{Type: "commit", Connector: strings.TrimPrefix(key, "commit-")}, nil
+	case strings.HasPrefix(key, "task-"):
+		connector, task, err := parseConnectTaskID(strings.TrimPrefix(key, "task-"))
+		if err != nil {
+			return ConnectConfigKey{}, err
+		}
+		return ConnectConfigKey{Type: "task", Connector: connector, Task: &task}, nil
+	default:
+		return ConnectConfigKey{}, fmt.Errorf("unknown connect config key '%v'", key)
+	}
+}
+
+// connectStatusDecoder decodes the records of the connect status topic.
+type connectStatusDecoder struct{}
+
+func (d *connectStatusDecoder) Encoding() MessageEncoding {
+	return messageEncodingConnectStatus
+}
+
+func (d *connectStatusDecoder) Priority() int {
+	return 800
+}
+
+// Detect always returns false, the decoder is selected by the topic encodings instead.
+func (d *connectStatusDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *connectStatusDecoder) Decode(payload []byte, _ string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if recordType != proto.RecordKey {
+		obj, err := decodeConnectJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return newTypedPayload(messageEncodingConnectStatus, obj, payload)
+	}
+
+	key, err := parseConnectStatusKey(string(payload))
+	if err != nil {
+		return nil, err
+	}
+	return newTypedPayload(messageEncodingConnectStatus, key, payload)
+}
+
+func parseConnectStatusKey(key string) (ConnectStatusKey, error) {
+	switch {
+	case strings.HasPrefix(key, "status-connector-"):
+		return ConnectStatusKey{Type: "connector", Connector: strings.TrimPrefix(key, "status-connector-")}, nil
+	case strings.HasPrefix(key, "status-task-"):
+		connector, task, err := parseConnectTaskID(strings.TrimPrefix(key, "status-task-"))
+		if err != nil {
+			return ConnectStatusKey{}, err
+		}
+		return ConnectStatusKey{Type: "task", Connector: connector, Task: &task}, nil
+	case strings.HasPrefix(key, "status-topic-"):
+		// status-topic-<topic>:connector-<connector>, topic names can't contain colons
+		topicAndConnector := strings.SplitN(strings.TrimPrefix(key, "status-topic-"), ":connector-", 2)
+		if len(topicAndConnector) != 2 {
+			return ConnectStatusKey{}, fmt.Errorf("invalid connect topic status key '%v'", key)
+		}
+		return ConnectStatusKey{Type: "topic", Topic: topicAndConnector[0], Connector: topicAndConnector[1]}, nil
+	default:
+		return ConnectStatusKey{}, fmt.Errorf("unknown connect status key '%v'", key)
+	}
+}
+
+// parseConnectTaskID parses task ids in the form of <connector>-<task number>. Connector names may contain dashes.
+func parseConnectTaskID(taskID string) (string, int32, error) {
+	i := strings.LastIndex(taskID, "-")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid connect task id '%v'", taskID)
+	}
+	task, err := strconv.ParseInt(taskID[i+1:], 10, 32)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid connect task id '%v': %w", taskID, err)
+	}
+
+	return taskID[:i], int32(task), nil
+}
+
+// decodeConnectJSON decodes a payload that has been written by Connect's JsonConverter. If schemas have been enabled
+// for the converter, the payload is wrapped in an envelope along with its schema. The envelope will be removed.
+func decodeConnectJSON(payload []byte) (interface{}, error) {
+	var obj interface{}
+	err := json.Unmarshal(payload, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, isObject := obj.(map[string]interface{})
+	if !isObject || len(envelope) != 2 {
+		return obj, nil
+	}
+	_, hasSchema := envelope["schema"]
+	envelopedPayload, hasPayload := envelope["payload"]
+	if hasSchema && hasPayload {
+		return envelopedPayload, nil
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,409 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// consumerOffsetsDecoder decodes the records of the __consumer_offsets topic, in which the group coordinators store
+// the committed offsets and the group metadata. The key's version determines the type of the record and thereby the
+// structure of the value, hence records should be decoded with deserializeConsumerOffsetsRecord. The decoder itself
+// is used if only the key or the value is requested to be decoded as consumer offsets.
+type consumerOffsetsDecoder struct{}
+
+// OffsetCommitKey is the key of a committed offset (key version 0 and 1).
+type OffsetCommitKey struct {
+	Version   int16  `json:"version"`
+	Group     string `json:"group"`
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+}
+
+// OffsetCommitValue is a committed offset.
+type OffsetCommitValue struct {
+	Version         int16  `json:"version"`
+	Offset          int64  `json:"offset"`
+	LeaderEpoch     *int32 `json:"leaderEpoch,omitempty"` // v3+
+	Metadata        string `json:"metadata"`
+	CommitTimestamp int64  `json:"commitTimestamp"`
+	ExpireTimestamp *int64 `json:"expireTimestamp,omitempty"` // v1 only
+}
+
+// GroupMetadataKey is the key of the group metadata (key version 2).
+type GroupMetadataKey struct {
+	Version int16  `json:"version"`
+	Group   string `json:"group"`
+}
+
+// GroupMetadataValue is the state of a classic consumer group.
+type GroupMetadataValue struct {
+	Version               int16                 `json:"version"`
+	ProtocolType          string                `json:"protocolType"`
+	Generation            int32                 `json:"generation"`
+	Protocol              *string               `json:"protocol"`
+	Leader                *string               `json:"leader"`
+	CurrentStateTimestamp *int64                `json:"currentStateTimestamp,omitempty"` // v2+
+	Members               []GroupMetadataMember `json:"members"`
+}
+
+// GroupMetadataMember is a member of a classic consumer group. If the group uses the consumer protocol, the member's
+// subscription and assignment are decoded, otherwise they are shown as (base64 encoded) bytes.
+type GroupMetadataMember struct {
+	MemberID string `json:"memberId"`
+	// GroupInstanceID is the id of a static member (v3+)
+	GroupInstanceID    *string     `json:"groupInstanceId"`
+	ClientID           string      `json:"clientId"`
+	ClientHost         string      `json:"clientHost"`
+	RebalanceTimeoutMs *int32      `json:"rebalanceTimeoutMs,omitempty"` // v1+
+	SessionTimeoutMs   int32       `json:"sessionTimeoutMs"`
+	Subscription       interface{} `json:"subscription"`
+	Assignment         interface{} `json:"assignment"`
+}
+
+// ConsumerProtocolSubscription is the member metadata of a group using the consumer protocol.
+type ConsumerProtocolSubscription struct {
+	Version         int16                             `json:"version"`
+	Topics          []string                          `json:"topics"`
+	UserData        []byte                            `json:"userData"`
+	OwnedPartitions []ConsumerProtocolTopicPartitions `json:"ownedPartitions,omitempty"` // v1+
+	GenerationID    *int32                            `json:"generationId,omitempty"`    // v2+
+	RackID          *string                           `json:"rackId,omitempty"`          // v3+
+}
+
+// ConsumerProtocolAssignment are the partitions that have been assigned to a member of a group using the consumer
+// protocol.
+type ConsumerProtocolAssignment struct {
+	Version  int16                             `json:"version"`
+	Topics   []ConsumerProtocolTopicPartitions `json:"topics"`
+	UserData []byte                            `json:"userData"`
+}
+
+type ConsumerProtocolTopicPartitions struct {
+	Topic      string  `json:"topic"`
+	Partitions []int32 `json:"partitions"`
+}
+
+// ConsumerGroupRecordKey is the key of the records which are written by the group coordinator of the new consumer
+// group protocol (KIP-848). The key's version is the record type.
+type ConsumerGroupRecordKey struct {
+	Version  int16  `json:"version"`
+	Type     string `json:"type"`
+	Group    string `json:"group"`
+	MemberID string `json:"memberId,omitempty"`
+}
+
+// ConsumerGroupEpochValue is the value of the consumer group metadata and target assignment metadata records of the
+// new consumer group protocol.
+type ConsumerGroupEpochValue struct {
+	Version int16 `json:"version"`
+	Epoch   int32 `json:"epoch"`
+}
+
+// consumerGroupRecordTypes are the names of the record types of the new consumer group protocol by their key
+// version, along with whether the key contains a member id and whether the value is an epoch only.
+var consumerGroupRecordTypes = map[int16]struct {
+	name         string
+	hasMemberID  bool
+	hasEpochOnly bool
+}{
+	3: {name: "ConsumerGroupMetadata", hasEpochOnly: true},
+	4: {name: "ConsumerGroupPartitionMetadata"},
+	5: {name: "ConsumerGroupMemberMetadata", hasMemberID: true},
+	6: {name: "ConsumerGroupTargetAssignmentMetadata", hasEpochOnly: true},
+	7: {name: "ConsumerGroupTargetAssignmentMember", hasMemberID: true},
+	8: {name: "ConsumerGroupCurrentMemberAssignment", hasMemberID: true},
+}
+
+func (d *consumerOffsetsDecoder) Encoding() MessageEncoding {
+	return messageEncodingConsumerOffsets
+}
+
+func (d *consumerOffsetsDecoder) Priority() int {
+	return 800
+}
+
+// Detect always returns false, the decoder is selected by the topic encodings instead.
+func (d *consumerOffsetsDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *consumerOffsetsDecoder) Decode(payload []byte, _ string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if recordType == proto.RecordKey {
+		key, _, err := decodeConsumerOffsetsKey(payload)
+		if err != nil {
+			return nil, err
+		}
+		return newTypedPayload(messageEncodingConsumerOffsets, key, payload)
+	}
+
+	// Without the key we can only guess the value's type
+	offsetCommit, err := decodeOffsetCommitValue(payload)
+	if err == nil {
+		return newTypedPayload(messageEncodingConsumerOffsets, offsetCommit, payload)
+	}
+	groupMetadata, groupErr := decodeGroupMetadataValue(payload)
+	if groupErr != nil {
+		return nil, fmt.Errorf("value is neither an offset commit (%v) nor group metadata (%v)", err, groupErr)
+	}
+	return newTypedPayload(messageEncodingConsumerOffsets, groupMetadata, payload)
+}
+
+// deserializeConsumerOffsetsRecord decodes a record of the __consumer_offsets topic. An error is returned if the key
+// can't be decoded. Values that can't be decoded are returned as binary along with the decoding error.
+func deserializeConsumerOffsetsRecord(record *kgo.Record) (*deserializedRecord, error) {
+	key, keyVersion, err := decodeConsumerOffsetsKey(record.Key)
+	if err != nil {
+		return nil, err
+	}
+	deserializedKey, err := newTypedPayload(messageEncodingConsumerOffsets, key, record.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	var deserializedVal *DeserializedPayload
+	if len(record.Value) == 0 {
+		// Tombstone
+		deserializedVal = emptyPayload(record.Value)
+	} else {
+		var value interface{}
+		switch {
+		case keyVersion <= 1:
+			value, err = decodeOffsetCommitValue(record.Value)
+		case keyVersion == 2:
+			value, err = decodeGroupMetadataValue(record.Value)
+		case consumerGroupRecordTypes[keyVersion].hasEpochOnly:
+			value, err = decodeConsumerGroupEpochValue(record.Value)
+		default:
+			// The values of the other record types of the new consumer group protocol are not decoded (yet)
+			deserializedVal = binaryPayload(record.Value)
+		}
+		if err == nil && deserializedVal == nil {
+			deserializedVal, err = newTypedPayload(messageEncodingConsumerOffsets, value, record.Value)
+		}
+		if err != nil {
+			deserializedVal = binaryPayload(record.Value)
+			deserializedVal.DecodeError = fmt.Sprintf("failed to decode payload as %v: %v", messageEncodingConsumerOffsets, err)
+		}
+	}
+
+	return &deserializedRecord{
+		Key:     deserializedKey,
+		Value:   deserializedVal,
+		Headers: make(map[string]*DeserializedPayload),
+	}, nil
+}
+
+// decodeConsumerOffsetsKey decodes the key and returns it along with its version, which is the type of the record.
+func decodeConsumerOffsetsKey(payload []byte) (interface{}, int16, error) {
+	if len(payload) < 2 {
+		return nil, 0, fmt.Errorf("consumer offsets key is supposed to be at least 2 bytes long")
+	}
+
+	r := &kbin.Reader{Src: payload}
+	version := r.Int16()
+
+	var key interface{}
+	switch version {
+	case 0, 1:
+		key = OffsetCommitKey{
+			Version:   version,
+			Group:     r.String(),
+			Topic:     r.String(),
+			Partition: r.Int32(),
+		}
+	case 2:
+		key = GroupMetadataKey{
+			Version: version,
+			Group:   r.String(),
+		}
+	default:
+		recordType, exists := consumerGroupRecordTypes[version]
+		if !exists {
+			return nil, version, fmt.Errorf("unknown consumer offsets key version '%d'", version)
+		}
+		groupKey := ConsumerGroupRecordKey{
+			Version: version,
+			Type:    recordType.name,
+			Group:   r.String(),
+		}
+		if recordType.hasMemberID {
+			groupKey.MemberID = r.String()
+		}
+		key = groupKey
+	}
+
+	if err := completeKafkaProtocolReader(r); err != nil {
+		return nil, version, fmt.Errorf("failed to decode consumer offsets key: %w", err)
+	}
+	return key, version, nil
+}
+
+// decodeOffsetCommitValue decodes all versions of the offset commit value. Version 4 is a flexible version.
+func decodeOffsetCommitValue(payload []byte) (OffsetCommitValue, error) {
+	r := &kbin.Reader{Src: payload}
+	value := OffsetCommitValue{Version: r.Int16()}
+	if value.Version < 0 || value.Version > 4 {
+		return OffsetCommitValue{}, fmt.Errorf("unknown offset commit value version '%d'", value.Version)
+	}
+	isFlexible := value.Version >= 4
+
+	value.Offset = r.Int64()
+	if value.Version >= 3 {
+		leaderEpoch := r.Int32()
+		value.LeaderEpoch = &leaderEpoch
+	}
+	value.Metadata = readKafkaString(r, isFlexible)
+	value.CommitTimestamp = r.Int64()
+	if value.Version == 1 {
+		expireTimestamp := r.Int64()
+		value.ExpireTimestamp = &expireTimestamp
+	}
+	if isFlexible {
+		skipKafkaTaggedFields(r)
+	}
+
+	if err := completeKafkaProtocolReader(r); err != nil {
+		return OffsetCommitValue{}, fmt.Errorf("failed to decode offset commit value: %w", err)
+	}
+	return value, nil
+}
+
+// decodeGroupMetadataValue decodes all versions of the group metadata value. Version 4 is a flexible version.
+func decodeGroupMetadataValue(payload []byte) (GroupMetadataValue, error) {
+	r := &kbin.Reader{Src: payload}
+	value := GroupMetadataValue{Version: r.Int16()}
+	if value.Version < 0 || value.Version > 4 {
+		return GroupMetadataValue{}, fmt.Errorf("unknown group metadata value version '%d'", value.Version)
+	}
+	isFlexible := value.Version >= 4
+
+	value.ProtocolType = readKafkaString(r, isFlexible)
+	value.Generation = r.Int32()
+	value.Protocol = readKafkaNullableString(r, isFlexible)
+	value.Leader = readKafkaNullableString(r, isFlexible)
+	if value.Version >= 2 {
+		currentStateTimestamp := r.Int64()
+		value.CurrentStateTimestamp = &currentStateTimestamp
+	}
+
+	membersLen := readKafkaArrayLen(r, isFlexible)
+	value.Members = make([]GroupMetadataMember, 0)
+	for i := int32(0); i < membersLen && r.Ok(); i++ {
+		member := GroupMetadataMember{MemberID: readKafkaString(r, isFlexible)}
+		if value.Version >= 3 {
+			member.GroupInstanceID = readKafkaNullableString(r, isFlexible)
+		}
+		member.ClientID = readKafkaString(r, isFlexible)
+		member.ClientHost = readKafkaString(r, isFlexible)
+		if value.Version >= 1 {
+			rebalanceTimeout := r.Int32()
+			member.RebalanceTimeoutMs = &rebalanceTimeout
+		}
+		member.SessionTimeoutMs = r.Int32()
+		subscription := readKafkaBytes(r, isFlexible)
+		assignment := readKafkaBytes(r, isFlexible)
+		if isFlexible {
+			skipKafkaTaggedFields(r)
+		}
+
+		member.Subscription = subscription
+		member.Assignment = assignment
+		if value.ProtocolType == "consumer" {
+			member.Subscription = decodeConsumerProtocolSubscription(subscription)
+			member.Assignment = decodeConsumerProtocolAssignment(assignment)
+		}
+		value.Members = append(value.Members, member)
+	}
+	if isFlexible {
+		skipKafkaTaggedFields(r)
+	}
+
+	if err := completeKafkaProtocolReader(r); err != nil {
+		return GroupMetadataValue{}, fmt.Errorf("failed to decode group metadata value: %w", err)
+	}
+	return value, nil
+}
+
+// decodeConsumerProtocolSubscription decodes the member metadata of the consumer protocol. The bytes are returned as
+// they are if they can't be decoded, because clients may use custom subscriptions.
+func decodeConsumerProtocolSubscription(payload []byte) interface{} {
+	r := &kbin.Reader{Src: payload}
+	subscription := ConsumerProtocolSubscription{Version: r.Int16()}
+
+	topicsLen := r.ArrayLen()
+	subscription.Topics = make([]string, 0)
+	for i := int32(0); i < topicsLen && r.Ok(); i++ {
+		subscription.Topics = append(subscription.Topics, r.String())
+	}
+	subscription.UserData = r.NullableBytes()
+	if subscription.Version >= 1 {
+		subscription.OwnedPartitions = readConsumerProtocolTopicPartitions(r)
+	}
+	if subscription.Version >= 2 {
+		generationID := r.Int32()
+		subscription.GenerationID = &generationID
+	}
+	if subscription.Version >= 3 {
+		subscription.RackID = r.NullableString()
+	}
+
+	// Newer versions may append fields, which is why remaining bytes are fine
+	if r.Complete() != nil {
+		return payload
+	}
+	return subscription
+}
+
+// decodeConsumerProtocolAssignment decodes the assigned partitions of the consumer protocol. The bytes are returned
+// as they are if they can't be decoded.
+func decodeConsumerProtocolAssignment(payload []byte) interface{} {
+	memberAssignment := kmsg.GroupMemberAssignment{}
+	err := memberAssignment.ReadFrom(payload)
+	if err != nil {
+		return payload
+	}
+
+	assignment := ConsumerProtocolAssignment{
+		Version: memberAssignment.Version,
+		Topics:  make([]ConsumerProtocolTopicPartitions, len(memberAssignment.Topics)),
+	}
+	// Empty and null user data can't be distinguished when reading the assignment, both are rendered as null
+	if len(memberAssignment.UserData) > 0 {
+		assignment.UserData = memberAssignment.UserData
+	}
+	for i, topic := range memberAssignment.Topics {
+		assignment.Topics[i] = ConsumerProtocolTopicPartitions{Topic: topic.Topic, Partitions: topic.Partitions}
+	}
+	return assignment
+}
+
+func readConsumerProtocolTopicPartitions(r *kbin.Reader) []ConsumerProtocolTopicPartitions {
+	topicsLen := r.ArrayLen()
+	res := make([]ConsumerProtocolTopicPartitions, 0)
+	for i := int32(0); i < topicsLen && r.Ok(); i++ {
+		topic := ConsumerProtocolTopicPartitions{Topic: r.String(), Partitions: make([]int32, 0)}
+		partitionsLen := r.ArrayLen()
+		for j := int32(0); j < partitionsLen && r.Ok(); j++ {
+			topic.Partitions = append(topic.Partitions, r.Int32())
+		}
+		res = append(res, topic)
+	}
+	return res
+}
+
+// decodeConsumerGroupEpochValue decodes the values of the records of the new consumer group protocol, which consist
+// of an epoch only. These values use flexible versions from the start.
+func decodeConsumerGroupEpochValue(payload []byte) (ConsumerGroupEpochValue, error) {
+	r := &kbin.Reader{Src: payload}
+	value := ConsumerGroupEpochValue{Version: r.Int16(), Epoch: r.Int32()}
+	skipKafkaTaggedFields(r)
+
+	if err := completeKafkaProtocolReader(r); err != nil {
+		return ConsumerGroupEpochValue{}, fmt.Errorf("failed to decode consumer group epoch value: %w", err)
+	}
+	return value, nil
+}
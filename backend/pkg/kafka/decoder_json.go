@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+)
+
+// jsonDecoder decodes plain JSON payloads as well as JSON payloads in the schema registry wire format.
+type jsonDecoder struct {
+	schemaSvc *schema.Service
+}
+
+func (d *jsonDecoder) Encoding() MessageEncoding {
+	return messageEncodingJSON
+}
+
+func (d *jsonDecoder) Priority() int {
+	return 100
+}
+
+func (d *jsonDecoder) Detect(payload []byte) bool {
+	if _, ok := schemaRegistrySchemaID(d.schemaSvc, payload, schema.SchemaTypeJSON); ok {
+		return true
+	}
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{')
+}
+
+func (d *jsonDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if schemaID, ok := schemaRegistrySchemaID(d.schemaSvc, payload, schema.SchemaTypeJSON); ok {
+		return deserializeJSONSchemaPayload(d.schemaSvc, payload, schemaID)
+	}
+
+	var obj interface{}
+	err := json.Unmarshal(payload, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeserializedPayload{Payload: NormalizedPayload{
+		Payload:            bytes.TrimSpace(payload),
+		RecognizedEncoding: messageEncodingJSON,
+	}, Object: obj, RecognizedEncoding: messageEncodingJSON, Size: len(payload)}, nil
+}
+
+// isSchemaRegistryWireFormat returns whether the payload starts with the magic byte and a schema id.
+// Reference: https://docs.confluent.io/current/schema-registry/serdes-develop/index.html#wire-format
+func isSchemaRegistryWireFormat(payload []byte) bool {
+	return len(payload) > 5 && payload[0] == byte(0)
+}
+
+// schemaRegistrySchemaID returns the schema id of a payload in the schema registry wire format, if the referenced
+// schema is of the given schema type. This way each payload is only decoded by the decoder of its schema type.
+// Schemas are cached by the schema service, hence the lookup is cheap enough to be done during detection.
+func schemaRegistrySchemaID(schemaSvc *schema.Service, payload []byte, schemaType string) (uint32, bool) {
+	if schemaSvc == nil || !isSchemaRegistryWireFormat(payload) {
+		return 0, false
+	}
+
+	schemaID := binary.BigEndian.Uint32(payload[1:5])
+	schemaRes, err := schemaSvc.GetSchemaByID(schemaID)
+	if err != nil || schemaRes.SchemaType != schemaType {
+		return 0, false
+	}
+
+	return schemaID, true
+}
+
+// deserializeJSONSchemaPayload decodes a JSON payload in the schema registry wire format and validates it against its
+// schema. Payloads which don't match the schema are returned nonetheless, along with the validation error.
+func deserializeJSONSchemaPayload(schemaSvc *schema.Service, payload []byte, schemaID uint32) (*DeserializedPayload, error) {
+	jsonSchema, err := schemaSvc.GetJSONSchemaByID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonPayload := bytes.TrimSpace(payload[5:])
+	var obj interface{}
+	err = json.Unmarshal(jsonPayload, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	validationErr := ""
+	err = jsonSchema.Validate(obj)
+	if err != nil {
+		validationErr = err.Error()
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            jsonPayload,
+			RecognizedEncoding: messageEncodingJSON,
+		},
+		Object:                obj,
+		RecognizedEncoding:    messageEncodingJSON,
+		SchemaID:              schemaID,
+		SchemaValidationError: validationErr,
+		Size:                  len(payload),
+	}, nil
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgPackDecoder decodes MessagePack payloads. Only payloads whose top level value is a map are detected, because
+// most other MessagePack values can't be told apart from arbitrary binary data.
+type msgPackDecoder struct{}
+
+func (d *msgPackDecoder) Encoding() MessageEncoding {
+	return messageEncodingMsgPack
+}
+
+func (d *msgPackDecoder) Priority() int {
+	return 530
+}
+
+func (d *msgPackDecoder) Detect(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+	first := payload[0]
+	isFixMap := first >= 0x80 && first <= 0x8f
+	isMap16Or32 := first == 0xde || first == 0xdf
+	return isFixMap || isMap16Or32
+}
+
+func (d *msgPackDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	r := bytes.NewReader(payload)
+	dec := msgpack.NewDecoder(r)
+	dec.UseLooseInterfaceDecoding(true)
+	dec.SetMapDecoder(decodeMsgPackMap)
+
+	obj, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode msgpack payload: %w", err)
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("failed to decode msgpack payload: %d bytes left after decoding", r.Len())
+	}
+
+	return newJSONPayload(messageEncodingMsgPack, normalizeDecodedValue(obj, nil), payload)
+}
+
+// decodeMsgPackMap decodes a map like msgpack's DecodeUntypedMap, but rejects keys which are maps or arrays. These are
+// valid MessagePack, but can neither be used as Go map keys (DecodeUntypedMap panics) nor be represented in JSON.
+// Binary keys are converted to strings.
+func decodeMsgPackMap(dec *msgpack.Decoder) (interface{}, error) {
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	if n == -1 {
+		return nil, nil
+	}
+
+	m := make(map[interface{}]interface{})
+	for i := 0; i < n; i++ {
+		key, err := dec.DecodeInterfaceLoose()
+		if err != nil {
+			return nil, err
+		}
+		if binaryKey, isBinary := key.([]byte); isBinary {
+			key = string(binaryKey)
+		}
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("map keys of type %T are not supported", key)
+		}
+
+		value, err := dec.DecodeInterfaceLoose()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+
+	return m, nil
+}
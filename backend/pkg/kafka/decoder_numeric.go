@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// The numeric decoders decode fixed size, big-endian primitives, which are a common encoding for record keys. They are
+// never detected automatically, because any payload with a matching size would qualify.
+
+// int32BEDecoder decodes a big-endian int32.
+type int32BEDecoder struct{}
+
+func (d *int32BEDecoder) Encoding() MessageEncoding {
+	return messageEncodingInt32BE
+}
+
+func (d *int32BEDecoder) Priority() int {
+	return 800
+}
+
+func (d *int32BEDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *int32BEDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if err := checkPayloadSize(payload, 4); err != nil {
+		return nil, err
+	}
+
+	number := int32(binary.BigEndian.Uint32(payload))
+	return numericPayload(payload, messageEncodingInt32BE, number, []byte(strconv.FormatInt(int64(number), 10))), nil
+}
+
+// int64BEDecoder decodes a big-endian int64.
+type int64BEDecoder struct{}
+
+func (d *int64BEDecoder) Encoding() MessageEncoding {
+	return messageEncodingInt64BE
+}
+
+func (d *int64BEDecoder) Priority() int {
+	return 800
+}
+
+func (d *int64BEDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *int64BEDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if err := checkPayloadSize(payload, 8); err != nil {
+		return nil, err
+	}
+
+	number := int64(binary.BigEndian.Uint64(payload))
+	return numericPayload(payload, messageEncodingInt64BE, number, []byte(strconv.FormatInt(number, 10))), nil
+}
+
+// float32BEDecoder decodes a big-endian IEEE 754 single precision float.
+type float32BEDecoder struct{}
+
+func (d *float32BEDecoder) Encoding() MessageEncoding {
+	return messageEncodingFloat32BE
+}
+
+func (d *float32BEDecoder) Priority() int {
+	return 800
+}
+
+func (d *float32BEDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *float32BEDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if err := checkPayloadSize(payload, 4); err != nil {
+		return nil, err
+	}
+
+	number := math.Float32frombits(binary.BigEndian.Uint32(payload))
+	return numericPayload(payload, messageEncodingFloat32BE, number, formatFloat(float64(number), 32)), nil
+}
+
+// float64BEDecoder decodes a big-endian IEEE 754 double precision float.
+type float64BEDecoder struct{}
+
+func (d *float64BEDecoder) Encoding() MessageEncoding {
+	return messageEncodingFloat64BE
+}
+
+func (d *float64BEDecoder) Priority() int {
+	return 800
+}
+
+func (d *float64BEDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *float64BEDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if err := checkPayloadSize(payload, 8); err != nil {
+		return nil, err
+	}
+
+	number := math.Float64frombits(binary.BigEndian.Uint64(payload))
+	return numericPayload(payload, messageEncodingFloat64BE, number, formatFloat(number, 64)), nil
+}
+
+// uuidDecoder decodes a UUID in its 16 byte binary form into its canonical string representation.
+type uuidDecoder struct{}
+
+func (d *uuidDecoder) Encoding() MessageEncoding {
+	return messageEncodingUUID
+}
+
+func (d *uuidDecoder) Priority() int {
+	return 800
+}
+
+func (d *uuidDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *uuidDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if err := checkPayloadSize(payload, 16); err != nil {
+		return nil, err
+	}
+
+	h := hex.EncodeToString(payload)
+	uuid := h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+	jsonBytes, _ := json.Marshal(uuid)
+	return numericPayload(payload, messageEncodingUUID, uuid, jsonBytes), nil
+}
+
+func checkPayloadSize(payload []byte, size int) error {
+	if len(payload) != size {
+		return fmt.Errorf("payload must be %d bytes long, but is %d bytes long", size, len(payload))
+	}
+	return nil
+}
+
+// formatFloat returns the JSON representation of the float. NaN and infinity can't be represented as JSON number,
+// hence they are returned as JSON string.
+func formatFloat(number float64, bitSize int) []byte {
+	formatted := strconv.FormatFloat(number, 'g', -1, bitSize)
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return []byte(strconv.Quote(formatted))
+	}
+	return []byte(formatted)
+}
+
+func numericPayload(payload []byte, encoding MessageEncoding, object interface{}, jsonBytes []byte) *DeserializedPayload {
+	return &DeserializedPayload{Payload: NormalizedPayload{
+		Payload:            jsonBytes,
+		RecognizedEncoding: encoding,
+	}, Object: object, RecognizedEncoding: encoding, Size: len(payload)}
+}
@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// protobufDecoder decodes Protobuf payloads, which are either in the schema registry wire format or encoded with the
+// proto type that is mapped to the topic.
+type protobufDecoder struct {
+	schemaSvc *schema.Service
+	protoSvc  *proto.Service
+}
+
+func (d *protobufDecoder) Encoding() MessageEncoding {
+	return messageEncodingProtobuf
+}
+
+func (d *protobufDecoder) Priority() int {
+	return 400
+}
+
+func (d *protobufDecoder) Detect(payload []byte) bool {
+	if _, ok := schemaRegistrySchemaID(d.schemaSvc, payload, schema.SchemaTypeProtobuf); ok {
+		return true
+	}
+	return d.protoSvc != nil
+}
+
+func (d *protobufDecoder) Decode(payload []byte, topicName string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if schemaID, ok := schemaRegistrySchemaID(d.schemaSvc, payload, schema.SchemaTypeProtobuf); ok {
+		deserialized, err := deserializeProtobufSchemaPayload(d.schemaSvc, payload, schemaID)
+		if err == nil || d.protoSvc == nil {
+			return deserialized, err
+		}
+	}
+
+	if d.protoSvc == nil {
+		return nil, fmt.Errorf("payload is not a protobuf schema registry payload and no protobuf mappings are configured")
+	}
+	return d.deserializeLocalProtobufPayload(payload, topicName, recordType)
+}
+
+// deserializeLocalProtobufPayload decodes a Protobuf payload with the proto type that is mapped to the topic.
+func (d *protobufDecoder) deserializeLocalProtobufPayload(payload []byte, topicName string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	jsonBytes, err := d.protoSvc.UnmarshalPayload(payload, topicName, recordType)
+	if err != nil {
+		return nil, err
+	}
+	var native interface{}
+	err = json.Unmarshal(jsonBytes, &native)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingProtobuf,
+		},
+		Object:             native,
+		RecognizedEncoding: messageEncodingProtobuf,
+		Size:               len(payload),
+	}, nil
+}
+
+// deserializeProtobufSchemaPayload decodes a Protobuf payload in the schema registry wire format. Other than Avro the
+// schema id is followed by the message indexes, which determine the message type within the schema.
+func deserializeProtobufSchemaPayload(schemaSvc *schema.Service, payload []byte, schemaID uint32) (*DeserializedPayload, error) {
+	messageIndexes, n, err := schema.ReadProtobufMessageIndexes(payload[5:])
+	if err != nil {
+		return nil, err
+	}
+	messageDescriptor, err := schemaSvc.GetProtoMessageDescriptor(schemaID, messageIndexes)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamic.NewMessage(messageDescriptor)
+	err = msg.Unmarshal(payload[5+n:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload into protobuf message: %w", err)
+	}
+	jsonBytes, err := msg.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message to JSON: %w", err)
+	}
+	var native interface{}
+	err = json.Unmarshal(jsonBytes, &native)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingProtobuf,
+		},
+		Object:             native,
+		RecognizedEncoding: messageEncodingProtobuf,
+		SchemaID:           schemaID,
+		Size:               len(payload),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// SchemasKey is the key of a record in the schema registry's _schemas topic. Its type determines the value's
+// structure, e.g. a SCHEMA key is followed by the registered schema and a CONFIG key by the compatibility level.
+type SchemasKey struct {
+	// KeyType is one of SCHEMA, CONFIG, MODE, DELETE_SUBJECT, CLEAR_SUBJECT or NOOP
+	KeyType string `json:"keytype"`
+	Subject string `json:"subject,omitempty"`
+	Version *int   `json:"version,omitempty"`
+	Magic   int    `json:"magic"`
+}
+
+// schemasDecoder decodes the records of the schema registry's _schemas topic, in which all schemas and configs are
+// stored. Keys and values are JSON objects.
+type schemasDecoder struct{}
+
+func (d *schemasDecoder) Encoding() MessageEncoding {
+	return messageEncodingSchemas
+}
+
+func (d *schemasDecoder) Priority() int {
+	return 800
+}
+
+// Detect always returns false, the decoder is selected by the topic encodings instead.
+func (d *schemasDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *schemasDecoder) Decode(payload []byte, _ string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if recordType != proto.RecordKey {
+		var obj map[string]interface{}
+		err := json.Unmarshal(payload, &obj)
+		if err != nil {
+			return nil, err
+		}
+		return newTypedPayload(messageEncodingSchemas, obj, payload)
+	}
+
+	var key SchemasKey
+	err := json.Unmarshal(payload, &key)
+	if err != nil {
+		return nil, err
+	}
+	if key.KeyType == "" {
+		return nil, fmt.Errorf("schemas key must have a keytype")
+	}
+	return newTypedPayload(messageEncodingSchemas, key, payload)
+}
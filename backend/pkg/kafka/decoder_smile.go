@@ -0,0 +1,440 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// smileHeader are the first bytes of each Smile payload. The fourth header byte contains the version and flags.
+// Specification: https://github.com/FasterXML/smile-format-specification
+var smileHeader = []byte(":)\n")
+
+const (
+	smileFlagSharedNames  = 0x01
+	smileFlagSharedValues = 0x02
+
+	// smileMaxSharedReferences is the size of the shared name and value tables. Once a table is full it's cleared.
+	smileMaxSharedReferences = 1024
+)
+
+// smileDecoder decodes Smile payloads, a binary JSON format which is used by Jackson.
+type smileDecoder struct{}
+
+func (d *smileDecoder) Encoding() MessageEncoding {
+	return messageEncodingSmile
+}
+
+func (d *smileDecoder) Priority() int {
+	return 500
+}
+
+func (d *smileDecoder) Detect(payload []byte) bool {
+	return len(payload) > len(smileHeader) && bytes.HasPrefix(payload, smileHeader)
+}
+
+func (d *smileDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if !d.Detect(payload) {
+		return nil, fmt.Errorf("payload does not start with the smile header")
+	}
+	flags := payload[3]
+	if version := flags >> 4; version != 0 {
+		return nil, fmt.Errorf("unsupported smile version %d", version)
+	}
+
+	r := &smileReader{
+		buf:          payload,
+		pos:          4,
+		sharedNames:  flags&smileFlagSharedNames != 0,
+		sharedValues: flags&smileFlagSharedValues != 0,
+		seenNames:    make([]string, 0),
+		seenValues:   make([]string, 0),
+	}
+	obj, err := r.readValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode smile payload: %w", err)
+	}
+	// The root value may be followed by the optional end marker
+	if r.pos < len(payload) && payload[r.pos] == 0xFF {
+		r.pos++
+	}
+	if r.pos != len(payload) {
+		return nil, fmt.Errorf("failed to decode smile payload: %d bytes left after decoding", len(payload)-r.pos)
+	}
+
+	return newJSONPayload(messageEncodingSmile, obj, payload)
+}
+
+// smileReader reads Smile values from a buffer, while keeping track of the shared (back referenced) names and values.
+type smileReader struct {
+	buf []byte
+	pos int
+
+	sharedNames  bool
+	sharedValues bool
+	seenNames    []string
+	seenValues   []string
+}
+
+// errSmileEndMarker is returned by readValue if the end of an array has been reached.
+var errSmileEndMarker = fmt.Errorf("unexpected end of array marker")
+
+func (r *smileReader) read(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.buf) {
+		return nil, fmt.Errorf("unexpected end of payload at offset %d", r.pos)
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *smileReader) readByte() (byte, error) {
+	b, err := r.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readVInt reads a variable length unsigned int. All bytes but the last contain 7 bits, the last byte has its high bit
+// set and contains 6 bits.
+func (r *smileReader) readVInt() (uint64, error) {
+	var value uint64
+	for i := 0; i < 10; i++ {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		if b&0x80 != 0 {
+			return value<<6 | uint64(b&0x3F), nil
+		}
+		value = value<<7 | uint64(b)
+	}
+	return 0, fmt.Errorf("vint at offset %d is too long", r.pos)
+}
+
+func (r *smileReader) readZigZagVInt() (int64, error) {
+	v, err := r.readVInt()
+	if err != nil {
+		return 0, err
+	}
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+// readFixed7Bit reads n bytes which contain 7 bits each, as used for floating point numbers.
+func (r *smileReader) readFixed7Bit(n int) (uint64, error) {
+	b, err := r.read(n)
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	for _, c := range b {
+		if c&0x80 != 0 {
+			return 0, fmt.Errorf("invalid 7-bit encoded byte at offset %d", r.pos)
+		}
+		value = value<<7 | uint64(c)
+	}
+	return value, nil
+}
+
+// read7BitBinary reads binary data of the given length, that has been encoded with 7 bits per byte. Each 7 bytes are
+// encoded as 8 bytes, the remaining n bytes are encoded as n+1 bytes where the last byte contains the last n bits.
+func (r *smileReader) read7BitBinary(length uint64) ([]byte, error) {
+	if length > uint64(len(r.buf)) {
+		return nil, fmt.Errorf("binary length %d exceeds payload size", length)
+	}
+	res := make([]byte, 0, length)
+	for remaining := int(length); remaining > 0; {
+		chunk := remaining
+		if chunk > 7 {
+			chunk = 7
+		}
+		encoded, err := r.read(chunk + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		var value uint64
+		for _, c := range encoded[:chunk] {
+			value = value<<7 | uint64(c&0x7F)
+		}
+		// A full chunk has 7 bits in its last byte as well, a partial chunk has as many bits as it has bytes
+		value = value<<uint(chunk) | uint64(encoded[chunk]&(1<<uint(chunk)-1))
+		for i := chunk - 1; i >= 0; i-- {
+			res = append(res, byte(value>>(8*uint(i))))
+		}
+		remaining -= chunk
+	}
+	return res, nil
+}
+
+// readUntilEndMarker reads a variable length string, which is terminated by the end of string marker.
+func (r *smileReader) readUntilEndMarker() (string, error) {
+	end := bytes.IndexByte(r.buf[r.pos:], 0xFC)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at offset %d", r.pos)
+	}
+	s := r.buf[r.pos : r.pos+end]
+	r.pos += end + 1
+	return validUTF8(s)
+}
+
+func (r *smileReader) readString(length int) (string, error) {
+	b, err := r.read(length)
+	if err != nil {
+		return "", err
+	}
+	return validUTF8(b)
+}
+
+func validUTF8(b []byte) (string, error) {
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("string is not valid UTF-8")
+	}
+	return string(b), nil
+}
+
+func addSharedReference(seen []string, s string) []string {
+	if len(seen) >= smileMaxSharedReferences {
+		seen = seen[:0]
+	}
+	return append(seen, s)
+}
+
+func sharedReference(seen []string, index int) (string, error) {
+	if index >= len(seen) {
+		return "", fmt.Errorf("invalid shared reference %d, only %d references are known", index, len(seen))
+	}
+	return seen[index], nil
+}
+
+func (r *smileReader) readValue() (interface{}, error) {
+	token, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case token >= 0x01 && token <= 0x1F:
+		return sharedReference(r.seenValues, int(token)-1)
+	case token == 0x20:
+		return "", nil
+	case token == 0x21:
+		return nil, nil
+	case token == 0x22:
+		return false, nil
+	case token == 0x23:
+		return true, nil
+	case token == 0x24, token == 0x25:
+		return r.readZigZagVInt()
+	case token == 0x26:
+		length, err := r.readVInt()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.read7BitBinary(length)
+		if err != nil {
+			return nil, err
+		}
+		return twosComplementToBigInt(b).String(), nil
+	case token == 0x28:
+		bits, err := r.readFixed7Bit(5)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(uint32(bits)), nil
+	case token == 0x29:
+		bits, err := r.readFixed7Bit(10)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(bits), nil
+	case token == 0x2A:
+		scale, err := r.readZigZagVInt()
+		if err != nil {
+			return nil, err
+		}
+		if scale > math.MaxInt32 || scale < math.MinInt32 {
+			return nil, fmt.Errorf("big decimal scale %d exceeds int32 range", scale)
+		}
+		length, err := r.readVInt()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.read7BitBinary(length)
+		if err != nil {
+			return nil, err
+		}
+		return formatBigDecimal(twosComplementToBigInt(b), scale), nil
+	case token >= 0x40 && token <= 0xBF:
+		// Tiny and short ASCII / Unicode strings, which may be referenced later on
+		var length int
+		switch {
+		case token < 0x60:
+			length = int(token&0x1F) + 1
+		case token < 0x80:
+			length = int(token&0x1F) + 33
+		case token < 0xA0:
+			length = int(token&0x1F) + 2
+		default:
+			length = int(token&0x1F) + 34
+		}
+		s, err := r.readString(length)
+		if err != nil {
+			return nil, err
+		}
+		if r.sharedValues {
+			r.seenValues = addSharedReference(r.seenValues, s)
+		}
+		return s, nil
+	case token >= 0xC0 && token <= 0xDF:
+		v := int64(token & 0x1F)
+		return (v >> 1) ^ -(v & 1), nil
+	case token == 0xE0, token == 0xE4:
+		return r.readUntilEndMarker()
+	case token == 0xE8:
+		length, err := r.readVInt()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.read7BitBinary(length)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	case token >= 0xEC && token <= 0xEF:
+		next, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return sharedReference(r.seenValues, int(token&0x03)<<8|int(next))
+	case token == 0xF8:
+		arr := make([]interface{}, 0)
+		for {
+			value, err := r.readValue()
+			if err == errSmileEndMarker {
+				return arr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+	case token == 0xF9:
+		return nil, errSmileEndMarker
+	case token == 0xFA:
+		return r.readObject()
+	case token == 0xFD:
+		length, err := r.readVInt()
+		if err != nil {
+			return nil, err
+		}
+		if length > uint64(len(r.buf)) {
+			return nil, fmt.Errorf("binary length %d exceeds payload size", length)
+		}
+		b, err := r.read(int(length))
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	default:
+		return nil, fmt.Errorf("unexpected token 0x%02x at offset %d", token, r.pos-1)
+	}
+}
+
+func (r *smileReader) readObject() (interface{}, error) {
+	obj := make(map[string]interface{})
+	for {
+		token, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		var name string
+		switch {
+		case token == 0xFB:
+			return obj, nil
+		case token == 0x20:
+			name = ""
+		case token >= 0x30 && token <= 0x33:
+			next, err := r.readByte()
+			if err != nil {
+				return nil, err
+			}
+			name, err = sharedReference(r.seenNames, int(token&0x03)<<8|int(next))
+			if err != nil {
+				return nil, err
+			}
+		case token == 0x34:
+			name, err = r.readUntilEndMarker()
+			if err != nil {
+				return nil, err
+			}
+		case token >= 0x40 && token <= 0x7F:
+			name, err = sharedReference(r.seenNames, int(token&0x3F))
+			if err != nil {
+				return nil, err
+			}
+		case token >= 0x80 && token <= 0xF7:
+			length := int(token&0x3F) + 1
+			if token >= 0xC0 {
+				length = int(token&0x3F) + 2
+			}
+			name, err = r.readString(length)
+			if err != nil {
+				return nil, err
+			}
+			if r.sharedNames {
+				r.seenNames = addSharedReference(r.seenNames, name)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected key token 0x%02x at offset %d", token, r.pos-1)
+		}
+
+		value, err := r.readValue()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read value of '%v': %w", name, err)
+		}
+		obj[name] = value
+	}
+}
+
+// twosComplementToBigInt converts a big-endian two's complement number into a big int.
+func twosComplementToBigInt(b []byte) *big.Int {
+	value := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+	}
+	return value
+}
+
+// smileMaxPlainScale is the max (absolute) scale of big decimals which are formatted without exponent.
+const smileMaxPlainScale = 1000
+
+// formatBigDecimal formats the decimal unscaled * 10^-scale as string.
+func formatBigDecimal(unscaled *big.Int, scale int64) string {
+	if scale < -smileMaxPlainScale || scale > smileMaxPlainScale {
+		// Avoid rendering huge amounts of zeros
+		return fmt.Sprintf("%vE%d", unscaled, -scale)
+	}
+	if scale <= 0 {
+		return new(big.Int).Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(-scale), nil)).String()
+	}
+
+	sign := ""
+	digits := unscaled.String()
+	if unscaled.Sign() < 0 {
+		sign = "-"
+		digits = digits[1:]
+	}
+	for int64(len(digits)) <= scale {
+		digits = "0" + digits
+	}
+	pointPosition := int64(len(digits)) - scale
+	return sign + digits[:pointPosition] + "." + digits[pointPosition:]
+}
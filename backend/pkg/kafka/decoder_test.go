@@ -0,0 +1,339 @@
+package kafka
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var updateGoldenFiles = flag.Bool("update", false, "update the decoder golden files in testdata/decoders")
+
+// decoderGoldenFile is the content of a golden file. Each golden file belongs to a payload in testdata/decoders, where
+// the payload's directory is the encoding that is used for decoding it. Payloads whose filename starts with "key" are
+// decoded as record key, all others as record value.
+type decoderGoldenFile struct {
+	Encoding MessageEncoding `json:"encoding"`
+	Payload  json.RawMessage `json:"payload"`
+	Object   interface{}     `json:"object"`
+}
+
+func TestDecoders_GoldenFiles(t *testing.T) {
+	payloadPaths, err := filepath.Glob(filepath.Join("testdata", "decoders", "*", "*.bin"))
+	require.NoError(t, err)
+	require.NotEmpty(t, payloadPaths)
+
+	d := newDeserializer(nil, nil, nil, nil)
+	for _, payloadPath := range payloadPaths {
+		encoding := MessageEncoding(filepath.Base(filepath.Dir(payloadPath)))
+		goldenPath := strings.TrimSuffix(payloadPath, ".bin") + ".golden"
+
+		t.Run(string(encoding)+"/"+filepath.Base(payloadPath), func(t *testing.T) {
+			payload, err := ioutil.ReadFile(payloadPath)
+			require.NoError(t, err)
+
+			recordType := proto.RecordValue
+			if strings.HasPrefix(filepath.Base(payloadPath), "key") {
+				recordType = proto.RecordKey
+			}
+
+			res := d.deserializePayloadWithEncoding(payload, "test", recordType, encoding)
+			require.Empty(t, res.DecodeError)
+			require.Equal(t, encoding, res.RecognizedEncoding)
+			assert.Equal(t, len(payload), res.Size)
+
+			normalized, err := json.Marshal(&res.Payload)
+			require.NoError(t, err)
+			actual, err := json.MarshalIndent(decoderGoldenFile{
+				Encoding: res.RecognizedEncoding,
+				Payload:  normalized,
+				Object:   res.Object,
+			}, "", "  ")
+			require.NoError(t, err, "the decoded object must consist of JSON compatible types")
+
+			if *updateGoldenFiles {
+				require.NoError(t, ioutil.WriteFile(goldenPath, append(actual, '\n'), 0644))
+			}
+			expected, err := ioutil.ReadFile(goldenPath)
+			require.NoError(t, err, "golden file is missing, run the tests with -update to create it")
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestDecoders_BSONFixtures(t *testing.T) {
+	// The BSON payloads in testdata must be the documents as written by the mongo driver, so that the golden files
+	// don't just reflect how the decoder understands the format
+	objectID, err := primitive.ObjectIDFromHex("5f1e7e2b9d1c4a0001a1b2c3")
+	require.NoError(t, err)
+	balance, err := primitive.ParseDecimal128("12.34")
+	require.NoError(t, err)
+
+	tt := []struct {
+		filename string
+		document bson.D
+	}{
+		{"hello.bin", bson.D{{Key: "hello", Value: "world"}}},
+		{"awesome.bin", bson.D{{Key: "BSON", Value: bson.A{"awesome", 5.05, int32(1986)}}}},
+		{"types.bin", bson.D{
+			{Key: "_id", Value: objectID},
+			{Key: "createdAt", Value: primitive.NewDateTimeFromTime(time.Date(2020, 9, 13, 12, 26, 40, 123000000, time.UTC))},
+			{Key: "views", Value: int64(9007199254740993)},
+			{Key: "deletedAt", Value: nil},
+			{Key: "active", Value: true},
+			{Key: "avatar", Value: primitive.Binary{Subtype: 0x00, Data: []byte{0x01, 0x02, 0x03}}},
+			{Key: "balance", Value: balance},
+		}},
+	}
+
+	for _, table := range tt {
+		expected, err := bson.Marshal(table.document)
+		require.NoError(t, err)
+
+		payloadPath := filepath.Join("testdata", "decoders", string(messageEncodingBSON), table.filename)
+		if *updateGoldenFiles {
+			require.NoError(t, ioutil.WriteFile(payloadPath, expected, 0644))
+		}
+		payload, err := ioutil.ReadFile(payloadPath)
+		require.NoError(t, err)
+		assert.Equal(t, expected, payload, "payload %v differs from the document encoded by the mongo driver", payloadPath)
+	}
+}
+
+func TestDecoders_Detection(t *testing.T) {
+	d := newDeserializer(nil, nil, nil, nil)
+
+	// Payloads of these encodings must be detected without requesting the encoding
+	for _, encoding := range []MessageEncoding{messageEncodingMsgPack, messageEncodingCBOR, messageEncodingBSON, messageEncodingSmile} {
+		payloadPaths, err := filepath.Glob(filepath.Join("testdata", "decoders", string(encoding), "*.bin"))
+		require.NoError(t, err)
+		require.NotEmpty(t, payloadPaths)
+
+		for _, payloadPath := range payloadPaths {
+			payload, err := ioutil.ReadFile(payloadPath)
+			require.NoError(t, err)
+
+			res := d.deserializePayload(payload, "test", proto.RecordValue)
+			assert.Equal(t, encoding, res.RecognizedEncoding, "unexpected encoding detected for %v", payloadPath)
+		}
+	}
+
+	// Truncated payloads and payloads with trailing bytes must not be detected
+	for _, payload := range [][]byte{
+		{0x82, 0xa7, 'c', 'o'},
+		{0x81, 0xa1, 'a', 0x01, 0x02},
+		{0xa1, 0x61, 0x61},
+		{0x05, 0x00, 0x00, 0x00, 0x00, 0xff},
+		{':', ')', '\n', 0x00, 0xfa, 0x80},
+	} {
+		res := d.deserializePayload(payload, "test", proto.RecordValue)
+		assert.Equal(t, messageEncodingBinary, res.RecognizedEncoding, "expected payload %x to be binary", payload)
+	}
+
+	// Numeric encodings are never detected
+	res := d.deserializePayload([]byte{0x00, 0x00, 0x30, 0x39}, "test", proto.RecordValue)
+	assert.Equal(t, messageEncodingText, res.RecognizedEncoding)
+}
+
+func TestDecoders_NumericPayloadSize(t *testing.T) {
+	d := newDeserializer(nil, nil, nil, nil)
+	for _, encoding := range []MessageEncoding{
+		messageEncodingInt32BE, messageEncodingInt64BE, messageEncodingFloat32BE, messageEncodingFloat64BE, messageEncodingUUID,
+	} {
+		res := d.deserializePayloadWithEncoding([]byte{0x01, 0x02, 0x03}, "test", proto.RecordKey, encoding)
+		assert.Equal(t, messageEncodingBinary, res.RecognizedEncoding)
+		assert.Contains(t, res.DecodeError, "bytes long")
+	}
+}
+
+// panicDecoder is a decoder with a bug, which panics for all payloads.
+type panicDecoder struct{}
+
+func (d *panicDecoder) Encoding() MessageEncoding {
+	return "panic"
+}
+
+func (d *panicDecoder) Priority() int {
+	return 50
+}
+
+func (d *panicDecoder) Detect(_ []byte) bool {
+	return true
+}
+
+func (d *panicDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	return nil, fmt.Errorf("unreachable: %v", payload[len(payload)])
+}
+
+func TestDecoders_MalformedPayloads(t *testing.T) {
+	d := newDeserializer(nil, nil, nil, nil)
+
+	// MessagePack maps may use maps or arrays as keys, which can't be decoded into Go maps
+	for _, payload := range [][]byte{
+		{0x81, 0x80, 0x01},
+		{0x81, 0x91, 0x01, 0x01},
+		{0x82, 0xa1, 'a', 0x01, 0x81, 0x80, 0x01},
+	} {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "test", Value: payload}, recordEncodings{})
+		assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+
+		rec = d.DeserializeRecord(&kgo.Record{Topic: "test", Value: payload}, recordEncodings{Value: messageEncodingMsgPack})
+		assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+		assert.Contains(t, rec.Value.DecodeError, "map keys of type")
+	}
+
+	// Binary keys are valid map keys
+	res := d.deserializePayloadWithEncoding([]byte{0x81, 0xc4, 0x01, 'k', 0x01}, "test", proto.RecordValue, messageEncodingMsgPack)
+	assert.Empty(t, res.DecodeError)
+	assert.Equal(t, map[string]interface{}{"k": int64(1)}, res.Object)
+
+	// Panics of decoders are returned as decode errors
+	require.NoError(t, d.RegisterDecoder(&panicDecoder{}))
+	rec := d.DeserializeRecord(&kgo.Record{Topic: "test", Value: []byte(`{"id": 1}`)}, recordEncodings{})
+	assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding, "other decoders must be tried after a panic")
+	rec = d.DeserializeRecord(&kgo.Record{Topic: "test", Value: []byte(`{"id": 1}`)}, recordEncodings{Value: "panic"})
+	assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+	assert.Contains(t, rec.Value.DecodeError, "decoder panicked")
+}
+
+func TestDecoders_InternalTopics(t *testing.T) {
+	topicEncodings, err := newTopicEncodings([]TopicEncodingConfig{
+		{TopicName: "connect-cluster-configs", Key: "text", Value: "json"},
+		{TopicPattern: "connect-.*-state", Key: "connectStatus", Value: "connectStatus"},
+	})
+	require.NoError(t, err)
+	d := newDeserializer(nil, nil, nil, topicEncodings)
+
+	internalEncodings := func(encoding MessageEncoding) recordEncodings {
+		return recordEncodings{Key: encoding, Value: encoding}
+	}
+	for topic, expected := range map[string]recordEncodings{
+		"__consumer_offsets":         internalEncodings(messageEncodingConsumerOffsets),
+		"__transaction_state":        internalEncodings(messageEncodingTransactionState),
+		"_schemas":                   internalEncodings(messageEncodingSchemas),
+		"connect-offsets":            internalEncodings(messageEncodingConnectOffsets),
+		"docker-connect-offsets":     internalEncodings(messageEncodingConnectOffsets),
+		"connect-configs":            internalEncodings(messageEncodingConnectConfigs),
+		"connect-status":             internalEncodings(messageEncodingConnectStatus),
+		"connect-cluster-state":      internalEncodings(messageEncodingConnectStatus),
+		"connect-cluster-configs":    {Key: messageEncodingText, Value: messageEncodingJSON},
+		"connect-offsets-of-my-team": {},
+		"orders":                     {},
+	} {
+		assert.Equal(t, expected, d.TopicEncodings.encodingsForTopic(topic), "unexpected encodings for topic %v", topic)
+	}
+
+	rec := d.DeserializeRecord(&kgo.Record{
+		Topic: "connect-status",
+		Key:   []byte("status-connector-jdbc-source"),
+		Value: nil,
+	}, recordEncodings{})
+	assert.Equal(t, messageEncodingConnectStatus, rec.Key.RecognizedEncoding)
+	assert.Equal(t, map[string]interface{}{"type": "connector", "connector": "jdbc-source"}, rec.Key.Object)
+	assert.Equal(t, messageEncodingNone, rec.Value.RecognizedEncoding, "tombstones are not decoded")
+
+	rec = d.DeserializeRecord(&kgo.Record{Topic: "__transaction_state", Key: []byte{0x00, 0x05}}, recordEncodings{})
+	assert.Equal(t, messageEncodingBinary, rec.Key.RecognizedEncoding)
+	assert.NotEmpty(t, rec.Key.DecodeError, "truncated keys must not be decoded")
+}
+
+// consumerOffsetsGoldenFile is the content of a golden file for a record of the __consumer_offsets topic. Each record
+// in testdata/consumerOffsets consists of a .key.bin and .value.bin file.
+type consumerOffsetsGoldenFile struct {
+	Key   decoderGoldenFile `json:"key"`
+	Value decoderGoldenFile `json:"value"`
+}
+
+func TestDecoders_ConsumerOffsets(t *testing.T) {
+	keyPaths, err := filepath.Glob(filepath.Join("testdata", "consumerOffsets", "*.key.bin"))
+	require.NoError(t, err)
+	require.NotEmpty(t, keyPaths)
+
+	d := newDeserializer(nil, nil, nil, newTestTopicEncodings(t))
+	for _, keyPath := range keyPaths {
+		recordPath := strings.TrimSuffix(keyPath, ".key.bin")
+
+		t.Run(filepath.Base(recordPath), func(t *testing.T) {
+			key, err := ioutil.ReadFile(keyPath)
+			require.NoError(t, err)
+			value, err := ioutil.ReadFile(recordPath + ".value.bin")
+			require.NoError(t, err)
+
+			rec := d.DeserializeRecord(&kgo.Record{Topic: "__consumer_offsets", Key: key, Value: value}, recordEncodings{})
+			require.Equal(t, messageEncodingConsumerOffsets, rec.Key.RecognizedEncoding)
+			require.Empty(t, rec.Value.DecodeError)
+			assert.Equal(t, len(value), rec.Value.Size)
+
+			golden := consumerOffsetsGoldenFile{}
+			for _, payload := range []struct {
+				res    *DeserializedPayload
+				golden *decoderGoldenFile
+			}{{rec.Key, &golden.Key}, {rec.Value, &golden.Value}} {
+				normalized, err := json.Marshal(&payload.res.Payload)
+				require.NoError(t, err)
+				*payload.golden = decoderGoldenFile{
+					Encoding: payload.res.RecognizedEncoding,
+					Payload:  normalized,
+					Object:   payload.res.Object,
+				}
+			}
+			actual, err := json.MarshalIndent(golden, "", "  ")
+			require.NoError(t, err, "the decoded objects must consist of JSON compatible types")
+
+			goldenPath := recordPath + ".golden"
+			if *updateGoldenFiles {
+				require.NoError(t, ioutil.WriteFile(goldenPath, append(actual, '\n'), 0644))
+			}
+			expected, err := ioutil.ReadFile(goldenPath)
+			require.NoError(t, err, "golden file is missing, run the tests with -update to create it")
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestDecoders_ConsumerOffsetsInvalidRecords(t *testing.T) {
+	d := newDeserializer(nil, nil, nil, newTestTopicEncodings(t))
+
+	groupMetadataKey := []byte{0x00, 0x02, 0x00, 0x01, 'g'}
+	offsetCommitKey := []byte{0x00, 0x01, 0x00, 0x01, 'g', 0x00, 0x01, 't', 0x00, 0x00, 0x00, 0x00}
+
+	// Values which can't be decoded must not be shown as tombstones
+	rec := d.DeserializeRecord(&kgo.Record{Topic: "__consumer_offsets", Key: groupMetadataKey, Value: []byte{0x00, 0x09, 0x01}}, recordEncodings{})
+	assert.Equal(t, messageEncodingConsumerOffsets, rec.Key.RecognizedEncoding)
+	assert.Equal(t, map[string]interface{}{"version": float64(2), "group": "g"}, rec.Key.Object)
+	assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+	assert.Contains(t, rec.Value.DecodeError, "unknown group metadata value version")
+
+	// Trailing bytes indicate an unknown format
+	value := []byte{0x00, 0x02, 0, 0, 0, 0, 0, 0, 0, 1, 0x00, 0x00, 0, 0, 0, 0, 0, 0, 0, 2, 0xff}
+	rec = d.DeserializeRecord(&kgo.Record{Topic: "__consumer_offsets", Key: offsetCommitKey, Value: value}, recordEncodings{})
+	assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+	assert.NotEmpty(t, rec.Value.DecodeError)
+
+	// Keys with unknown versions fall back to the detection of the encodings
+	rec = d.DeserializeRecord(&kgo.Record{Topic: "__consumer_offsets", Key: []byte{0x00, 0x63, 'x'}, Value: []byte("{}")}, recordEncodings{})
+	assert.NotEqual(t, messageEncodingConsumerOffsets, rec.Key.RecognizedEncoding)
+	assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding)
+
+	// Values can be decoded without the key if requested
+	res := d.deserializePayloadWithEncoding(value[:len(value)-1], "offsets-backup", proto.RecordValue, messageEncodingConsumerOffsets)
+	assert.Equal(t, messageEncodingConsumerOffsets, res.RecognizedEncoding)
+	assert.Equal(t, float64(1), res.Object.(map[string]interface{})["offset"])
+}
+
+func newTestTopicEncodings(t *testing.T) topicEncodings {
+	topicEncodings, err := newTopicEncodings(nil)
+	require.NoError(t, err)
+	return topicEncodings
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// textDecoder accepts all valid UTF-8 payloads. It's tried after all structured formats.
+type textDecoder struct{}
+
+func (d *textDecoder) Encoding() MessageEncoding {
+	return messageEncodingText
+}
+
+func (d *textDecoder) Priority() int {
+	return 900
+}
+
+func (d *textDecoder) Detect(payload []byte) bool {
+	return utf8.Valid(payload)
+}
+
+func (d *textDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	if !utf8.Valid(payload) {
+		return nil, fmt.Errorf("payload is not valid UTF-8")
+	}
+
+	return &DeserializedPayload{Payload: NormalizedPayload{
+		Payload:            payload,
+		RecognizedEncoding: messageEncodingText,
+	}, Object: string(payload), RecognizedEncoding: messageEncodingText, Size: len(payload)}, nil
+}
+
+// binaryDecoder accepts any payload and is therefore tried last.
+type binaryDecoder struct{}
+
+func (d *binaryDecoder) Encoding() MessageEncoding {
+	return messageEncodingBinary
+}
+
+func (d *binaryDecoder) Priority() int {
+	return 1000
+}
+
+func (d *binaryDecoder) Detect(_ []byte) bool {
+	return true
+}
+
+func (d *binaryDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	return binaryPayload(payload), nil
+}
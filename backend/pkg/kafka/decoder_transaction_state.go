@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/twmb/franz-go/pkg/kbin"
+)
+
+// transactionStateDecoder decodes the records of the __transaction_state topic, in which the transaction coordinators
+// store the state of all transactional producers. Keys and values are encoded in Kafka's binary protocol and start
+// with their version.
+type transactionStateDecoder struct{}
+
+// TransactionStateKey is the key of a record in the __transaction_state topic
+type TransactionStateKey struct {
+	Version         int16  `json:"version"`
+	TransactionalID string `json:"transactionalId"`
+}
+
+// TransactionStateValue is the metadata of a transaction, as it's stored by the transaction coordinator.
+type TransactionStateValue struct {
+	Version               int16                             `json:"version"`
+	ProducerID            int64                             `json:"producerId"`
+	ProducerEpoch         int16                             `json:"producerEpoch"`
+	TransactionTimeoutMs  int32                             `json:"transactionTimeoutMs"`
+	TransactionStatus     string                            `json:"transactionStatus"`
+	TransactionPartitions []TransactionStatePartitionsValue `json:"transactionPartitions"`
+	LastUpdateTimestampMs int64                             `json:"transactionLastUpdateTimestampMs"`
+	StartTimestampMs      int64                             `json:"transactionStartTimestampMs"`
+}
+
+// TransactionStatePartitionsValue are the partitions of a topic, which are part of a transaction.
+type TransactionStatePartitionsValue struct {
+	Topic        string  `json:"topic"`
+	PartitionIDs []int32 `json:"partitionIds"`
+}
+
+// transactionStatuses are the names of the transaction states, indexed by their id.
+var transactionStatuses = []string{
+	"Empty",
+	"Ongoing",
+	"PrepareCommit",
+	"PrepareAbort",
+	"CompleteCommit",
+	"CompleteAbort",
+	"Dead",
+	"PrepareEpochFence",
+}
+
+func (d *transactionStateDecoder) Encoding() MessageEncoding {
+	return messageEncodingTransactionState
+}
+
+func (d *transactionStateDecoder) Priority() int {
+	return 800
+}
+
+// Detect always returns false, the decoder is selected by the topic encodings instead.
+func (d *transactionStateDecoder) Detect(_ []byte) bool {
+	return false
+}
+
+func (d *transactionStateDecoder) Decode(payload []byte, _ string, recordType proto.RecordPropertyType) (*DeserializedPayload, error) {
+	r := &kbin.Reader{Src: payload}
+	version := r.Int16()
+
+	var obj interface{}
+	if recordType == proto.RecordKey {
+		if version != 0 {
+			return nil, fmt.Errorf("unknown transaction state key version '%d'", version)
+		}
+		obj = TransactionStateKey{
+			Version:         version,
+			TransactionalID: r.String(),
+		}
+	} else {
+		value, err := readTransactionStateValue(r, version)
+		if err != nil {
+			return nil, err
+		}
+		obj = value
+	}
+
+	if err := completeKafkaProtocolReader(r); err != nil {
+		return nil, err
+	}
+	return newTypedPayload(messageEncodingTransactionState, obj, payload)
+}
+
+// readTransactionStateValue reads the transaction metadata. Version 1 is a flexible version, that is it uses compact
+// strings / arrays and may contain tagged fields.
+func readTransactionStateValue(r *kbin.Reader, version int16) (TransactionStateValue, error) {
+	if version < 0 || version > 1 {
+		return TransactionStateValue{}, fmt.Errorf("unknown transaction state value version '%d'", version)
+	}
+	isFlexible := version >= 1
+
+	value := TransactionStateValue{
+		Version:              version,
+		ProducerID:           r.Int64(),
+		ProducerEpoch:        r.Int16(),
+		TransactionTimeoutMs: r.Int32(),
+	}
+	status := r.Int8()
+	value.TransactionStatus = fmt.Sprintf("Unknown(%d)", status)
+	if status >= 0 && int(status) < len(transactionStatuses) {
+		value.TransactionStatus = transactionStatuses[status]
+	}
+
+	partitionsLen := readKafkaArrayLen(r, isFlexible)
+	value.TransactionPartitions = make([]TransactionStatePartitionsValue, 0)
+	for i := int32(0); i < partitionsLen && r.Ok(); i++ {
+		partitions := TransactionStatePartitionsValue{Topic: readKafkaString(r, isFlexible)}
+		idsLen := readKafkaArrayLen(r, isFlexible)
+		partitions.PartitionIDs = make([]int32, 0)
+		for j := int32(0); j < idsLen && r.Ok(); j++ {
+			partitions.PartitionIDs = append(partitions.PartitionIDs, r.Int32())
+		}
+		if isFlexible {
+			skipKafkaTaggedFields(r)
+		}
+		value.TransactionPartitions = append(value.TransactionPartitions, partitions)
+	}
+
+	value.LastUpdateTimestampMs = r.Int64()
+	value.StartTimestampMs = r.Int64()
+	if isFlexible {
+		skipKafkaTaggedFields(r)
+	}
+
+	return value, nil
+}
+
+func readKafkaArrayLen(r *kbin.Reader, isFlexible bool) int32 {
+	if isFlexible {
+		return r.CompactArrayLen()
+	}
+	return r.ArrayLen()
+}
+
+func readKafkaString(r *kbin.Reader, isFlexible bool) string {
+	if isFlexible {
+		return r.CompactString()
+	}
+	return r.String()
+}
+
+func readKafkaNullableString(r *kbin.Reader, isFlexible bool) *string {
+	if isFlexible {
+		return r.CompactNullableString()
+	}
+	return r.NullableString()
+}
+
+func readKafkaBytes(r *kbin.Reader, isFlexible bool) []byte {
+	if isFlexible {
+		return r.CompactBytes()
+	}
+	return r.Bytes()
+}
+
+// skipKafkaTaggedFields skips all tagged fields, which are unknown to us.
+func skipKafkaTaggedFields(r *kbin.Reader) {
+	numTags := r.Uvarint()
+	for i := uint32(0); i < numTags && r.Ok(); i++ {
+		_ = r.Uvarint() // tag
+		size := int(r.Uvarint())
+		if size > len(r.Src) {
+			// Let the reader fail on its own by reading more than available
+			r.Src = r.Src[:0]
+			_ = r.Int8()
+			return
+		}
+		r.Src = r.Src[size:]
+	}
+}
+
+// completeKafkaProtocolReader returns an error if the payload was too short or has not been read completely.
+func completeKafkaProtocolReader(r *kbin.Reader) error {
+	if err := r.Complete(); err != nil {
+		return err
+	}
+	if len(r.Src) > 0 {
+		return fmt.Errorf("%d bytes left after decoding", len(r.Src))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+
+	xj "github.com/basgys/goxml2json"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// xmlDecoder converts XML payloads into their JSON representation.
+type xmlDecoder struct{}
+
+func (d *xmlDecoder) Encoding() MessageEncoding {
+	return messageEncodingXML
+}
+
+func (d *xmlDecoder) Priority() int {
+	return 200
+}
+
+func (d *xmlDecoder) Detect(payload []byte) bool {
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '<'
+}
+
+func (d *xmlDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	r := bytes.NewReader(bytes.TrimSpace(payload))
+	jsonPayload, err := xj.Convert(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj interface{}
+	_ = json.Unmarshal(jsonPayload.Bytes(), &obj) // no err possible unless the xml2json package is buggy
+	return &DeserializedPayload{Payload: NormalizedPayload{
+		Payload:            jsonPayload.Bytes(),
+		RecognizedEncoding: messageEncodingXML,
+	}, Object: obj, RecognizedEncoding: messageEncodingXML, Size: len(payload)}, nil
+}
@@ -0,0 +1,21 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DeleteACLs deletes all ACL bindings which match at least one of the given filters.
+func (s *Service) DeleteACLs(ctx context.Context, filters []kmsg.DeleteACLsRequestFilter) (*kmsg.DeleteACLsResponse, error) {
+	req := kmsg.NewDeleteACLsRequest()
+	req.Filters = filters
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete ACLs: %w", err)
+	}
+
+	return res, nil
+}
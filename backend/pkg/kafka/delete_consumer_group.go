@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DeleteConsumerGroups deletes the given consumer groups. Only groups without active members can be deleted.
+func (s *Service) DeleteConsumerGroups(ctx context.Context, groups []string) (*kmsg.DeleteGroupsResponse, error) {
+	req := kmsg.NewDeleteGroupsRequest()
+	req.Groups = groups
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete consumer groups: %w", err)
+	}
+
+	return res, nil
+}
+
+const (
+	// coordinatorMaxAttempts is the number of attempts for requests which are sent to the group coordinator
+	coordinatorMaxAttempts = 3
+	// coordinatorRetryBackoff is the time to wait before the coordinator is looked up again
+	coordinatorRetryBackoff = 250 * time.Millisecond
+)
+
+// DeleteConsumerGroupOffsets deletes the committed offsets of a group for the given topic partitions. The offsets
+// can not be deleted if the group is actively subscribed to the topic.
+func (s *Service) DeleteConsumerGroupOffsets(ctx context.Context, group string, topics []kmsg.OffsetDeleteRequestTopic) (*kmsg.OffsetDeleteResponse, error) {
+	req := kmsg.NewOffsetDeleteRequest()
+	req.Group = group
+	req.Topics = topics
+
+	// The coordinator may have moved to another broker or may still be loading the group, in which case we look it up
+	// again and retry the request.
+	for attempt := 1; ; attempt++ {
+		res, err := s.sendToGroupCoordinator(ctx, group, &req)
+		retry := isRetriableCoordinatorError(err)
+		if err == nil {
+			retry = isRetriableCoordinatorError(kerr.ErrorForCode(res.ErrorCode))
+		}
+		if !retry || attempt == coordinatorMaxAttempts {
+			return res, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(coordinatorRetryBackoff):
+		}
+	}
+}
+
+// sendToGroupCoordinator sends the offset delete request to the broker that coordinates the group. The client does
+// not route offset delete requests to the group coordinator, hence we have to look it up ourselves.
+func (s *Service) sendToGroupCoordinator(ctx context.Context, group string, req *kmsg.OffsetDeleteRequest) (*kmsg.OffsetDeleteResponse, error) {
+	coordinatorID, err := s.findGroupCoordinator(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.RequestWith(ctx, s.KafkaClient.Broker(int(coordinatorID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete group offsets for group '%v': %w", group, err)
+	}
+
+	return res, nil
+}
+
+// isRetriableCoordinatorError returns true if the error indicates that the group coordinator must be looked up again.
+func isRetriableCoordinatorError(err error) bool {
+	return errors.Is(err, kerr.NotCoordinator) ||
+		errors.Is(err, kerr.CoordinatorLoadInProgress) ||
+		errors.Is(err, kerr.CoordinatorNotAvailable)
+}
+
+// findGroupCoordinator returns the node id of the broker that coordinates the given consumer group.
+func (s *Service) findGroupCoordinator(ctx context.Context, group string) (int32, error) {
+	req := kmsg.NewFindCoordinatorRequest()
+	req.CoordinatorKey = group
+	req.CoordinatorType = 0 // Group coordinator
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find coordinator for group '%v': %w", group, err)
+	}
+
+	err = kerr.ErrorForCode(res.ErrorCode)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find coordinator for group '%v'. Inner error: %w", group, err)
+	}
+
+	return res.NodeID, nil
+}
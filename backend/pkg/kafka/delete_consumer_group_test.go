@@ -0,0 +1,29 @@
+package kafka
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestIsRetriableCoordinatorError(t *testing.T) {
+	tt := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{kerr.NotCoordinator, true},
+		{kerr.CoordinatorLoadInProgress, true},
+		{kerr.CoordinatorNotAvailable, true},
+		{fmt.Errorf("failed to find coordinator for group 'orders'. Inner error: %w", kerr.CoordinatorNotAvailable), true},
+		{kerr.GroupSubscribedToTopic, false},
+		{kerr.GroupIDNotFound, false},
+		{fmt.Errorf("connection refused"), false},
+	}
+
+	for _, table := range tt {
+		assert.Equal(t, table.expected, isRetriableCoordinatorError(table.err), "unexpected result for error '%v'", table.err)
+	}
+}
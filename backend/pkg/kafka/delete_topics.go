@@ -0,0 +1,31 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DeleteTopics deletes the given topics including all their data.
+func (s *Service) DeleteTopics(ctx context.Context, topicNames []string) (*kmsg.DeleteTopicsResponse, error) {
+	// Topics are identified by name up to v5 and by name or topic id since v6. The client picks the right field
+	// depending on the negotiated request version.
+	topics := make([]kmsg.DeleteTopicsRequestTopic, len(topicNames))
+	for i := range topicNames {
+		topic := kmsg.NewDeleteTopicsRequestTopic()
+		topic.Topic = &topicNames[i]
+		topics[i] = topic
+	}
+
+	req := kmsg.NewDeleteTopicsRequest()
+	req.TopicNames = topicNames
+	req.Topics = topics
+
+	res, err := req.RequestWith(ctx, s.KafkaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete topics: %w", err)
+	}
+
+	return res, nil
+}
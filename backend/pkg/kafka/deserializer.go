@@ -3,49 +3,67 @@ package kafka
 import (
 	"bytes"
 	"encoding/base64"
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"github.com/cloudhut/kowl/backend/pkg/proto"
-	"github.com/twmb/franz-go/pkg/kbin"
-	"github.com/twmb/franz-go/pkg/kgo"
-	"github.com/twmb/franz-go/pkg/kmsg"
-	"strings"
-	"unicode/utf8"
 
-	xj "github.com/basgys/goxml2json"
+	"github.com/cloudhut/kowl/backend/pkg/avro"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
 	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/twmb/franz-go/pkg/kgo"
 )
 
-// deserializer can deserialize messages from various formats (json, xml, avro, ..) into a Go native form.
+// deserializer can deserialize messages from various formats (json, xml, avro, ..) into a Go native form. Each format
+// is implemented by a Decoder, additional decoders can be registered via RegisterDecoder.
 type deserializer struct {
 	SchemaService *schema.Service
 	ProtoService  *proto.Service
+	AvroService   *avro.Service
+
+	// TopicEncodings are the encodings which have been configured for specific topics
+	TopicEncodings topicEncodings
+
+	decoders *decoderRegistry
 }
 
-type messageEncoding string
+// MessageEncoding is the name of the encoding a payload has been decoded with. Decoders may introduce their own
+// encodings.
+type MessageEncoding string
 
 const (
-	messageEncodingNone            messageEncoding = "none"
-	messageEncodingAvro            messageEncoding = "avro"
-	messageEncodingProtobuf        messageEncoding = "protobuf"
-	messageEncodingJSON            messageEncoding = "json"
-	messageEncodingXML             messageEncoding = "xml"
-	messageEncodingText            messageEncoding = "text"
-	messageEncodingConsumerOffsets messageEncoding = "consumerOffsets"
-	messageEncodingBinary          messageEncoding = "binary"
+	messageEncodingNone             MessageEncoding = "none"
+	messageEncodingAvro             MessageEncoding = "avro"
+	messageEncodingProtobuf         MessageEncoding = "protobuf"
+	messageEncodingJSON             MessageEncoding = "json"
+	messageEncodingXML              MessageEncoding = "xml"
+	messageEncodingText             MessageEncoding = "text"
+	messageEncodingConsumerOffsets  MessageEncoding = "consumerOffsets"
+	messageEncodingBinary           MessageEncoding = "binary"
+	messageEncodingMsgPack          MessageEncoding = "msgpack"
+	messageEncodingCBOR             MessageEncoding = "cbor"
+	messageEncodingBSON             MessageEncoding = "bson"
+	messageEncodingSmile            MessageEncoding = "smile"
+	messageEncodingInt32BE          MessageEncoding = "int32be"
+	messageEncodingInt64BE          MessageEncoding = "int64be"
+	messageEncodingFloat32BE        MessageEncoding = "float32be"
+	messageEncodingFloat64BE        MessageEncoding = "float64be"
+	messageEncodingUUID             MessageEncoding = "uuid"
+	messageEncodingTransactionState MessageEncoding = "transactionState"
+	messageEncodingConnectOffsets   MessageEncoding = "connectOffsets"
+	messageEncodingConnectConfigs   MessageEncoding = "connectConfigs"
+	messageEncodingConnectStatus    MessageEncoding = "connectStatus"
+	messageEncodingSchemas          MessageEncoding = "schemas"
 )
 
-// normalizedPayload is a wrapper of the original message with the purpose of having a custom JSON marshal method
-type normalizedPayload struct {
+// NormalizedPayload is a wrapper of the original message with the purpose of having a custom JSON marshal method
+type NormalizedPayload struct {
 	// Payload is the original payload except for all message encodings which can be converted to a JSON object
 	Payload            []byte
-	RecognizedEncoding messageEncoding `json:"encoding"`
+	RecognizedEncoding MessageEncoding `json:"encoding"`
 }
 
 // MarshalJSON implements the 'Marshaller' interface for deserialized payload.
 // We do this because we want to pass the deserialized payload as JavaScript object (regardless of the encoding) to the frontend.
-func (d *normalizedPayload) MarshalJSON() ([]byte, error) {
+func (d *NormalizedPayload) MarshalJSON() ([]byte, error) {
 	switch d.RecognizedEncoding {
 	case messageEncodingNone:
 		return []byte("{}"), nil
@@ -59,250 +77,247 @@ func (d *normalizedPayload) MarshalJSON() ([]byte, error) {
 	}
 }
 
-type deserializedPayload struct {
-	Payload normalizedPayload `json:"payload"`
+type DeserializedPayload struct {
+	Payload NormalizedPayload `json:"payload"`
 
 	// Object is the parsed version of the payload. This will be passed to the JavaScript interpreter
 	Object             interface{}     `json:"-"`
-	RecognizedEncoding messageEncoding `json:"encoding"`
+	RecognizedEncoding MessageEncoding `json:"encoding"`
 	AvroSchemaID       uint32          `json:"avroSchemaId"`
 	Size               int             `json:"size"` // number of 'raw' bytes
+
+	// SchemaID is set if the payload has been decoded using a schema from the schema registry
+	SchemaID uint32 `json:"schemaId"`
+	// SchemaValidationError is set if a JSON payload does not match its JSON schema
+	SchemaValidationError string `json:"schemaValidationError,omitempty"`
+	// DecodeError is set if the payload could not be decoded with the requested encoding
+	DecodeError string `json:"decodeError,omitempty"`
+}
+
+// newJSONPayload returns the deserialized payload for a decoded object, whose JSON representation is shown in the
+// frontend. This is the shape all decoders of JSON like formats (e.g. MessagePack or CBOR) shall return.
+func newJSONPayload(encoding MessageEncoding, object interface{}, payload []byte) (*DeserializedPayload, error) {
+	jsonBytes, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal decoded payload to JSON: %w", err)
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: encoding,
+		},
+		Object:             object,
+		RecognizedEncoding: encoding,
+		Size:               len(payload),
+	}, nil
+}
+
+// newTypedPayload returns the deserialized payload for a Go struct, which describes the structure of a binary or JSON
+// payload. The struct is converted into its JSON form, so that the JavaScript filter sees the same (JSON) field names
+// as the frontend.
+func newTypedPayload(encoding MessageEncoding, typed interface{}, payload []byte) (*DeserializedPayload, error) {
+	jsonBytes, err := json.Marshal(typed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal decoded payload to JSON: %w", err)
+	}
+	var obj interface{}
+	err = json.Unmarshal(jsonBytes, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeserializedPayload{
+		Payload: NormalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: encoding,
+		},
+		Object:             obj,
+		RecognizedEncoding: encoding,
+		Size:               len(payload),
+	}, nil
 }
 
 type deserializedRecord struct {
-	Key     *deserializedPayload
-	Value   *deserializedPayload
-	Headers map[string]*deserializedPayload
+	Key     *DeserializedPayload
+	Value   *DeserializedPayload
+	Headers map[string]*DeserializedPayload
 }
 
-// DeserializePayload tries to deserialize a given byte array.
-// The payload's byte array may represent
+// newDeserializer creates a deserializer with all built-in decoders. The services are optional, decoders that depend
+// on a service which is nil won't decode any payloads.
+func newDeserializer(schemaSvc *schema.Service, protoSvc *proto.Service, avroSvc *avro.Service, topicEncodings topicEncodings) *deserializer {
+	d := &deserializer{
+		SchemaService:  schemaSvc,
+		ProtoService:   protoSvc,
+		AvroService:    avroSvc,
+		TopicEncodings: topicEncodings,
+		decoders:       newDecoderRegistry(),
+	}
+
+	builtInDecoders := []Decoder{
+		&jsonDecoder{schemaSvc: schemaSvc},
+		&xmlDecoder{},
+		&avroDecoder{schemaSvc: schemaSvc, avroSvc: avroSvc},
+		&protobufDecoder{schemaSvc: schemaSvc, protoSvc: protoSvc},
+		&smileDecoder{},
+		&bsonDecoder{},
+		&cborDecoder{},
+		&msgPackDecoder{},
+		&textDecoder{},
+		&binaryDecoder{},
+		&int32BEDecoder{},
+		&int64BEDecoder{},
+		&float32BEDecoder{},
+		&float64BEDecoder{},
+		&uuidDecoder{},
+		&consumerOffsetsDecoder{},
+		&transactionStateDecoder{},
+		&connectOffsetsDecoder{},
+		&connectConfigsDecoder{},
+		&connectStatusDecoder{},
+		&schemasDecoder{},
+	}
+	for _, decoder := range builtInDecoders {
+		// Built-in decoders have distinct encodings, hence registering them can't fail
+		_ = d.decoders.register(decoder)
+	}
+
+	return d
+}
+
+// RegisterDecoder adds a decoder for an additional encoding. The decoder will be used for detecting encodings as well
+// as if its encoding has been requested. Decoders must be registered before any messages are deserialized.
+func (d *deserializer) RegisterDecoder(decoder Decoder) error {
+	return d.decoders.register(decoder)
+}
+
+// isSupportedEncoding returns whether a decoder for the given encoding has been registered.
+func (d *deserializer) isSupportedEncoding(encoding MessageEncoding) bool {
+	_, exists := d.decoders.get(encoding)
+	return exists
+}
+
+// validateTopicEncodings checks that all encodings of the configured topic encodings are supported.
+func (d *deserializer) validateTopicEncodings() error {
+	for _, topicEncoding := range d.TopicEncodings {
+		encodings := topicEncoding.encodings
+		for _, encoding := range []MessageEncoding{encodings.Key, encodings.Value, encodings.Headers} {
+			if encoding != "" && !d.isSupportedEncoding(encoding) {
+				return fmt.Errorf("unsupported encoding '%v' configured for topic '%v%v', supported encodings are: %v",
+					encoding, topicEncoding.topicName, topicEncoding.topicPattern, d.decoders.encodings())
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeserializeRecord tries to deserialize the key, value and headers of the given record.
+// Each payload's byte array may represent
 //  - an encoded message such as JSON, Avro or XML
 //  - UTF-8 Text
 //  - Binary content
-// Idea: Add encoding hint where user can suggest the backend to test this encoding first.
-func (d *deserializer) DeserializeRecord(record *kgo.Record) *deserializedRecord {
-	// 1. Test if it's a known binary Format
-	if record.Topic == "__consumer_offsets" {
-		rec, err := d.deserializeConsumerOffset(record)
+// Encodings which have been requested for this record (or configured for the record's topic) are used instead of
+// detecting the encoding.
+func (d *deserializer) DeserializeRecord(record *kgo.Record, requested recordEncodings) *deserializedRecord {
+	// 1. Use the requested encodings, the encodings configured for the topic or detect them otherwise
+	encodings := d.TopicEncodings.encodingsForTopic(record.Topic)
+	if requested.Key != "" {
+		encodings.Key = requested.Key
+	}
+	if requested.Value != "" {
+		encodings.Value = requested.Value
+	}
+
+	// 2. The type of the values in __consumer_offsets is determined by the key, hence they are decoded together
+	if encodings.Key == messageEncodingConsumerOffsets && encodings.Value == messageEncodingConsumerOffsets {
+		rec, err := deserializeConsumerOffsetsRecord(record)
 		if err == nil {
 			return rec
 		}
+		encodings.Key = ""
+		encodings.Value = ""
 	}
 
-	headers := make(map[string]*deserializedPayload)
+	headers := make(map[string]*DeserializedPayload)
 	for _, header := range record.Headers {
-		headers[header.Key] = d.deserializePayload(header.Value, record.Topic, proto.RecordValue)
+		headers[header.Key] = d.deserializePayloadWithEncoding(header.Value, record.Topic, proto.RecordValue, encodings.Headers)
 	}
 	return &deserializedRecord{
-		Key:     d.deserializePayload(record.Key, record.Topic, proto.RecordKey),
-		Value:   d.deserializePayload(record.Value, record.Topic, proto.RecordValue),
+		Key:     d.deserializePayloadWithEncoding(record.Key, record.Topic, proto.RecordKey, encodings.Key),
+		Value:   d.deserializePayloadWithEncoding(record.Value, record.Topic, proto.RecordValue, encodings.Value),
 		Headers: headers,
 	}
 }
 
-func (d *deserializer) deserializePayload(payload []byte, topicName string, recordType proto.RecordPropertyType) *deserializedPayload {
-	// 0. Check if payload is empty / whitespace only
+// deserializePayloadWithEncoding decodes the payload with the given encoding only. If the payload can not be decoded
+// it's returned as binary along with the decoding error, rather than silently falling back to another encoding. If no
+// encoding is given, it will be detected.
+func (d *deserializer) deserializePayloadWithEncoding(payload []byte, topicName string, recordType proto.RecordPropertyType, encoding MessageEncoding) *DeserializedPayload {
+	if encoding == "" {
+		return d.deserializePayload(payload, topicName, recordType)
+	}
 	if len(payload) == 0 {
-		return &deserializedPayload{Payload: normalizedPayload{
-			Payload:            payload,
-			RecognizedEncoding: messageEncodingNone,
-		}, Object: "", RecognizedEncoding: messageEncodingNone, Size: len(payload)}
+		return emptyPayload(payload)
+	}
+
+	var deserialized *DeserializedPayload
+	var err error
+	decoder, exists := d.decoders.get(encoding)
+	if exists {
+		deserialized, err = decode(decoder, payload, topicName, recordType)
+	} else {
+		err = fmt.Errorf("unsupported encoding")
+	}
+	if err != nil {
+		deserialized = binaryPayload(payload)
+		deserialized.DecodeError = fmt.Sprintf("failed to decode payload as %v: %v", encoding, err)
+	}
+
+	return deserialized
+}
+
+// deserializePayload detects the payload's encoding by trying all decoders, which consider the payload decodable, in
+// the order of their priority. Payloads that can't be decoded otherwise are returned as binary.
+func (d *deserializer) deserializePayload(payload []byte, topicName string, recordType proto.RecordPropertyType) *DeserializedPayload {
+	// Check if payload is empty / whitespace only
+	if len(payload) == 0 {
+		return emptyPayload(payload)
 	}
 
 	trimmed := bytes.TrimLeft(payload, " \t\r\n")
 	if len(trimmed) == 0 {
-		return &deserializedPayload{Payload: normalizedPayload{
+		return &DeserializedPayload{Payload: NormalizedPayload{
 			Payload:            payload,
 			RecognizedEncoding: messageEncodingText,
 		}, Object: string(payload), RecognizedEncoding: messageEncodingText, Size: len(payload)}
 	}
 
-	// 1. Test for valid JSON
-	startsWithJSON := trimmed[0] == '[' || trimmed[0] == '{'
-	if startsWithJSON {
-		var obj interface{}
-		err := json.Unmarshal(payload, &obj)
-		if err == nil {
-			return &deserializedPayload{Payload: normalizedPayload{
-				Payload:            trimmed,
-				RecognizedEncoding: messageEncodingJSON,
-			}, Object: obj, RecognizedEncoding: messageEncodingJSON, Size: len(payload)}
+	for _, decoder := range d.decoders.byPriority {
+		if !decoder.Detect(payload) {
+			continue
 		}
-	}
-
-	// 2. Test for valid XML
-	startsWithXML := trimmed[0] == '<'
-	if startsWithXML {
-		r := strings.NewReader(string(trimmed))
-		jsonPayload, err := xj.Convert(r)
+		deserialized, err := decode(decoder, payload, topicName, recordType)
 		if err == nil {
-			var obj interface{}
-			_ = json.Unmarshal(jsonPayload.Bytes(), &obj) // no err possible unless the xml2json package is buggy
-			return &deserializedPayload{Payload: normalizedPayload{
-				Payload:            jsonPayload.Bytes(),
-				RecognizedEncoding: messageEncodingXML,
-			}, Object: obj, RecognizedEncoding: messageEncodingXML, Size: len(payload)}
-		}
-	}
-
-	// 3. Test for Avro (reference: https://docs.confluent.io/current/schema-registry/serdes-develop/index.html#wire-format)
-	if d.SchemaService != nil && len(payload) > 5 {
-		// Check if magic byte is set
-		if payload[0] == byte(0) {
-			schemaID := binary.BigEndian.Uint32(payload[1:5])
-			codec, err := d.SchemaService.GetAvroSchemaByID(schemaID)
-			if err == nil {
-				native, _, err := codec.NativeFromBinary(payload[5:])
-				if err == nil {
-					normalized, _ := codec.TextualFromNative(nil, native)
-					return &deserializedPayload{
-						Payload: normalizedPayload{
-							Payload:            normalized,
-							RecognizedEncoding: messageEncodingAvro,
-						},
-						Object:             native,
-						RecognizedEncoding: messageEncodingAvro,
-						AvroSchemaID:       schemaID,
-						Size:               len(payload),
-					}
-				}
-			}
+			return deserialized
 		}
 	}
 
-	// 4. Test for Protobuf
-	if d.ProtoService != nil {
-		jsonBytes, err := d.ProtoService.UnmarshalPayload(payload, topicName, recordType)
-		if err == nil {
-			var native interface{}
-			err := json.Unmarshal(jsonBytes, &native)
-			if err == nil {
-				return &deserializedPayload{
-					Payload: normalizedPayload{
-						Payload:            jsonBytes,
-						RecognizedEncoding: messageEncodingProtobuf,
-					},
-					Object:             native,
-					RecognizedEncoding: messageEncodingProtobuf,
-					Size:               len(payload),
-				}
-			}
-		}
-	}
+	// Anything else is considered as binary content
+	return binaryPayload(payload)
+}
 
-	// 5. Test for UTF-8 validity
-	isUTF8 := utf8.Valid(payload)
-	if isUTF8 {
-		return &deserializedPayload{Payload: normalizedPayload{
-			Payload:            payload,
-			RecognizedEncoding: messageEncodingText,
-		}, Object: string(payload), RecognizedEncoding: messageEncodingText, Size: len(payload)}
-	}
+func emptyPayload(payload []byte) *DeserializedPayload {
+	return &DeserializedPayload{Payload: NormalizedPayload{
+		Payload:            payload,
+		RecognizedEncoding: messageEncodingNone,
+	}, Object: "", RecognizedEncoding: messageEncodingNone, Size: len(payload)}
+}
 
-	// Anything else is considered as binary content
-	return &deserializedPayload{Payload: normalizedPayload{
+func binaryPayload(payload []byte) *DeserializedPayload {
+	return &DeserializedPayload{Payload: NormalizedPayload{
 		Payload:            payload,
 		RecognizedEncoding: messageEncodingBinary,
 	}, Object: payload, RecognizedEncoding: messageEncodingBinary, Size: len(payload)}
 }
-
-// deserializeConsumerOffset deserializes the binary messages in the __consumer_offsets topic
-func (d *deserializer) deserializeConsumerOffset(record *kgo.Record) (*deserializedRecord, error) {
-	if len(record.Key) < 2 {
-		return nil, fmt.Errorf("offset commit key is supposed to be at least 2 bytes long")
-	}
-
-	// 1. Figure out what kind of message we've got. On this topic we'll find OffsetCommits as well as GroupMetadata
-	// messages.
-	messageVer := (&kbin.Reader{Src: record.Key}).Int16()
-
-	var deserializedKey *deserializedPayload
-	var deserializedVal *deserializedPayload
-	switch messageVer {
-	case 0, 1:
-		// We got an offset commit message
-		offsetCommitKey := kmsg.NewOffsetCommitKey()
-		err := offsetCommitKey.ReadFrom(record.Key)
-		if err == nil {
-			key, _ := json.Marshal(offsetCommitKey)
-			deserializedKey = &deserializedPayload{
-				Payload: normalizedPayload{
-					Payload:            key,
-					RecognizedEncoding: messageEncodingConsumerOffsets,
-				},
-				Object:             offsetCommitKey,
-				RecognizedEncoding: messageEncodingConsumerOffsets,
-				Size:               len(record.Key),
-			}
-		}
-
-		if record.Value == nil {
-			break
-		}
-		offsetCommitValue := kmsg.NewOffsetCommitValue()
-		err = offsetCommitValue.ReadFrom(record.Value)
-		if err == nil {
-			val, _ := json.Marshal(offsetCommitValue)
-			deserializedVal = &deserializedPayload{
-				Payload: normalizedPayload{
-					Payload:            val,
-					RecognizedEncoding: messageEncodingConsumerOffsets,
-				},
-				Object:             val,
-				RecognizedEncoding: messageEncodingConsumerOffsets,
-				Size:               len(record.Value),
-			}
-		}
-	case 2:
-		// We got a group metadata message
-		metadataKey := kmsg.NewGroupMetadataKey()
-		err := metadataKey.ReadFrom(record.Key)
-		if err == nil {
-			key, _ := json.Marshal(metadataKey)
-			deserializedKey = &deserializedPayload{
-				Payload: normalizedPayload{
-					Payload:            key,
-					RecognizedEncoding: messageEncodingConsumerOffsets,
-				},
-				Object:             metadataKey,
-				RecognizedEncoding: messageEncodingConsumerOffsets,
-				Size:               len(record.Key),
-			}
-		}
-
-		if record.Value == nil {
-			break
-		}
-		metadataValue := kmsg.NewGroupMetadataValue()
-		err = metadataValue.ReadFrom(record.Value)
-		if err == nil {
-			key, _ := json.Marshal(metadataValue)
-			deserializedVal = &deserializedPayload{
-				Payload: normalizedPayload{
-					Payload:            key,
-					RecognizedEncoding: messageEncodingConsumerOffsets,
-				},
-				Object:             metadataValue,
-				RecognizedEncoding: messageEncodingConsumerOffsets,
-				Size:               len(record.Value),
-			}
-		}
-	default:
-		// Unknown format
-		return nil, fmt.Errorf("unknown message version '%d' detected", messageVer)
-	}
-
-	if deserializedVal == nil {
-		// Tombstone
-		deserializedVal = &deserializedPayload{Payload: normalizedPayload{
-			Payload:            record.Value,
-			RecognizedEncoding: messageEncodingNone,
-		}, Object: "", RecognizedEncoding: messageEncodingNone, Size: len(record.Value)}
-	}
-	return &deserializedRecord{
-		Key:     deserializedKey,
-		Value:   deserializedVal,
-		Headers: nil,
-	}, nil
-}
@@ -0,0 +1,293 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func newSchemaRegistryDeserializer(t *testing.T, schemasByPath map[string]interface{}) (*deserializer, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, exists := schemasByPath[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+
+	cfg := schema.Config{Enabled: true, URLs: []string{server.URL}}
+	cfg.SetDefaults()
+	schemaSvc, err := schema.NewSevice(cfg)
+	require.NoError(t, err)
+
+	return newDeserializer(schemaSvc, nil, nil, nil), server.Close
+}
+
+func wireFormatPayload(schemaID uint32, payload ...byte) []byte {
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], schemaID)
+	return append(header, payload...)
+}
+
+func TestDeserializer_SchemaRegistryWireFormat(t *testing.T) {
+	d, closeServer := newSchemaRegistryDeserializer(t, map[string]interface{}{
+		"/schemas/ids/1": map[string]interface{}{
+			"schemaType": schema.SchemaTypeProtobuf,
+			"schema": `syntax = "proto3";
+message Customer { string name = 1; }
+message Order {
+  message Item { string sku = 1; }
+  string id = 1;
+}`,
+		},
+		"/schemas/ids/2": map[string]interface{}{
+			"schemaType": schema.SchemaTypeJSON,
+			"schema":     `{"type": "object", "required": ["id"], "properties": {"id": {"type": "string"}}}`,
+		},
+		"/schemas/ids/3": map[string]interface{}{
+			"schema": `{"type": "string"}`,
+		},
+	})
+	defer closeServer()
+
+	t.Run("protobuf first message", func(t *testing.T) {
+		// Message index [0] followed by field 1 = "ann"
+		payload := wireFormatPayload(1, 0x00, 0x0a, 0x03, 'a', 'n', 'n')
+		res := d.deserializePayload(payload, "orders", proto.RecordValue)
+		assert.Equal(t, messageEncodingProtobuf, res.RecognizedEncoding)
+		assert.Equal(t, uint32(1), res.SchemaID)
+		assert.Equal(t, map[string]interface{}{"name": "ann"}, res.Object)
+	})
+
+	t.Run("protobuf nested message", func(t *testing.T) {
+		// Message indexes [1, 0] followed by field 1 = "x1"
+		payload := wireFormatPayload(1, 0x04, 0x02, 0x00, 0x0a, 0x02, 'x', '1')
+		res := d.deserializePayload(payload, "orders", proto.RecordValue)
+		assert.Equal(t, messageEncodingProtobuf, res.RecognizedEncoding)
+		assert.Equal(t, map[string]interface{}{"sku": "x1"}, res.Object)
+	})
+
+	t.Run("valid json schema payload", func(t *testing.T) {
+		payload := wireFormatPayload(2, []byte(`{"id": "order-1"}`)...)
+		res := d.deserializePayload(payload, "orders", proto.RecordValue)
+		assert.Equal(t, messageEncodingJSON, res.RecognizedEncoding)
+		assert.Equal(t, uint32(2), res.SchemaID)
+		assert.Empty(t, res.SchemaValidationError)
+		assert.Equal(t, map[string]interface{}{"id": "order-1"}, res.Object)
+	})
+
+	t.Run("invalid json schema payload", func(t *testing.T) {
+		payload := wireFormatPayload(2, []byte(`{"id": 5}`)...)
+		res := d.deserializePayload(payload, "orders", proto.RecordValue)
+		assert.Equal(t, messageEncodingJSON, res.RecognizedEncoding)
+		assert.Equal(t, uint32(2), res.SchemaID)
+		assert.Contains(t, res.SchemaValidationError, "/id")
+	})
+
+	t.Run("avro payload", func(t *testing.T) {
+		// Avro string "hi": zigzag length 2 (0x04) followed by the bytes
+		payload := wireFormatPayload(3, 0x04, 'h', 'i')
+		res := d.deserializePayload(payload, "orders", proto.RecordValue)
+		assert.Equal(t, messageEncodingAvro, res.RecognizedEncoding)
+		assert.Equal(t, uint32(3), res.AvroSchemaID)
+		assert.Equal(t, "hi", res.Object)
+	})
+
+	t.Run("unknown schema id", func(t *testing.T) {
+		payload := wireFormatPayload(4, 0x01, 0x02)
+		res := d.deserializePayload(payload, "orders", proto.RecordValue)
+		assert.Equal(t, uint32(0), res.SchemaID)
+	})
+}
+
+func TestDecoders_DetectSchemaRegistryPayloads(t *testing.T) {
+	d, closeServer := newSchemaRegistryDeserializer(t, map[string]interface{}{
+		"/schemas/ids/1": map[string]interface{}{"schemaType": schema.SchemaTypeProtobuf, "schema": `syntax = "proto3"; message Customer { string name = 1; }`},
+		"/schemas/ids/2": map[string]interface{}{"schemaType": schema.SchemaTypeJSON, "schema": `{"type": "object"}`},
+		"/schemas/ids/3": map[string]interface{}{"schema": `{"type": "string"}`},
+	})
+	defer closeServer()
+
+	// Registry payloads must only be detected by the decoder of their schema type, so that they are decoded once
+	tt := []struct {
+		schemaID uint32
+		expected MessageEncoding
+	}{
+		{1, messageEncodingProtobuf},
+		{2, messageEncodingJSON},
+		{3, messageEncodingAvro},
+		{4, ""},
+	}
+
+	for _, table := range tt {
+		payload := wireFormatPayload(table.schemaID, 0x00, 0x0a, 0x03, 'a', 'n', 'n')
+		detectedBy := make([]MessageEncoding, 0)
+		for _, encoding := range []MessageEncoding{messageEncodingJSON, messageEncodingAvro, messageEncodingProtobuf} {
+			decoder, exists := d.decoders.get(encoding)
+			require.True(t, exists)
+			if decoder.Detect(payload) {
+				detectedBy = append(detectedBy, encoding)
+			}
+		}
+
+		expected := []MessageEncoding{}
+		if table.expected != "" {
+			expected = append(expected, table.expected)
+		}
+		assert.Equal(t, expected, detectedBy, "unexpected decoders for schema id %d", table.schemaID)
+	}
+}
+
+func TestDeserializer_AvroObjectContainerFile(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:      buf,
+		Schema: `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}, {"name": "note", "type": ["null", "string"]}]}`,
+	})
+	require.NoError(t, err)
+	err = writer.Append([]interface{}{
+		map[string]interface{}{"id": "order-1", "note": nil},
+		map[string]interface{}{"id": "order-2", "note": goavro.Union("string", "gift")},
+	})
+	require.NoError(t, err)
+
+	d := newDeserializer(nil, nil, nil, nil)
+	res := d.deserializePayload(buf.Bytes(), "orders", proto.RecordValue)
+	assert.Equal(t, messageEncodingAvro, res.RecognizedEncoding)
+	assert.Len(t, res.Object, 2)
+	assert.JSONEq(t, `[{"id": "order-1", "note": null}, {"id": "order-2", "note": {"string": "gift"}}]`, string(res.Payload.Payload))
+
+	// Truncated container files are not recognized as Avro
+	res = d.deserializePayload(buf.Bytes()[:10], "orders", proto.RecordValue)
+	assert.NotEqual(t, messageEncodingAvro, res.RecognizedEncoding)
+}
+
+func TestDeserializer_ForcedEncodings(t *testing.T) {
+	topicEncodings, err := newTopicEncodings([]TopicEncodingConfig{
+		{TopicName: "orders", Key: "int64be"},
+		{TopicPattern: "audit-.*", Value: "text", Headers: "binary"},
+	})
+	require.NoError(t, err)
+	d := newDeserializer(nil, nil, nil, topicEncodings)
+
+	int64Key := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x30, 0x39}
+
+	t.Run("topic name override", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "orders", Key: int64Key, Value: []byte("123")}, recordEncodings{})
+		assert.Equal(t, messageEncodingInt64BE, rec.Key.RecognizedEncoding)
+		assert.Equal(t, int64(12345), rec.Key.Object)
+		assert.Equal(t, "12345", string(rec.Key.Payload.Payload))
+		// Values of the topic are still detected
+		assert.Equal(t, messageEncodingText, rec.Value.RecognizedEncoding)
+	})
+
+	t.Run("topic pattern override", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{
+			Topic:   "audit-log",
+			Value:   []byte(`{"id": 1}`),
+			Headers: []kgo.RecordHeader{{Key: "trace", Value: []byte("abc")}},
+		}, recordEncodings{})
+		assert.Equal(t, messageEncodingText, rec.Value.RecognizedEncoding)
+		assert.Equal(t, `{"id": 1}`, rec.Value.Object)
+		assert.Equal(t, messageEncodingBinary, rec.Headers["trace"].RecognizedEncoding)
+
+		rec = d.DeserializeRecord(&kgo.Record{Topic: "audit-log-archive", Value: []byte(`{"id": 1}`)}, recordEncodings{})
+		assert.Equal(t, messageEncodingText, rec.Value.RecognizedEncoding)
+
+		rec = d.DeserializeRecord(&kgo.Record{Topic: "old-audit-log", Value: []byte(`{"id": 1}`)}, recordEncodings{})
+		assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding, "pattern must match the whole topic name")
+	})
+
+	t.Run("requested encodings take precedence", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "orders", Key: []byte("42"), Value: []byte("42")}, recordEncodings{
+			Key:   messageEncodingJSON,
+			Value: messageEncodingJSON,
+		})
+		assert.Equal(t, messageEncodingJSON, rec.Key.RecognizedEncoding)
+		assert.Equal(t, float64(42), rec.Key.Object)
+		assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding)
+	})
+
+	t.Run("decode errors are reported", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "orders", Key: []byte("not-a-number")}, recordEncodings{
+			Value: messageEncodingProtobuf,
+		})
+		assert.Equal(t, messageEncodingBinary, rec.Key.RecognizedEncoding)
+		assert.Contains(t, rec.Key.DecodeError, "failed to decode payload as int64be")
+		assert.Equal(t, messageEncodingNone, rec.Value.RecognizedEncoding, "empty payloads are not decoded")
+
+		rec = d.DeserializeRecord(&kgo.Record{Topic: "payments", Value: []byte{0x0a, 0x01}}, recordEncodings{
+			Value: messageEncodingProtobuf,
+		})
+		assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+		assert.NotEmpty(t, rec.Value.DecodeError)
+	})
+}
+
+func TestTopicEncodingConfig_Validate(t *testing.T) {
+	valid := TopicEncodingConfig{TopicPattern: "orders-.*", Key: "int64be", Value: "avro"}
+	assert.NoError(t, valid.Validate())
+
+	for _, cfg := range []TopicEncodingConfig{
+		{Key: "json"},
+		{TopicName: "orders", TopicPattern: "orders"},
+		{TopicPattern: "orders-[", Key: "json"},
+	} {
+		assert.Error(t, cfg.Validate(), "expected config %+v to be invalid", cfg)
+	}
+}
+
+// yamlDecoder is a minimal custom decoder, which doesn't parse the YAML document.
+type yamlDecoder struct{}
+
+func (d *yamlDecoder) Encoding() MessageEncoding {
+	return "yaml"
+}
+
+func (d *yamlDecoder) Priority() int {
+	return 150
+}
+
+func (d *yamlDecoder) Detect(payload []byte) bool {
+	return bytes.HasPrefix(payload, []byte("---\n"))
+}
+
+func (d *yamlDecoder) Decode(payload []byte, _ string, _ proto.RecordPropertyType) (*DeserializedPayload, error) {
+	document := bytes.TrimPrefix(payload, []byte("---\n"))
+	return newJSONPayload("yaml", map[string]interface{}{"document": string(document)}, payload)
+}
+
+func TestDeserializer_RegisterDecoder(t *testing.T) {
+	topicEncodings, err := newTopicEncodings([]TopicEncodingConfig{{TopicName: "orders", Value: "yaml"}})
+	require.NoError(t, err)
+	d := newDeserializer(nil, nil, nil, topicEncodings)
+	assert.Error(t, d.validateTopicEncodings(), "expected unknown encoding to be invalid")
+	assert.False(t, d.isSupportedEncoding("yaml"))
+
+	require.NoError(t, d.RegisterDecoder(&yamlDecoder{}))
+	assert.Error(t, d.RegisterDecoder(&yamlDecoder{}), "expected an error for duplicate encodings")
+	assert.NoError(t, d.validateTopicEncodings())
+	assert.True(t, d.isSupportedEncoding("yaml"))
+
+	// Detected by priority, the text decoder would accept the payload as well
+	res := d.deserializePayload([]byte("---\nid: 1"), "customers", proto.RecordValue)
+	assert.Equal(t, MessageEncoding("yaml"), res.RecognizedEncoding)
+	assert.Equal(t, map[string]interface{}{"document": "id: 1"}, res.Object)
+
+	rec := d.DeserializeRecord(&kgo.Record{Topic: "orders", Value: []byte("id: 1")}, recordEncodings{})
+	assert.Equal(t, MessageEncoding("yaml"), rec.Value.RecognizedEncoding)
+	assert.Empty(t, rec.Value.DecodeError)
+}
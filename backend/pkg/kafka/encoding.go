@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"regexp"
+)
+
+// IsSupportedEncoding returns whether the given encoding can be forced for decoding message keys, values or headers.
+func (s *Service) IsSupportedEncoding(encoding string) bool {
+	return s.Deserializer.isSupportedEncoding(MessageEncoding(encoding))
+}
+
+// recordEncodings are the encodings that shall be used for decoding a record's key, value and headers. Empty
+// encodings will be detected.
+type recordEncodings struct {
+	Key     MessageEncoding
+	Value   MessageEncoding
+	Headers MessageEncoding
+}
+
+type topicEncoding struct {
+	topicName    string
+	topicPattern *regexp.Regexp
+	encodings    recordEncodings
+}
+
+// topicEncodings are the compiled topic encoding configs. The first config that matches a topic is used.
+type topicEncodings []topicEncoding
+
+// internalTopicEncodings select the decoders for the internal topics of Kafka, Kafka Connect and the schema registry
+// by their default names. They are appended to the configured topic encodings, so that they can be overridden, e.g.
+// Connect's topics can be configured with a different pattern.
+var internalTopicEncodings = []TopicEncodingConfig{
+	{TopicName: "__consumer_offsets", Key: string(messageEncodingConsumerOffsets), Value: string(messageEncodingConsumerOffsets)},
+	{TopicName: "__transaction_state", Key: string(messageEncodingTransactionState), Value: string(messageEncodingTransactionState)},
+	{TopicName: "_schemas", Key: string(messageEncodingSchemas), Value: string(messageEncodingSchemas)},
+	{TopicPattern: ".*connect-offsets", Key: string(messageEncodingConnectOffsets), Value: string(messageEncodingConnectOffsets)},
+	{TopicPattern: ".*connect-configs", Key: string(messageEncodingConnectConfigs), Value: string(messageEncodingConnectConfigs)},
+	{TopicPattern: ".*connect-status", Key: string(messageEncodingConnectStatus), Value: string(messageEncodingConnectStatus)},
+}
+
+// newTopicEncodings compiles the configured topic encodings, followed by the encodings for internal topics.
+func newTopicEncodings(cfgs []TopicEncodingConfig) (topicEncodings, error) {
+	cfgs = append(cfgs[:len(cfgs):len(cfgs)], internalTopicEncodings...)
+	res := make(topicEncodings, len(cfgs))
+	for i, cfg := range cfgs {
+		res[i] = topicEncoding{
+			topicName: cfg.TopicName,
+			encodings: recordEncodings{
+				Key:     MessageEncoding(cfg.Key),
+				Value:   MessageEncoding(cfg.Value),
+				Headers: MessageEncoding(cfg.Headers),
+			},
+		}
+		if cfg.TopicPattern != "" {
+			regex, err := compileTopicPattern(cfg.TopicPattern)
+			if err != nil {
+				return nil, err
+			}
+			res[i].topicPattern = regex
+		}
+	}
+
+	return res, nil
+}
+
+func (t topicEncodings) encodingsForTopic(topicName string) recordEncodings {
+	for _, encoding := range t {
+		if encoding.topicName == topicName || (encoding.topicPattern != nil && encoding.topicPattern.MatchString(topicName)) {
+			return encoding.encodings
+		}
+	}
+
+	return recordEncodings{}
+}
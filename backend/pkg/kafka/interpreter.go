@@ -0,0 +1,212 @@
+package kafka
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/interpreter"
+	"github.com/dop251/goja"
+)
+
+// findFunctionProgram contains the compiled find() and findAll() helpers, which are loaded into every VM once.
+var findFunctionProgram = goja.MustCompile("find_function.js", interpreter.FindFunction, false)
+
+// hardenGlobalsProgram freezes all global variables along with all objects that are reachable from them (e.g. the
+// built-in prototypes and the find helpers), before the VM is put into the pool. Otherwise a filter could overwrite a
+// global such as find() or mutate a built-in prototype and that change would leak into other users' searches. New
+// global variables can still be declared, they are removed by reset(). As a side effect assigning a property that
+// exists on a frozen prototype (e.g. obj.toString = ...) is ignored.
+var hardenGlobalsProgram = goja.MustCompile("harden_globals.js", `(function(global) {
+	var frozen = new Set([global]);
+	function deepFreeze(obj) {
+		if (obj === null || (typeof obj !== "object" && typeof obj !== "function") || frozen.has(obj)) {
+			return;
+		}
+		frozen.add(obj);
+		Object.freeze(obj);
+		Reflect.ownKeys(obj).forEach(function(key) {
+			var descriptor = Object.getOwnPropertyDescriptor(obj, key);
+			deepFreeze(descriptor.value);
+			deepFreeze(descriptor.get);
+			deepFreeze(descriptor.set);
+		});
+		deepFreeze(Object.getPrototypeOf(obj));
+	}
+
+	deepFreeze(Object.getPrototypeOf(global));
+	Object.getOwnPropertyNames(global).forEach(function(name) {
+		var descriptor = Object.getOwnPropertyDescriptor(global, name);
+		deepFreeze(descriptor.value);
+		deepFreeze(descriptor.get);
+		deepFreeze(descriptor.set);
+		if ("value" in descriptor) {
+			descriptor.writable = false;
+		}
+		descriptor.configurable = false;
+		Object.defineProperty(global, name, descriptor);
+	});
+})(this)`, false)
+
+// interpreterVMPool contains JavaScript VMs that have the find helpers loaded already. VMs are shared across all
+// workers and requests, because setting up a new VM is expensive compared to evaluating the filter code.
+var interpreterVMPool = sync.Pool{
+	New: func() interface{} {
+		return newInterpreterVM()
+	},
+}
+
+// interpreterVM is a pooled JavaScript VM along with the global variables that exist after its initialization.
+type interpreterVM struct {
+	vm          *goja.Runtime
+	baseGlobals map[string]struct{}
+}
+
+func newInterpreterVM() *interpreterVM {
+	vm := goja.New()
+	_, err := vm.RunProgram(findFunctionProgram)
+	if err != nil {
+		// The find function is a constant which is covered by tests, so this can not happen at runtime
+		panic(fmt.Errorf("failed to load find function: %w", err))
+	}
+	_, err = vm.RunProgram(hardenGlobalsProgram)
+	if err != nil {
+		panic(fmt.Errorf("failed to harden global variables: %w", err))
+	}
+
+	baseGlobals := make(map[string]struct{})
+	for _, key := range vm.GlobalObject().Keys() {
+		baseGlobals[key] = struct{}{}
+	}
+
+	return &interpreterVM{
+		vm:          vm,
+		baseGlobals: baseGlobals,
+	}
+}
+
+// reset removes all global variables which have been added since the VM has been initialized (e.g. the filter
+// function, the message properties or variables that have been implicitly declared by the filter code), so that
+// no state leaks into the next request which uses this VM. The base globals can't be changed by the filter code,
+// because they have been frozen by the hardenGlobalsProgram.
+func (i *interpreterVM) reset() {
+	for _, key := range i.vm.GlobalObject().Keys() {
+		if _, isBaseGlobal := i.baseGlobals[key]; !isBaseGlobal {
+			_ = i.vm.GlobalObject().Delete(key)
+		}
+	}
+	i.vm.ClearInterrupt()
+}
+
+// compileFilterCode compiles the user's filter code once, so that the resulting program can be run by all VMs.
+// It returns nil if there is no filter code.
+func compileFilterCode(interpreterCode string) (*goja.Program, error) {
+	if interpreterCode == "" {
+		return nil, nil
+	}
+
+	// The function is not declared with var, so that it can be deleted when the VM is returned to the pool
+	code := fmt.Sprintf(`isMessageOk = function() {%s}`, interpreterCode)
+	program, err := goja.Compile("filter.js", code, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile given interpreter code: %w", err)
+	}
+
+	return program, nil
+}
+
+type isMessageOkFunc = func(args interpreterArguments) (bool, error)
+
+// setupInterpreter takes a VM from the pool and loads the compiled filter program. It returns a wrapper function
+// which accepts all Kafka message properties (offset, key, value, ...) and returns true (message shall be returned)
+// or false (message shall be filtered). The returned release function must be called once the interpreter is no
+// longer used, so that the VM is returned to the pool. The wrapper function must not be called concurrently.
+func setupInterpreter(filterProgram *goja.Program, timeout time.Duration) (isMessageOkFunc, func(), error) {
+	// In case there's no code for the interpreter let's return a dummy function which always allows all messages
+	if filterProgram == nil {
+		return func(args interpreterArguments) (bool, error) { return true, nil }, func() {}, nil
+	}
+
+	pooledVM := interpreterVMPool.Get().(*interpreterVM)
+	vm := pooledVM.vm
+	_, err := vm.RunProgram(filterProgram)
+	if err != nil {
+		pooledVM.reset()
+		interpreterVMPool.Put(pooledVM)
+		return nil, nil, fmt.Errorf("failed to load interpreter code: %w", err)
+	}
+
+	watchdog := newInterpreterWatchdog(vm, timeout)
+	go watchdog.run()
+
+	// Returning a proper error is important because we want to stop the consumer for this partition
+	// if we exceed the execution timeout.
+	isMessageOk := func(args interpreterArguments) (bool, error) {
+		vm.Set("partitionID", args.PartitionID)
+		vm.Set("offset", args.Offset)
+		vm.Set("timestamp", args.Timestamp)
+		vm.Set("key", args.Key)
+		vm.Set("value", args.Value)
+		vm.Set("headers", args.HeadersByKey)
+
+		// Call Javascript function and check if it could be evaluated and whether it returned true or false
+		watchdog.started <- struct{}{}
+		isOkRes, err := vm.RunString("isMessageOk()")
+		watchdog.finished <- struct{}{}
+		vm.ClearInterrupt() // The watchdog might have interrupted the VM right after the evaluation has returned
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate javascript code: %w", err)
+		}
+
+		return isOkRes.ToBoolean(), nil
+	}
+
+	release := func() {
+		close(watchdog.stop)
+		pooledVM.reset()
+		interpreterVMPool.Put(pooledVM)
+	}
+
+	return isMessageOk, release, nil
+}
+
+// interpreterWatchdog interrupts the VM if the evaluation of a single message takes longer than the timeout. Each
+// interpreter has its own watchdog go routine, instead of starting a new timer go routine for each message.
+type interpreterWatchdog struct {
+	vm      *goja.Runtime
+	timeout time.Duration
+
+	started  chan struct{}
+	finished chan struct{}
+	stop     chan struct{}
+}
+
+func newInterpreterWatchdog(vm *goja.Runtime, timeout time.Duration) *interpreterWatchdog {
+	return &interpreterWatchdog{
+		vm:       vm,
+		timeout:  timeout,
+		started:  make(chan struct{}),
+		finished: make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+}
+
+func (w *interpreterWatchdog) run() {
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.started:
+		}
+
+		timer := time.NewTimer(w.timeout)
+		select {
+		case <-timer.C:
+			w.vm.Interrupt(fmt.Sprintf("timeout after %v", w.timeout))
+			// Wait until the evaluation has returned, so that the interrupt does not affect the next message
+			<-w.finished
+		case <-w.finished:
+			timer.Stop()
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/interpreter"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSyntheticInterpreterArguments(i int) interpreterArguments {
+	return interpreterArguments{
+		PartitionID: int32(i % 12),
+		Offset:      int64(i),
+		Timestamp:   time.Unix(int64(i), 0),
+		Key:         fmt.Sprintf("customer-%d", i%1000),
+		Value: map[string]interface{}{
+			"orderId": i,
+			"status":  "CREATED",
+			"customer": map[string]interface{}{
+				"id":   i % 1000,
+				"tier": "gold",
+			},
+		},
+		HeadersByKey: map[string]interface{}{"source": "benchmark"},
+	}
+}
+
+func TestSetupInterpreter(t *testing.T) {
+	program, err := compileFilterCode(`return value.find("tier") == "gold" && offset % 2 == 0`)
+	require.NoError(t, err)
+
+	isMessageOk, release, err := setupInterpreter(program, time.Second)
+	require.NoError(t, err)
+	defer release()
+
+	isOk, err := isMessageOk(newSyntheticInterpreterArguments(2))
+	assert.NoError(t, err)
+	assert.True(t, isOk)
+
+	isOk, err = isMessageOk(newSyntheticInterpreterArguments(3))
+	assert.NoError(t, err)
+	assert.False(t, isOk)
+}
+
+func TestSetupInterpreter_NoFilterCode(t *testing.T) {
+	program, err := compileFilterCode("")
+	require.NoError(t, err)
+	assert.Nil(t, program)
+
+	isMessageOk, release, err := setupInterpreter(program, time.Second)
+	require.NoError(t, err)
+	defer release()
+
+	isOk, err := isMessageOk(newSyntheticInterpreterArguments(1))
+	assert.NoError(t, err)
+	assert.True(t, isOk)
+}
+
+func TestSetupInterpreter_InvalidCode(t *testing.T) {
+	_, err := compileFilterCode(`return value ==`)
+	assert.Error(t, err)
+}
+
+func TestSetupInterpreter_Timeout(t *testing.T) {
+	program, err := compileFilterCode(`if (offset == 1) { while (true) {} } return true`)
+	require.NoError(t, err)
+
+	isMessageOk, release, err := setupInterpreter(program, 50*time.Millisecond)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = isMessageOk(newSyntheticInterpreterArguments(1))
+	assert.Error(t, err)
+
+	// The interrupt must not affect the evaluation of subsequent messages
+	isOk, err := isMessageOk(newSyntheticInterpreterArguments(2))
+	assert.NoError(t, err)
+	assert.True(t, isOk)
+}
+
+func TestSetupInterpreter_NoStateLeaksBetweenRequests(t *testing.T) {
+	leakingProgram, err := compileFilterCode(`leaked = true; return true`)
+	require.NoError(t, err)
+	checkingProgram, err := compileFilterCode(`return typeof leaked === "undefined" && typeof find === "function"`)
+	require.NoError(t, err)
+
+	isMessageOk, release, err := setupInterpreter(leakingProgram, time.Second)
+	require.NoError(t, err)
+	_, err = isMessageOk(newSyntheticInterpreterArguments(1))
+	require.NoError(t, err)
+	release()
+
+	// VMs are pooled, hence the checking program will most likely run on the same VM
+	isMessageOk, release, err = setupInterpreter(checkingProgram, time.Second)
+	require.NoError(t, err)
+	defer release()
+	isOk, err := isMessageOk(newSyntheticInterpreterArguments(1))
+	assert.NoError(t, err)
+	assert.True(t, isOk)
+}
+
+func TestSetupInterpreter_BaseGlobalsCanNotBeChanged(t *testing.T) {
+	maliciousProgram, err := compileFilterCode(`
+		find = function() { return "hijacked" };
+		delete findAll;
+		Object.prototype.find = find;
+		Array.prototype.map = function() { return [] };
+		String.prototype.toUpperCase = function() { return "" };
+		JSON.stringify = function() { return "" };
+		Object.defineProperty(this, "findGeneric", {value: null});
+		return true`)
+	require.NoError(t, err)
+	normalProgram, err := compileFilterCode(`return value.find("tier") == "gold" &&
+		typeof findAll === "function" &&
+		[1, 2].map(function(x) { return x * 2 })[1] == 4 &&
+		key.toUpperCase() == "CUSTOMER-2" &&
+		JSON.stringify({a: 1}) == '{"a":1}'`)
+	require.NoError(t, err)
+
+	isMessageOk, release, err := setupInterpreter(maliciousProgram, time.Second)
+	require.NoError(t, err)
+	_, err = isMessageOk(newSyntheticInterpreterArguments(2))
+	// Defining a property on the global object must fail, all other changes are silently ignored
+	assert.Error(t, err)
+	release()
+
+	// VMs are pooled, hence the normal program will most likely run on the same VM
+	isMessageOk, release, err = setupInterpreter(normalProgram, time.Second)
+	require.NoError(t, err)
+	defer release()
+	isOk, err := isMessageOk(newSyntheticInterpreterArguments(2))
+	assert.NoError(t, err)
+	assert.True(t, isOk)
+}
+
+// legacySetupInterpreter is the former implementation, which creates a new VM per worker and request and starts
+// a timer go routine for each message. It is only used as baseline for the benchmarks.
+func legacySetupInterpreter(interpreterCode string, timeout time.Duration) (isMessageOkFunc, error) {
+	vm := goja.New()
+	_, err := vm.RunString(fmt.Sprintf(`var isMessageOk = function() {%s}`, interpreterCode))
+	if err != nil {
+		return nil, err
+	}
+	_, err = vm.RunString(interpreter.FindFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(args interpreterArguments) (bool, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			timer := time.NewTimer(timeout)
+			select {
+			case <-timer.C:
+				vm.Interrupt("timeout")
+			case <-ctx.Done():
+			}
+		}()
+
+		vm.Set("partitionID", args.PartitionID)
+		vm.Set("offset", args.Offset)
+		vm.Set("timestamp", args.Timestamp)
+		vm.Set("key", args.Key)
+		vm.Set("value", args.Value)
+		vm.Set("headers", args.HeadersByKey)
+		isOkRes, err := vm.RunString("isMessageOk()")
+		if err != nil {
+			return false, err
+		}
+		return isOkRes.ToBoolean(), nil
+	}, nil
+}
+
+const (
+	benchmarkRecordCount = 1000000
+	benchmarkWorkerCount = 4
+	benchmarkFilterCode  = `return value.customer.tier == "gold" && key.charAt(key.length - 1) == "7"`
+)
+
+// runInterpreterBenchmark evaluates the filter for the synthetic records using the given number of workers, just like
+// a message search does. setup is called once per worker.
+func runInterpreterBenchmark(b *testing.B, setup func() (isMessageOkFunc, func(), error)) {
+	b.ReportAllocs()
+	start := time.Now()
+	for n := 0; n < b.N; n++ {
+		jobs := make(chan int, 100)
+		wg := sync.WaitGroup{}
+		for i := 0; i < benchmarkWorkerCount; i++ {
+			isMessageOk, release, err := setup()
+			if err != nil {
+				b.Fatal(err)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer release()
+				for record := range jobs {
+					if _, err := isMessageOk(newSyntheticInterpreterArguments(record)); err != nil {
+						b.Error(err)
+					}
+				}
+			}()
+		}
+
+		for record := 0; record < benchmarkRecordCount; record++ {
+			jobs <- record
+		}
+		close(jobs)
+		wg.Wait()
+	}
+	b.ReportMetric(float64(benchmarkRecordCount*b.N)/time.Since(start).Seconds(), "records/s")
+}
+
+func BenchmarkInterpreter_Legacy(b *testing.B) {
+	runInterpreterBenchmark(b, func() (isMessageOkFunc, func(), error) {
+		isMessageOk, err := legacySetupInterpreter(benchmarkFilterCode, 400*time.Millisecond)
+		return isMessageOk, func() {}, err
+	})
+}
+
+func BenchmarkInterpreter_Pooled(b *testing.B) {
+	program, err := compileFilterCode(benchmarkFilterCode)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runInterpreterBenchmark(b, func() (isMessageOkFunc, func(), error) {
+		return setupInterpreter(program, 400*time.Millisecond)
+	})
+}
+
+// BenchmarkInterpreterSetup_Legacy and BenchmarkInterpreterSetup_Pooled measure the overhead per message search
+// and worker, which is paid regardless of the number of consumed records.
+func BenchmarkInterpreterSetup_Legacy(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		_, err := legacySetupInterpreter(benchmarkFilterCode, 400*time.Millisecond)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInterpreterSetup_Pooled(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		program, err := compileFilterCode(benchmarkFilterCode)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, release, err := setupInterpreter(program, 400*time.Millisecond)
+		if err != nil {
+			b.Fatal(err)
+		}
+		release()
+	}
+}
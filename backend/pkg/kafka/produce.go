@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	// PartitionerStickyKey hashes the record key to choose a partition. Records without a key are sticky partitioned.
+	PartitionerStickyKey = "stickyKey"
+	// PartitionerSticky produces to the same partition until a batch is full, regardless of the record key.
+	PartitionerSticky = "sticky"
+	// PartitionerRoundRobin distributes the records one by one across all partitions.
+	PartitionerRoundRobin = "roundRobin"
+)
+
+// ProduceRecordsOptions configure the producer that will be used for a single produce request.
+type ProduceRecordsOptions struct {
+	// Partitioner is used for all records which do not have an explicit partition set (partition -1)
+	Partitioner string
+
+	// Compression is the compression codec for the produced batches: uncompressed, gzip, snappy, lz4 or zstd
+	Compression string
+}
+
+// ProduceRecordResult is the result of a single produced record. Either the error is set or the partition and offset
+// the record has been written to.
+type ProduceRecordResult struct {
+	PartitionID int32
+	Offset      int64
+	Error       error
+}
+
+// ProduceRecords produces the given records and waits until all of them have been acknowledged. The results are
+// returned in the same order as the passed records. Records which have their partition set to -1 will be assigned
+// to a partition by the configured partitioner.
+func (s *Service) ProduceRecords(ctx context.Context, records []*kgo.Record, opts ProduceRecordsOptions) ([]ProduceRecordResult, error) {
+	partitioner, err := newPartitioner(opts.Partitioner)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := compressionCodecFromName(opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	// We create a new client for each produce request, because the partitioner and compression are client options
+	client, err := s.NewKgoClient(
+		kgo.RecordPartitioner(partitioner),
+		kgo.BatchCompression(compression),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new kafka client: %w", err)
+	}
+	defer client.Close()
+
+	results := make([]ProduceRecordResult, len(records))
+	wg := sync.WaitGroup{}
+	for i, record := range records {
+		wg.Add(1)
+		index := i
+		promise := func(r *kgo.Record, err error) {
+			defer wg.Done()
+			if err != nil {
+				results[index] = ProduceRecordResult{PartitionID: r.Partition, Offset: -1, Error: err}
+				return
+			}
+			results[index] = ProduceRecordResult{PartitionID: r.Partition, Offset: r.Offset}
+		}
+
+		err := client.Produce(ctx, record, promise)
+		if err != nil {
+			// The promise will not be called if the record could not be buffered
+			results[index] = ProduceRecordResult{PartitionID: record.Partition, Offset: -1, Error: err}
+			wg.Done()
+		}
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func compressionCodecFromName(name string) (kgo.CompressionCodec, error) {
+	switch name {
+	case "", "uncompressed":
+		return kgo.NoCompression(), nil
+	case "gzip":
+		return kgo.GzipCompression(), nil
+	case "snappy":
+		return kgo.SnappyCompression(), nil
+	case "lz4":
+		return kgo.Lz4Compression(), nil
+	case "zstd":
+		return kgo.ZstdCompression(), nil
+	default:
+		return kgo.CompressionCodec{}, fmt.Errorf("unknown compression type '%v'", name)
+	}
+}
+
+func newPartitioner(name string) (kgo.Partitioner, error) {
+	var fallback kgo.Partitioner
+	switch name {
+	case "", PartitionerStickyKey:
+		fallback = kgo.StickyKeyPartitioner(nil)
+	case PartitionerSticky:
+		fallback = kgo.StickyPartitioner()
+	case PartitionerRoundRobin:
+		fallback = &roundRobinPartitioner{}
+	default:
+		return nil, fmt.Errorf("unknown partitioner '%v'", name)
+	}
+
+	return &manualPartitioner{fallback: fallback}, nil
+}
+
+// manualPartitioner respects the partition that has been set on a record. If a record's partition is set to -1 the
+// fallback partitioner will be asked to choose a partition.
+type manualPartitioner struct {
+	fallback kgo.Partitioner
+}
+
+func (p *manualPartitioner) ForTopic(topic string) kgo.TopicPartitioner {
+	return &manualTopicPartitioner{TopicPartitioner: p.fallback.ForTopic(topic)}
+}
+
+type manualTopicPartitioner struct {
+	kgo.TopicPartitioner
+}
+
+func (p *manualTopicPartitioner) RequiresConsistency(r *kgo.Record) bool {
+	return r.Partition >= 0 || p.TopicPartitioner.RequiresConsistency(r)
+}
+
+func (p *manualTopicPartitioner) Partition(r *kgo.Record, n int) int {
+	if r.Partition >= 0 {
+		return int(r.Partition)
+	}
+	return p.TopicPartitioner.Partition(r, n)
+}
+
+// roundRobinPartitioner assigns each record to the next partition.
+type roundRobinPartitioner struct{}
+
+func (*roundRobinPartitioner) ForTopic(string) kgo.TopicPartitioner {
+	return &roundRobinTopicPartitioner{}
+}
+
+type roundRobinTopicPartitioner struct {
+	counter uint64
+}
+
+func (*roundRobinTopicPartitioner) OnNewBatch()                          {}
+func (*roundRobinTopicPartitioner) RequiresConsistency(*kgo.Record) bool { return false }
+func (p *roundRobinTopicPartitioner) Partition(_ *kgo.Record, n int) int {
+	next := atomic.AddUint64(&p.counter, 1) - 1
+	return int(next % uint64(n))
+}
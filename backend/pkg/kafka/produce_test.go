@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestCompressionCodecFromName(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected kgo.CompressionCodec
+	}{
+		{"", kgo.NoCompression()},
+		{"uncompressed", kgo.NoCompression()},
+		{"gzip", kgo.GzipCompression()},
+		{"snappy", kgo.SnappyCompression()},
+		{"lz4", kgo.Lz4Compression()},
+		{"zstd", kgo.ZstdCompression()},
+	}
+
+	for _, table := range tt {
+		codec, err := compressionCodecFromName(table.name)
+		require.NoError(t, err, "unexpected error for compression '%v'", table.name)
+		assert.Equal(t, table.expected, codec, "unexpected codec for compression '%v'", table.name)
+	}
+
+	_, err := compressionCodecFromName("brotli")
+	assert.Error(t, err)
+}
+
+func TestNewPartitioner(t *testing.T) {
+	tt := []struct {
+		name string
+
+		// partitions are the expected partitions for records without partition and key, produced to 3 partitions.
+		// Nil means that the records may end up on any partition.
+		partitions []int
+	}{
+		{"", nil},
+		{PartitionerStickyKey, nil},
+		{PartitionerSticky, nil},
+		{PartitionerRoundRobin, []int{0, 1, 2, 0, 1}},
+	}
+
+	for _, table := range tt {
+		t.Run(table.name, func(t *testing.T) {
+			partitioner, err := newPartitioner(table.name)
+			require.NoError(t, err)
+			topicPartitioner := partitioner.ForTopic("orders")
+
+			// Records with a partition are always produced to that partition
+			record := &kgo.Record{Topic: "orders", Partition: 2, Key: []byte("order-1")}
+			assert.True(t, topicPartitioner.RequiresConsistency(record))
+			assert.Equal(t, 2, topicPartitioner.Partition(record, 3))
+
+			for i, expected := range table.partitions {
+				record := &kgo.Record{Topic: "orders", Partition: -1}
+				assert.Equal(t, expected, topicPartitioner.Partition(record, 3), "unexpected partition for record %d", i)
+			}
+		})
+	}
+
+	_, err := newPartitioner("random")
+	assert.Error(t, err)
+}
+
+func TestNewPartitioner_StickyKey(t *testing.T) {
+	partitioner, err := newPartitioner(PartitionerStickyKey)
+	require.NoError(t, err)
+	topicPartitioner := partitioner.ForTopic("orders")
+
+	// Records with the same key must be produced to the same partition, so that their order is kept
+	first := &kgo.Record{Topic: "orders", Partition: -1, Key: []byte("customer-42")}
+	second := &kgo.Record{Topic: "orders", Partition: -1, Key: []byte("customer-42")}
+	assert.True(t, topicPartitioner.RequiresConsistency(first))
+	assert.Equal(t, topicPartitioner.Partition(first, 12), topicPartitioner.Partition(second, 12))
+
+	// Keys are ignored by the sticky partitioner
+	partitioner, err = newPartitioner(PartitionerSticky)
+	require.NoError(t, err)
+	assert.False(t, partitioner.ForTopic("orders").RequiresConsistency(first))
+}
@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+)
+
+// FetchRecordTimestamps consumes the first record at or after each of the given offsets (topic -> partitionID ->
+// offset) and returns its timestamp. Records may be missing at the exact offset, because they have been removed by
+// compaction or retention or because the offset points to a transaction marker. In these cases the timestamp of the
+// next available record is returned. Partitions for which no record could be fetched before the high water mark has
+// been reached or before the context is done are not part of the returned map.
+func (s *Service) FetchRecordTimestamps(ctx context.Context, startOffsets map[string]map[int32]int64) (map[string]map[int32]time.Time, error) {
+	client, err := s.consumerPool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire kafka client: %w", err)
+	}
+	defer s.consumerPool.Release(client)
+
+	partitionOffsets := make(map[string]map[int32]kgo.Offset)
+	remainingPartitions := 0
+	for topic, partitions := range startOffsets {
+		partitionOffsets[topic] = make(map[int32]kgo.Offset, len(partitions))
+		for partitionID, offset := range partitions {
+			partitionOffsets[topic][partitionID] = kgo.NewOffset().At(offset)
+			remainingPartitions++
+		}
+	}
+	client.AssignPartitions(
+		kgo.ConsumePartitions(partitionOffsets),
+	)
+
+	timestamps := make(map[string]map[int32]time.Time)
+	missingPartitions := make(map[string]map[int32]struct{})
+	for remainingPartitions > 0 {
+		fetches := client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		for _, err := range fetches.Errors() {
+			s.Logger.Debug("errors while fetching record timestamps",
+				zap.String("topic_name", err.Topic),
+				zap.Int32("partition", err.Partition),
+				zap.Error(err.Err))
+		}
+
+		for _, fetch := range fetches {
+			for _, topic := range fetch.Topics {
+				for _, partition := range topic.Partitions {
+					startOffset, exists := startOffsets[topic.Topic][partition.Partition]
+					if !exists || partition.Err != nil {
+						continue
+					}
+					if _, isDone := timestamps[topic.Topic][partition.Partition]; isDone {
+						continue
+					}
+					if _, isDone := missingPartitions[topic.Topic][partition.Partition]; isDone {
+						continue
+					}
+
+					record := firstRecordAtOrAfter(partition.Records, startOffset)
+					if record != nil {
+						if _, ok := timestamps[topic.Topic]; !ok {
+							timestamps[topic.Topic] = make(map[int32]time.Time)
+						}
+						timestamps[topic.Topic][partition.Partition] = record.Timestamp
+						remainingPartitions--
+						continue
+					}
+
+					// The start offset is the last offset before the high water mark, but no record has been returned.
+					// Hence it is a transaction marker and no record will be returned until new records are produced.
+					if len(partition.Records) == 0 && startOffset >= partition.HighWatermark-1 {
+						if _, ok := missingPartitions[topic.Topic]; !ok {
+							missingPartitions[topic.Topic] = make(map[int32]struct{})
+						}
+						missingPartitions[topic.Topic][partition.Partition] = struct{}{}
+						remainingPartitions--
+					}
+				}
+			}
+		}
+	}
+
+	return timestamps, nil
+}
+
+// firstRecordAtOrAfter returns the first of the fetched records whose offset is at or after the start offset. Pooled
+// clients might still return records from a prior assignment, these must be skipped.
+func firstRecordAtOrAfter(records []*kgo.Record, startOffset int64) *kgo.Record {
+	for _, record := range records {
+		if record.Offset >= startOffset {
+			return record
+		}
+	}
+	return nil
+}
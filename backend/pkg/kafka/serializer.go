@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+)
+
+// serializer converts user provided payloads (text, json, avro, ...) into the byte arrays which will be produced to Kafka.
+// It is the counterpart to the deserializer.
+type serializer struct {
+	SchemaService *schema.Service
+	ProtoService  *proto.Service
+}
+
+// ProducePayload is a single payload (e.g. a record key or value) that shall be serialized before it's produced.
+type ProducePayload struct {
+	// Encoding determines how Data will be interpreted. Supported encodings are: none, text, json, binary (base64
+	// encoded data), avro (JSON data which will be encoded using the given schema id) and protobuf (JSON data which
+	// will be encoded using the proto type that is mapped to the topic).
+	Encoding string
+
+	Data string
+
+	// SchemaID is the schema registry's schema id which is used to encode avro payloads
+	SchemaID uint32
+}
+
+// SerializePayload returns the binary representation of the given payload. The topic name and record type are only
+// considered for protobuf payloads, where they are used to look up the configured proto type.
+func (s *serializer) SerializePayload(payload ProducePayload, topicName string, recordType proto.RecordPropertyType) ([]byte, error) {
+	switch MessageEncoding(payload.Encoding) {
+	case messageEncodingNone:
+		return nil, nil
+	case messageEncodingText:
+		return []byte(payload.Data), nil
+	case messageEncodingJSON:
+		if !json.Valid([]byte(payload.Data)) {
+			return nil, fmt.Errorf("payload is not valid JSON")
+		}
+		return []byte(payload.Data), nil
+	case messageEncodingBinary:
+		decoded, err := base64.StdEncoding.DecodeString(payload.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 payload: %w", err)
+		}
+		return decoded, nil
+	case messageEncodingAvro:
+		return s.serializeAvro(payload)
+	case messageEncodingProtobuf:
+		if s.ProtoService == nil {
+			return nil, fmt.Errorf("protobuf encoding requires a configured protobuf deserializer")
+		}
+		return s.ProtoService.MarshalPayload([]byte(payload.Data), topicName, recordType)
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding '%v'", payload.Encoding)
+	}
+}
+
+// serializeAvro encodes the given JSON payload with the avro schema that is registered under the given schema id. The
+// result is prefixed with the magic byte and schema id (Confluent wire format), so that consumers can look up the schema.
+// Reference: https://docs.confluent.io/current/schema-registry/serdes-develop/index.html#wire-format
+func (s *serializer) serializeAvro(payload ProducePayload) ([]byte, error) {
+	if s.SchemaService == nil {
+		return nil, fmt.Errorf("avro encoding requires a configured schema registry")
+	}
+
+	codec, err := s.SchemaService.GetAvroSchemaByID(payload.SchemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avro schema by id '%d': %w", payload.SchemaID, err)
+	}
+
+	native, _, err := codec.NativeFromTextual([]byte(payload.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert JSON payload to avro: %w", err)
+	}
+
+	header := make([]byte, 5)
+	header[0] = byte(0)
+	binary.BigEndian.PutUint32(header[1:5], payload.SchemaID)
+
+	encoded, err := codec.BinaryFromNative(header, native)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode avro payload: %w", err)
+	}
+
+	return encoded, nil
+}
@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializer_SerializePayload(t *testing.T) {
+	tt := []struct {
+		name     string
+		payload  ProducePayload
+		expected []byte
+		err      string
+	}{
+		{"none", ProducePayload{Encoding: "none", Data: "ignored"}, nil, ""},
+		{"text", ProducePayload{Encoding: "text", Data: "hello"}, []byte("hello"), ""},
+		{"json", ProducePayload{Encoding: "json", Data: `{"id": 1}`}, []byte(`{"id": 1}`), ""},
+		{"invalid json", ProducePayload{Encoding: "json", Data: `{"id": 1`}, nil, "not valid JSON"},
+		{"binary", ProducePayload{Encoding: "binary", Data: "AAEC/w=="}, []byte{0x00, 0x01, 0x02, 0xff}, ""},
+		{"invalid base64", ProducePayload{Encoding: "binary", Data: "AAEC/w"}, nil, "failed to decode base64"},
+		{"avro without schema registry", ProducePayload{Encoding: "avro", Data: `"hi"`, SchemaID: 1}, nil, "requires a configured schema registry"},
+		{"protobuf without proto config", ProducePayload{Encoding: "protobuf", Data: `{}`}, nil, "requires a configured protobuf"},
+		{"unknown encoding", ProducePayload{Encoding: "xml", Data: "<a/>"}, nil, "unsupported payload encoding"},
+	}
+
+	s := serializer{}
+	for _, table := range tt {
+		t.Run(table.name, func(t *testing.T) {
+			serialized, err := s.SerializePayload(table.payload, "orders", proto.RecordValue)
+			if table.err != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), table.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, table.expected, serialized)
+		})
+	}
+}
+
+func TestSerializer_Avro(t *testing.T) {
+	d, closeServer := newSchemaRegistryDeserializer(t, map[string]interface{}{
+		"/schemas/ids/3": map[string]interface{}{
+			"schema": `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}, {"name": "amount", "type": "int"}]}`,
+		},
+	})
+	defer closeServer()
+	s := serializer{SchemaService: d.SchemaService}
+
+	// The payload is written in the wire format, so that it's deserialized with the same schema
+	serialized, err := s.SerializePayload(ProducePayload{Encoding: "avro", Data: `{"id": "order-1", "amount": 3}`, SchemaID: 3}, "orders", proto.RecordValue)
+	require.NoError(t, err)
+	assert.Equal(t, wireFormatPayload(3, 0x0e, 'o', 'r', 'd', 'e', 'r', '-', '1', 0x06), serialized)
+
+	res := d.deserializePayload(serialized, "orders", proto.RecordValue)
+	assert.Equal(t, messageEncodingAvro, res.RecognizedEncoding)
+	assert.Equal(t, map[string]interface{}{"id": "order-1", "amount": int32(3)}, res.Object)
+
+	_, err = s.SerializePayload(ProducePayload{Encoding: "avro", Data: `{"id": "order-1"}`, SchemaID: 3}, "orders", proto.RecordValue)
+	assert.Error(t, err, "expected an error for a payload which doesn't match the schema")
+
+	_, err = s.SerializePayload(ProducePayload{Encoding: "avro", Data: `"hi"`, SchemaID: 4}, "orders", proto.RecordValue)
+	assert.Error(t, err, "expected an error for an unknown schema id")
+}
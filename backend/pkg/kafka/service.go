@@ -7,8 +7,10 @@ import (
 	"github.com/twmb/franz-go/pkg/kversion"
 	"time"
 
+	"github.com/cloudhut/kowl/backend/pkg/avro"
 	"github.com/cloudhut/kowl/backend/pkg/proto"
 	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
 
@@ -24,16 +26,21 @@ type Service struct {
 	KafkaClient      *kgo.Client
 	SchemaService    *schema.Service
 	ProtoService     *proto.Service
-	Deserializer     deserializer
+	AvroService      *avro.Service
+	Deserializer     *deserializer
+	Serializer       serializer
 	MetricsNamespace string
+	ClusterName      string
+
+	consumerPool *consumerClientPool
 }
 
 // NewService creates a new Kafka service and immediately checks connectivity to all components. If any of these external
-// dependencies fail an error wil be returned.
-func NewService(cfg Config, logger *zap.Logger, metricsNamespace string) (*Service, error) {
+// dependencies fail an error wil be returned. The cluster name is used to label the exported metrics.
+func NewService(cfg Config, logger *zap.Logger, metricsNamespace string, clusterName string) (*Service, error) {
 	// Kafka client
 	hooksChildLogger := logger.With(zap.String("source", "kafka_client_hooks"))
-	clientHooks := newClientHooks(hooksChildLogger, "kowl")
+	clientHooks := newClientHooks(hooksChildLogger, "kowl", clusterName)
 
 	kgoOpts, err := NewKgoConfig(&cfg, logger, clientHooks)
 	if err != nil {
@@ -58,17 +65,31 @@ func NewService(cfg Config, logger *zap.Logger, metricsNamespace string) (*Servi
 			return nil, fmt.Errorf("failed to create schema service: %w", err)
 		}
 
-		err := schemaSvc.CheckConnectivity()
+		healths, err := schemaSvc.CheckConnectivity(context.Background())
+		for _, health := range healths {
+			if !health.IsHealthy {
+				logger.Warn("schema registry url is not accessible, requests will fail over to the other urls",
+					zap.String("url", health.URL),
+					zap.String("error", health.Error))
+			}
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to verify connectivity to schema registry: %w", err)
 		}
 		logger.Info("successfully tested schema registry connectivity")
+
+		err = prometheus.Register(schema.NewCacheCollector(schemaSvc, metricsNamespace, clusterName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to register schema registry cache metrics: %w", err)
+		}
 	}
 
 	// Protoservice
 	var protoSvc *proto.Service
 	if cfg.Protobuf.Enabled {
 		cfg.Protobuf.Git.AllowedFileExtensions = []string{"proto"}
+		cfg.Protobuf.FileSystem.AllowedFileExtensions = []string{"proto"}
+		cfg.Protobuf.DescriptorSets.AllowedFileExtensions = []string{"pb", "desc"}
 		svc, err := proto.NewService(cfg.Protobuf, logger)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create protobuf service: %w", err)
@@ -76,36 +97,84 @@ func NewService(cfg Config, logger *zap.Logger, metricsNamespace string) (*Servi
 		protoSvc = svc
 	}
 
-	return &Service{
+	// Avro service for messages without schema registry
+	var avroSvc *avro.Service
+	if cfg.Avro.Enabled {
+		cfg.Avro.Git.AllowedFileExtensions = []string{"avsc"}
+		cfg.Avro.FileSystem.AllowedFileExtensions = []string{"avsc"}
+		svc, err := avro.NewService(cfg.Avro, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create avro service: %w", err)
+		}
+		avroSvc = svc
+	}
+
+	topicEncodings, err := newTopicEncodings(cfg.TopicEncodings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topic encodings: %w", err)
+	}
+
+	svc := &Service{
 		Config:           cfg,
 		Logger:           logger,
 		KafkaClientHooks: clientHooks,
 		KafkaClient:      kafkaClient,
 		SchemaService:    schemaSvc,
 		ProtoService:     protoSvc,
-		Deserializer: deserializer{
+		AvroService:      avroSvc,
+		Deserializer:     newDeserializer(schemaSvc, protoSvc, avroSvc, topicEncodings),
+		Serializer: serializer{
 			SchemaService: schemaSvc,
 			ProtoService:  protoSvc,
 		},
 		MetricsNamespace: metricsNamespace,
-	}, nil
+		ClusterName:      clusterName,
+	}
+	svc.consumerPool = newConsumerClientPool(cfg.ConsumerPool, logger, metricsNamespace, clusterName, func() (*kgo.Client, error) {
+		return svc.NewKgoClient()
+	})
+
+	return svc, nil
 }
 
 // Start starts all the (background) tasks which are required for this service to work properly. If any of these
 // tasks can not be setup an error will be returned which will cause the application to exit.
 func (s *Service) Start() error {
-	if s.ProtoService == nil {
-		return nil
+	// Custom decoders may have been registered after the service has been created, hence the configured topic
+	// encodings can't be validated any earlier
+	err := s.Deserializer.validateTopicEncodings()
+	if err != nil {
+		return fmt.Errorf("invalid topic encodings: %w", err)
+	}
+
+	go s.consumerPool.Start(context.Background())
+
+	if s.ProtoService != nil {
+		err := s.ProtoService.Start()
+		if err != nil {
+			return fmt.Errorf("failed to start protobuf service: %w", err)
+		}
 	}
-	return s.ProtoService.Start()
+
+	if s.AvroService != nil {
+		err := s.AvroService.Start()
+		if err != nil {
+			return fmt.Errorf("failed to start avro service: %w", err)
+		}
+	}
+
+	return nil
 }
 
-func (s *Service) NewKgoClient() (*kgo.Client, error) {
+// NewKgoClient creates a new Kafka client using the service's config. Additional options can be passed to override or
+// extend the default client options.
+func (s *Service) NewKgoClient(additionalOpts ...kgo.Opt) (*kgo.Client, error) {
 	// Kafka client
 	kgoOpts, err := NewKgoConfig(&s.Config, s.Logger, s.KafkaClientHooks)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a valid kafka client config: %w", err)
 	}
+	kgoOpts = append(kgoOpts, additionalOpts...)
 
 	kafkaClient, err := kgo.NewClient(kgoOpts...)
 	if err != nil {
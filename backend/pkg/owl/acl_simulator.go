@@ -0,0 +1,189 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+const (
+	aclWildcardPrincipal = "User:*"
+	aclWildcard          = "*"
+)
+
+// AclSimulationRequest asks whether a principal connecting from a host may perform an operation on a resource.
+type AclSimulationRequest struct {
+	Principal    string // e.g. User:alice
+	Host         string // e.g. 10.0.0.1
+	Operation    string // e.g. READ
+	ResourceType string // e.g. TOPIC
+	ResourceName string // e.g. orders
+}
+
+// OK validates the simulation request.
+func (a *AclSimulationRequest) OK() error {
+	if a.Principal == "" {
+		return fmt.Errorf("principal must be set")
+	}
+	// A request always originates from a concrete host. Simulating it for the wildcard host would ignore all bindings
+	// that are scoped to specific hosts, such as a DENY binding for the client's address.
+	if a.Host == "" || a.Host == aclWildcard {
+		return fmt.Errorf("host must be set to the address the client connects from")
+	}
+	if a.ResourceName == "" {
+		return fmt.Errorf("resource name must be set")
+	}
+
+	operation, err := parseACLOperation(a.Operation)
+	if err != nil {
+		return err
+	}
+	if operation == kmsg.ACLOperationAny || operation == kmsg.ACLOperationAll {
+		return fmt.Errorf("operation must be a concrete operation such as READ or WRITE")
+	}
+
+	resourceType, err := parseACLResourceType(a.ResourceType)
+	if err != nil {
+		return err
+	}
+	if resourceType == kmsg.ACLResourceTypeAny {
+		return fmt.Errorf("resource type must be a concrete resource type such as TOPIC or GROUP")
+	}
+
+	return nil
+}
+
+// AclSimulationResult is the verdict of an ACL simulation along with the bindings that decided it.
+type AclSimulationResult struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+
+	// DecidingBindings are the DENY bindings if the request has been denied by a binding or the ALLOW bindings if
+	// the request has been allowed. It's empty if there's no matching binding at all.
+	DecidingBindings []AclBinding `json:"decidingBindings"`
+}
+
+// SimulateACL evaluates whether the requested operation would be authorized by Kafka's default authorizer
+// (AclAuthorizer) given the ACLs that are currently stored in the cluster. Super users and the
+// allow.everyone.if.no.acl.found broker setting are not taken into account.
+func (s *Service) SimulateACL(ctx context.Context, req AclSimulationRequest) (*AclSimulationResult, error) {
+	resourceType, err := parseACLResourceType(req.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	// The MATCH pattern type returns all literal, prefixed and wildcard bindings which apply to the resource name
+	describeReq := kmsg.NewDescribeACLsRequest()
+	describeReq.ResourceType = resourceType
+	describeReq.ResourceName = &req.ResourceName
+	describeReq.ResourcePatternType = ACLResourcePatternTypeMatch
+	describeReq.Operation = kmsg.ACLOperationAny
+	describeReq.PermissionType = kmsg.ACLPermissionTypeAny
+
+	bindings, err := s.listACLBindings(ctx, describeReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluateACLs(req, bindings), nil
+}
+
+// evaluateACLs applies Kafka's authorizer semantics on the given bindings:
+//   - A binding applies if principal (or User:*), host (or *) and resource (literal, prefixed or wildcard) match.
+//   - Any applying DENY binding for the operation (or ALL) denies the request.
+//   - Otherwise an applying ALLOW binding for the operation, ALL or an operation that implies the requested
+//     operation (e.g. READ implies DESCRIBE) allows the request.
+//   - Without any applying ALLOW binding the request is denied.
+func evaluateACLs(req AclSimulationRequest, bindings []AclBinding) *AclSimulationResult {
+	operation := strings.ToUpper(req.Operation)
+
+	denyOperations := map[string]struct{}{operation: {}, "ALL": {}}
+	allowOperations := map[string]struct{}{operation: {}, "ALL": {}}
+	for _, impliedBy := range aclImpliedBy(operation) {
+		allowOperations[impliedBy] = struct{}{}
+	}
+
+	denyBindings := make([]AclBinding, 0)
+	allowBindings := make([]AclBinding, 0)
+	for _, binding := range bindings {
+		if !aclBindingMatchesRequest(binding, req) {
+			continue
+		}
+		bindingOperation := strings.ToUpper(binding.Operation)
+		switch strings.ToUpper(binding.PermissionType) {
+		case "DENY":
+			if _, exists := denyOperations[bindingOperation]; exists {
+				denyBindings = append(denyBindings, binding)
+			}
+		case "ALLOW":
+			if _, exists := allowOperations[bindingOperation]; exists {
+				allowBindings = append(allowBindings, binding)
+			}
+		}
+	}
+
+	if len(denyBindings) > 0 {
+		return &AclSimulationResult{
+			Allowed:          false,
+			Reason:           "denied by at least one DENY binding, DENY bindings always take precedence over ALLOW bindings",
+			DecidingBindings: denyBindings,
+		}
+	}
+	if len(allowBindings) > 0 {
+		return &AclSimulationResult{
+			Allowed:          true,
+			Reason:           "allowed by at least one ALLOW binding",
+			DecidingBindings: allowBindings,
+		}
+	}
+
+	return &AclSimulationResult{
+		Allowed:          false,
+		Reason:           "denied because there is no ALLOW binding that matches the request",
+		DecidingBindings: []AclBinding{},
+	}
+}
+
+// aclImpliedBy returns all operations which implicitly grant the given operation if they are allowed.
+func aclImpliedBy(operation string) []string {
+	switch operation {
+	case "DESCRIBE":
+		return []string{"READ", "WRITE", "DELETE", "ALTER"}
+	case "DESCRIBE_CONFIGS":
+		return []string{"ALTER_CONFIGS"}
+	default:
+		return nil
+	}
+}
+
+// aclBindingMatchesRequest returns true if the binding's resource, principal and host match the request.
+func aclBindingMatchesRequest(binding AclBinding, req AclSimulationRequest) bool {
+	if !strings.EqualFold(binding.ResourceType, req.ResourceType) {
+		return false
+	}
+
+	switch strings.ToUpper(binding.ResourcePatternType) {
+	case "LITERAL":
+		if binding.ResourceName != aclWildcard && binding.ResourceName != req.ResourceName {
+			return false
+		}
+	case "PREFIXED":
+		if !strings.HasPrefix(req.ResourceName, binding.ResourceName) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if binding.Principal != aclWildcardPrincipal && binding.Principal != req.Principal {
+		return false
+	}
+
+	if binding.Host != aclWildcard && binding.Host != req.Host {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,172 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateACLs(t *testing.T) {
+	allowReadOrders := AclBinding{"TOPIC", "orders", "LITERAL", "User:alice", "*", "READ", "ALLOW"}
+	allowWritePrefixed := AclBinding{"TOPIC", "orders-", "PREFIXED", "User:alice", "*", "WRITE", "ALLOW"}
+	allowAllWildcard := AclBinding{"TOPIC", "*", "LITERAL", "User:*", "*", "ALL", "ALLOW"}
+	denyReadFromHost := AclBinding{"TOPIC", "orders", "LITERAL", "User:alice", "10.0.0.1", "READ", "DENY"}
+	denyAllPrefixed := AclBinding{"TOPIC", "orders-", "PREFIXED", "User:*", "*", "ALL", "DENY"}
+	allowAlterConfigs := AclBinding{"TOPIC", "orders", "LITERAL", "User:alice", "*", "ALTER_CONFIGS", "ALLOW"}
+	allowReadGroup := AclBinding{"GROUP", "orders", "LITERAL", "User:alice", "*", "READ", "ALLOW"}
+
+	tt := []struct {
+		TestName         string
+		Request          AclSimulationRequest
+		Bindings         []AclBinding
+		ExpectedAllowed  bool
+		ExpectedDeciding []AclBinding
+	}{
+		{
+			TestName:         "no bindings",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "READ", "TOPIC", "orders"},
+			Bindings:         []AclBinding{},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{},
+		},
+		{
+			TestName:         "literal allow",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "READ", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadOrders},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowReadOrders},
+		},
+		{
+			TestName:         "literal allow for other principal",
+			Request:          AclSimulationRequest{"User:bob", "10.0.0.2", "READ", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadOrders},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{},
+		},
+		{
+			TestName:         "literal allow for other operation",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "WRITE", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadOrders},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{},
+		},
+		{
+			TestName:         "literal allow for other resource type",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "READ", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadGroup},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{},
+		},
+		{
+			TestName:         "prefixed allow",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "WRITE", "TOPIC", "orders-eu"},
+			Bindings:         []AclBinding{allowReadOrders, allowWritePrefixed},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowWritePrefixed},
+		},
+		{
+			TestName:         "prefixed allow does not match shorter name",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "WRITE", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowWritePrefixed},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{},
+		},
+		{
+			TestName:         "wildcard resource, principal and operation",
+			Request:          AclSimulationRequest{"User:bob", "10.0.0.2", "DELETE", "TOPIC", "payments"},
+			Bindings:         []AclBinding{allowAllWildcard},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowAllWildcard},
+		},
+		{
+			TestName:         "deny on matching host takes precedence",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.1", "READ", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadOrders, allowAllWildcard, denyReadFromHost},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{denyReadFromHost},
+		},
+		{
+			TestName:         "deny on other host does not apply",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "READ", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadOrders, denyReadFromHost},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowReadOrders},
+		},
+		{
+			TestName:         "prefixed deny all overrides allow",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "WRITE", "TOPIC", "orders-eu"},
+			Bindings:         []AclBinding{allowWritePrefixed, allowAllWildcard, denyAllPrefixed},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{denyAllPrefixed},
+		},
+		{
+			TestName:         "describe is implied by read",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "DESCRIBE", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadOrders},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowReadOrders},
+		},
+		{
+			TestName:         "read is not implied by describe",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "READ", "TOPIC", "orders"},
+			Bindings:         []AclBinding{{"TOPIC", "orders", "LITERAL", "User:alice", "*", "DESCRIBE", "ALLOW"}},
+			ExpectedAllowed:  false,
+			ExpectedDeciding: []AclBinding{},
+		},
+		{
+			TestName:         "describe configs is implied by alter configs",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "DESCRIBE_CONFIGS", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowAlterConfigs},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowAlterConfigs},
+		},
+		{
+			TestName:         "deny read does not deny implied describe",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.1", "DESCRIBE", "TOPIC", "orders"},
+			Bindings:         []AclBinding{allowReadOrders, denyReadFromHost},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowReadOrders},
+		},
+		{
+			TestName:         "operation is case insensitive",
+			Request:          AclSimulationRequest{"User:alice", "10.0.0.2", "read", "topic", "orders"},
+			Bindings:         []AclBinding{allowReadOrders},
+			ExpectedAllowed:  true,
+			ExpectedDeciding: []AclBinding{allowReadOrders},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.TestName, func(t *testing.T) {
+			actual := evaluateACLs(test.Request, test.Bindings)
+			assert.Equal(t, test.ExpectedAllowed, actual.Allowed)
+			assert.Equal(t, test.ExpectedDeciding, actual.DecidingBindings)
+			assert.NotEmpty(t, actual.Reason)
+		})
+	}
+}
+
+func TestAclSimulationRequest_OK(t *testing.T) {
+	tt := []struct {
+		TestName    string
+		Request     AclSimulationRequest
+		ExpectError bool
+	}{
+		{"valid request", AclSimulationRequest{"User:alice", "10.0.0.1", "READ", "TOPIC", "orders"}, false},
+		{"missing principal", AclSimulationRequest{"", "10.0.0.1", "READ", "TOPIC", "orders"}, true},
+		{"missing host", AclSimulationRequest{"User:alice", "", "READ", "TOPIC", "orders"}, true},
+		{"wildcard host", AclSimulationRequest{"User:alice", "*", "READ", "TOPIC", "orders"}, true},
+		{"missing resource name", AclSimulationRequest{"User:alice", "10.0.0.1", "READ", "TOPIC", ""}, true},
+		{"operation filter", AclSimulationRequest{"User:alice", "10.0.0.1", "ANY", "TOPIC", "orders"}, true},
+		{"resource type filter", AclSimulationRequest{"User:alice", "10.0.0.1", "READ", "ANY", "orders"}, true},
+	}
+
+	for _, table := range tt {
+		err := table.Request.OK()
+		if table.ExpectError {
+			assert.Error(t, err, "expected an error for case '%v'", table.TestName)
+		} else {
+			assert.NoError(t, err, "expected no error for case '%v'", table.TestName)
+		}
+	}
+}
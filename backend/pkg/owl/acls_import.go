@@ -0,0 +1,206 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// AclDocument is the format used to export and import ACLs. It can be serialized as YAML or JSON.
+type AclDocument struct {
+	// Principals whose ACLs are managed by this document. When the document is imported, all existing bindings of these
+	// principals that are not part of the document will be removed. If empty, all principals referenced by the
+	// bindings are considered.
+	Principals []string     `json:"principals,omitempty" yaml:"principals,omitempty"`
+	ACLs       []AclBinding `json:"acls" yaml:"acls"`
+}
+
+// OK validates all bindings in the document.
+func (d *AclDocument) OK() error {
+	for i, binding := range d.ACLs {
+		err := binding.OK()
+		if err != nil {
+			return fmt.Errorf("invalid ACL binding at index '%d': %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// managedPrincipals returns the set of principals whose bindings are managed by this document.
+func (d *AclDocument) managedPrincipals() map[string]struct{} {
+	principals := make(map[string]struct{})
+	for _, principal := range d.Principals {
+		principals[principal] = struct{}{}
+	}
+	if len(principals) > 0 {
+		return principals
+	}
+
+	for _, binding := range d.ACLs {
+		principals[binding.Principal] = struct{}{}
+	}
+	return principals
+}
+
+// AclDiff describes the changes which are required to get from the current ACLs to the desired ACLs.
+type AclDiff struct {
+	Added     []AclBinding `json:"added"`
+	Removed   []AclBinding `json:"removed"`
+	Unchanged int          `json:"unchanged"`
+}
+
+// ApplyACLsResponse contains the diff and, unless it has been a dry run, the results of the applied changes.
+type ApplyACLsResponse struct {
+	DryRun        bool              `json:"dryRun"`
+	Diff          AclDiff           `json:"diff"`
+	CreateResults []CreateACLResult `json:"createResults,omitempty"`
+	DeleteResults []DeleteACLResult `json:"deleteResults,omitempty"`
+}
+
+// ExportACLs returns all ACL bindings that match the given filter as AclDocument.
+func (s *Service) ExportACLs(ctx context.Context, req kmsg.DescribeACLsRequest) (*AclDocument, error) {
+	bindings, err := s.listACLBindings(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	principalSet := make(map[string]struct{})
+	for _, binding := range bindings {
+		principalSet[binding.Principal] = struct{}{}
+	}
+	principals := make([]string, 0, len(principalSet))
+	for principal := range principalSet {
+		principals = append(principals, principal)
+	}
+	sort.Strings(principals)
+
+	return &AclDocument{
+		Principals: principals,
+		ACLs:       bindings,
+	}, nil
+}
+
+// ApplyACLs compares the document's bindings with the bindings of the managed principals in the cluster and creates
+// or deletes bindings so that the cluster state matches the document. If dryRun is true only the diff is returned.
+func (s *Service) ApplyACLs(ctx context.Context, doc AclDocument, dryRun bool) (*ApplyACLsResponse, error) {
+	current, err := s.listACLBindings(ctx, newDescribeAllACLsRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffACLBindings(current, doc.ACLs, doc.managedPrincipals())
+	res := &ApplyACLsResponse{
+		DryRun: dryRun,
+		Diff:   diff,
+	}
+	if dryRun {
+		return res, nil
+	}
+
+	// Bindings are created first, so that principals do not temporarily lose access if bindings are just changed
+	res.CreateResults, err = s.CreateACLs(ctx, diff.Added)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ACLs: %w", err)
+	}
+
+	filters := make([]kmsg.DeleteACLsRequestFilter, len(diff.Removed))
+	for i, binding := range diff.Removed {
+		filter, err := binding.toDeleteFilter()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create delete filter for existing ACL binding: %w", err)
+		}
+		filters[i] = filter
+	}
+	res.DeleteResults, err = s.DeleteACLs(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete ACLs: %w", err)
+	}
+
+	return res, nil
+}
+
+// diffACLBindings returns the bindings which must be added and removed so that the current bindings of all managed
+// principals match the desired bindings. Bindings of principals which are not managed are never removed.
+func diffACLBindings(current []AclBinding, desired []AclBinding, managedPrincipals map[string]struct{}) AclDiff {
+	currentByKey := make(map[string]AclBinding)
+	for _, binding := range current {
+		if _, isManaged := managedPrincipals[binding.Principal]; !isManaged {
+			continue
+		}
+		currentByKey[binding.key()] = binding
+	}
+	desiredByKey := make(map[string]AclBinding)
+	for _, binding := range desired {
+		desiredByKey[binding.key()] = binding
+	}
+
+	diff := AclDiff{
+		Added:   make([]AclBinding, 0),
+		Removed: make([]AclBinding, 0),
+	}
+	for key, binding := range desiredByKey {
+		if _, exists := currentByKey[key]; exists {
+			diff.Unchanged++
+			continue
+		}
+		diff.Added = append(diff.Added, binding)
+	}
+	for key, binding := range currentByKey {
+		if _, exists := desiredByKey[key]; !exists {
+			diff.Removed = append(diff.Removed, binding)
+		}
+	}
+	sortACLBindings(diff.Added)
+	sortACLBindings(diff.Removed)
+
+	return diff
+}
+
+// listACLBindings describes the ACLs matching the given filter and returns them as flat list of bindings.
+func (s *Service) listACLBindings(ctx context.Context, req kmsg.DescribeACLsRequest) ([]AclBinding, error) {
+	kRes, err := s.kafkaSvc.ListACLs(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACLs from Kafka: %w", err)
+	}
+	err = kerr.ErrorForCode(kRes.ErrorCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACLs from Kafka. Inner error: %w", err)
+	}
+
+	bindings := make([]AclBinding, 0)
+	for _, resource := range kRes.Resources {
+		for _, acl := range resource.ACLs {
+			bindings = append(bindings, AclBinding{
+				ResourceType:        resource.ResourceType.String(),
+				ResourceName:        resource.ResourceName,
+				ResourcePatternType: ACLResourcePatternTypeName(resource.ResourcePatternType),
+				Principal:           acl.Principal,
+				Host:                acl.Host,
+				Operation:           acl.Operation.String(),
+				PermissionType:      acl.PermissionType.String(),
+			})
+		}
+	}
+	sortACLBindings(bindings)
+
+	return bindings, nil
+}
+
+// newDescribeAllACLsRequest returns a describe request whose filter matches all ACLs.
+func newDescribeAllACLsRequest() kmsg.DescribeACLsRequest {
+	req := kmsg.NewDescribeACLsRequest()
+	req.ResourceType = kmsg.ACLResourceTypeAny
+	req.ResourcePatternType = ACLResourcePatternTypeAny
+	req.Operation = kmsg.ACLOperationAny
+	req.PermissionType = kmsg.ACLPermissionTypeAny
+
+	return req
+}
+
+func sortACLBindings(bindings []AclBinding) {
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].key() < bindings[j].key() })
+}
@@ -0,0 +1,285 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// AclBinding is a single ACL entry along with the resource it applies to. Unlike AclResource it is flat, so that
+// a list of bindings can be easily exported, imported and compared.
+type AclBinding struct {
+	ResourceType        string `json:"resourceType" yaml:"resourceType"`
+	ResourceName        string `json:"resourceName" yaml:"resourceName"`
+	ResourcePatternType string `json:"resourcePatternType" yaml:"resourcePatternType"`
+	Principal           string `json:"principal" yaml:"principal"`
+	Host                string `json:"host" yaml:"host"`
+	Operation           string `json:"operation" yaml:"operation"`
+	PermissionType      string `json:"permissionType" yaml:"permissionType"`
+}
+
+// key returns a normalized string representation which identifies the binding. Only the enum values are case
+// insensitive, Kafka compares resource names, principals and hosts case-sensitively.
+func (a *AclBinding) key() string {
+	return strings.Join([]string{
+		strings.ToUpper(a.ResourceType),
+		a.ResourceName,
+		strings.ToUpper(a.ResourcePatternType),
+		a.Principal,
+		a.Host,
+		strings.ToUpper(a.Operation),
+		strings.ToUpper(a.PermissionType),
+	}, "|")
+}
+
+// OK validates the binding. ACL bindings must be concrete, hence filter values such as ANY or MATCH are not allowed.
+func (a *AclBinding) OK() error {
+	_, err := a.toCreation()
+	return err
+}
+
+func (a *AclBinding) toCreation() (kmsg.CreateACLsRequestCreation, error) {
+	creation := kmsg.NewCreateACLsRequestCreation()
+
+	resourceType, err := parseACLResourceType(a.ResourceType)
+	if err != nil {
+		return creation, err
+	}
+	patternType, err := parseACLResourcePatternType(a.ResourcePatternType)
+	if err != nil {
+		return creation, err
+	}
+	operation, err := parseACLOperation(a.Operation)
+	if err != nil {
+		return creation, err
+	}
+	permissionType, err := parseACLPermissionType(a.PermissionType)
+	if err != nil {
+		return creation, err
+	}
+
+	if resourceType == kmsg.ACLResourceTypeAny {
+		return creation, fmt.Errorf("resource type ANY can only be used in filters")
+	}
+	if patternType == ACLResourcePatternTypeAny || patternType == ACLResourcePatternTypeMatch {
+		return creation, fmt.Errorf("resource pattern type '%v' can only be used in filters", a.ResourcePatternType)
+	}
+	if operation == kmsg.ACLOperationAny {
+		return creation, fmt.Errorf("operation ANY can only be used in filters")
+	}
+	if permissionType == kmsg.ACLPermissionTypeAny {
+		return creation, fmt.Errorf("permission type ANY can only be used in filters")
+	}
+	if a.ResourceName == "" {
+		return creation, fmt.Errorf("resource name must be set")
+	}
+	if a.Principal == "" {
+		return creation, fmt.Errorf("principal must be set")
+	}
+	if a.Host == "" {
+		return creation, fmt.Errorf("host must be set, use '*' to allow all hosts")
+	}
+
+	creation.ResourceType = resourceType
+	creation.ResourceName = a.ResourceName
+	creation.ResourcePatternType = patternType
+	creation.Principal = a.Principal
+	creation.Host = a.Host
+	creation.Operation = operation
+	creation.PermissionType = permissionType
+
+	return creation, nil
+}
+
+// toDeleteFilter returns a delete filter which exactly matches this binding.
+func (a *AclBinding) toDeleteFilter() (kmsg.DeleteACLsRequestFilter, error) {
+	filter := kmsg.NewDeleteACLsRequestFilter()
+	creation, err := a.toCreation()
+	if err != nil {
+		return filter, err
+	}
+
+	filter.ResourceType = creation.ResourceType
+	filter.ResourceName = &creation.ResourceName
+	filter.ResourcePatternType = creation.ResourcePatternType
+	filter.Principal = &creation.Principal
+	filter.Host = &creation.Host
+	filter.Operation = creation.Operation
+	filter.PermissionType = creation.PermissionType
+
+	return filter, nil
+}
+
+// CreateACLResult reports whether a single ACL binding could be created.
+type CreateACLResult struct {
+	AclBinding
+	ErrorCode    string  `json:"errorCode,omitempty"`
+	ErrorMessage *string `json:"errorMessage,omitempty"`
+}
+
+// DeleteACLResult is an ACL binding that matched the delete filter and reports whether it could be deleted.
+type DeleteACLResult struct {
+	AclBinding
+	ErrorCode    string  `json:"errorCode,omitempty"`
+	ErrorMessage *string `json:"errorMessage,omitempty"`
+}
+
+// CreateACLs creates all given ACL bindings. Errors for individual bindings are reported in the results.
+func (s *Service) CreateACLs(ctx context.Context, bindings []AclBinding) ([]CreateACLResult, error) {
+	if len(bindings) == 0 {
+		return []CreateACLResult{}, nil
+	}
+
+	creations := make([]kmsg.CreateACLsRequestCreation, len(bindings))
+	for i, binding := range bindings {
+		creation, err := binding.toCreation()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACL binding at index '%d': %w", i, err)
+		}
+		creations[i] = creation
+	}
+
+	kRes, err := s.kafkaSvc.CreateACLs(ctx, creations)
+	if err != nil {
+		return nil, err
+	}
+	if len(kRes.Results) != len(bindings) {
+		return nil, fmt.Errorf("expected '%d' results in create ACLs response, but got '%d'", len(bindings), len(kRes.Results))
+	}
+
+	// Results are returned in the same order as the creations have been sent
+	res := make([]CreateACLResult, len(bindings))
+	for i, result := range kRes.Results {
+		var errorStr string
+		if kErr := kerr.ErrorForCode(result.ErrorCode); kErr != nil {
+			errorStr = kErr.Error()
+		}
+		res[i] = CreateACLResult{
+			AclBinding:   bindings[i],
+			ErrorCode:    errorStr,
+			ErrorMessage: result.ErrorMessage,
+		}
+	}
+
+	return res, nil
+}
+
+// DeleteACLs deletes all ACL bindings that match the given filters and returns the matched bindings.
+func (s *Service) DeleteACLs(ctx context.Context, filters []kmsg.DeleteACLsRequestFilter) ([]DeleteACLResult, error) {
+	if len(filters) == 0 {
+		return []DeleteACLResult{}, nil
+	}
+
+	kRes, err := s.kafkaSvc.DeleteACLs(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]DeleteACLResult, 0)
+	for _, result := range kRes.Results {
+		kErr := kerr.ErrorForCode(result.ErrorCode)
+		if kErr != nil {
+			errMessage := kErr.Error()
+			if result.ErrorMessage != nil {
+				errMessage = fmt.Sprintf("%v: %v", kErr.Error(), *result.ErrorMessage)
+			}
+			return nil, fmt.Errorf("failed to delete ACLs: %v", errMessage)
+		}
+
+		for _, acl := range result.MatchingACLs {
+			var errorStr string
+			if kErr := kerr.ErrorForCode(acl.ErrorCode); kErr != nil {
+				errorStr = kErr.Error()
+			}
+			res = append(res, DeleteACLResult{
+				AclBinding: AclBinding{
+					ResourceType:        acl.ResourceType.String(),
+					ResourceName:        acl.ResourceName,
+					ResourcePatternType: ACLResourcePatternTypeName(acl.ResourcePatternType),
+					Principal:           acl.Principal,
+					Host:                acl.Host,
+					Operation:           acl.Operation.String(),
+					PermissionType:      acl.PermissionType.String(),
+				},
+				ErrorCode:    errorStr,
+				ErrorMessage: acl.ErrorMessage,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// parseACLResourceType returns the resource type for its string representation (e.g. TOPIC).
+func parseACLResourceType(str string) (kmsg.ACLResourceType, error) {
+	for i := int8(0); i < 32; i++ {
+		t := kmsg.ACLResourceType(i)
+		if t.String() != kmsg.ACLResourceTypeUnknown.String() && strings.EqualFold(t.String(), str) {
+			return t, nil
+		}
+	}
+	return kmsg.ACLResourceTypeUnknown, fmt.Errorf("unknown resource type '%v'", str)
+}
+
+// Kafka's wire codes of the ACL resource pattern types. kmsg's ACLResourcePatternType enum lacks ANY, hence its
+// values are off by one (kmsg's MATCH is Kafka's ANY). Always use these constants and ACLResourcePatternTypeName
+// instead of kmsg's constants and String().
+const (
+	ACLResourcePatternTypeUnknown  kmsg.ACLResourcePatternType = 0
+	ACLResourcePatternTypeAny      kmsg.ACLResourcePatternType = 1
+	ACLResourcePatternTypeMatch    kmsg.ACLResourcePatternType = 2
+	ACLResourcePatternTypeLiteral  kmsg.ACLResourcePatternType = 3
+	ACLResourcePatternTypePrefixed kmsg.ACLResourcePatternType = 4
+)
+
+var aclResourcePatternTypeNames = map[kmsg.ACLResourcePatternType]string{
+	ACLResourcePatternTypeAny:      "ANY",
+	ACLResourcePatternTypeMatch:    "MATCH",
+	ACLResourcePatternTypeLiteral:  "LITERAL",
+	ACLResourcePatternTypePrefixed: "PREFIXED",
+}
+
+// ACLResourcePatternTypeName returns the name of the resource pattern type with the given wire code (e.g. LITERAL)
+// or UNKNOWN.
+func ACLResourcePatternTypeName(t kmsg.ACLResourcePatternType) string {
+	name, exists := aclResourcePatternTypeNames[t]
+	if !exists {
+		return "UNKNOWN"
+	}
+	return name
+}
+
+// parseACLResourcePatternType returns the resource pattern type for its string representation (e.g. LITERAL).
+func parseACLResourcePatternType(str string) (kmsg.ACLResourcePatternType, error) {
+	for t, name := range aclResourcePatternTypeNames {
+		if strings.EqualFold(name, str) {
+			return t, nil
+		}
+	}
+	return ACLResourcePatternTypeUnknown, fmt.Errorf("unknown resource pattern type '%v'", str)
+}
+
+// parseACLOperation returns the operation for its string representation (e.g. READ).
+func parseACLOperation(str string) (kmsg.ACLOperation, error) {
+	for i := int8(0); i < 32; i++ {
+		t := kmsg.ACLOperation(i)
+		if t.String() != kmsg.ACLOperationUnknown.String() && strings.EqualFold(t.String(), str) {
+			return t, nil
+		}
+	}
+	return kmsg.ACLOperationUnknown, fmt.Errorf("unknown operation '%v'", str)
+}
+
+// parseACLPermissionType returns the permission type for its string representation (e.g. ALLOW).
+func parseACLPermissionType(str string) (kmsg.ACLPermissionType, error) {
+	for i := int8(0); i < 32; i++ {
+		t := kmsg.ACLPermissionType(i)
+		if t.String() != kmsg.ACLPermissionTypeUnknown.String() && strings.EqualFold(t.String(), str) {
+			return t, nil
+		}
+	}
+	return kmsg.ACLPermissionTypeUnknown, fmt.Errorf("unknown permission type '%v'", str)
+}
@@ -0,0 +1,72 @@
+package owl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestACLResourcePatternType(t *testing.T) {
+	// Kafka's wire codes, see org.apache.kafka.common.resource.PatternType
+	wireCodes := map[string]int8{
+		"ANY":      1,
+		"MATCH":    2,
+		"LITERAL":  3,
+		"PREFIXED": 4,
+	}
+	for name, code := range wireCodes {
+		patternType, err := parseACLResourcePatternType(name)
+		require.NoError(t, err)
+		assert.Equal(t, kmsg.ACLResourcePatternType(code), patternType, "unexpected wire code for %v", name)
+		assert.Equal(t, name, ACLResourcePatternTypeName(patternType))
+
+		patternType, err = parseACLResourcePatternType(strings.ToLower(name))
+		require.NoError(t, err)
+		assert.Equal(t, kmsg.ACLResourcePatternType(code), patternType, "names must be case insensitive")
+	}
+
+	_, err := parseACLResourcePatternType("UNKNOWN")
+	assert.Error(t, err)
+	assert.Equal(t, "UNKNOWN", ACLResourcePatternTypeName(0))
+	assert.Equal(t, "UNKNOWN", ACLResourcePatternTypeName(5))
+}
+
+func TestAclBinding_ToCreation(t *testing.T) {
+	binding := AclBinding{"TOPIC", "orders-", "prefixed", "User:alice", "*", "READ", "ALLOW"}
+	creation, err := binding.toCreation()
+	require.NoError(t, err)
+	assert.Equal(t, kmsg.ACLResourcePatternType(4), creation.ResourcePatternType)
+
+	filter, err := binding.toDeleteFilter()
+	require.NoError(t, err)
+	assert.Equal(t, kmsg.ACLResourcePatternType(4), filter.ResourcePatternType)
+
+	for _, patternType := range []string{"ANY", "MATCH"} {
+		binding.ResourcePatternType = patternType
+		assert.Error(t, binding.OK(), "pattern type %v must only be allowed in filters", patternType)
+	}
+}
+
+func TestDiffACLBindings(t *testing.T) {
+	current := []AclBinding{
+		{"TOPIC", "Orders", "LITERAL", "User:Alice", "*", "READ", "ALLOW"},
+		{"TOPIC", "payments", "LITERAL", "User:Alice", "*", "WRITE", "ALLOW"},
+		{"TOPIC", "payments", "LITERAL", "User:bob", "*", "READ", "ALLOW"},
+	}
+	desired := []AclBinding{
+		// Enum values are case insensitive, hence this binding already exists
+		{"topic", "Orders", "literal", "User:Alice", "*", "read", "allow"},
+		// Resource names and principals are case sensitive
+		{"TOPIC", "orders", "LITERAL", "User:Alice", "*", "READ", "ALLOW"},
+		{"TOPIC", "payments", "LITERAL", "User:alice", "*", "WRITE", "ALLOW"},
+	}
+	managedPrincipals := map[string]struct{}{"User:Alice": {}, "User:alice": {}}
+
+	diff := diffACLBindings(current, desired, managedPrincipals)
+	assert.Equal(t, 1, diff.Unchanged)
+	assert.Equal(t, []AclBinding{desired[1], desired[2]}, diff.Added)
+	assert.Equal(t, []AclBinding{current[1]}, diff.Removed, "bindings of principals which are not managed must be kept")
+}
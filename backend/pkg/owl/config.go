@@ -7,10 +7,16 @@ import (
 
 type Config struct {
 	TopicDocumentation ConfigTopicDocumentation `yaml:"topicDocumentation"`
+	LagExporter        ConfigLagExporter        `yaml:"lagExporter"`
+	TimeLag            ConfigTimeLag            `yaml:"timeLag"`
+	LagHistory         ConfigLagHistory         `yaml:"lagHistory"`
 }
 
 func (c *Config) SetDefaults() {
 	c.TopicDocumentation.SetDefaults()
+	c.LagExporter.SetDefaults()
+	c.TimeLag.SetDefaults()
+	c.LagHistory.SetDefaults()
 }
 
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
@@ -23,5 +29,20 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("failed to validate topic documentation config: %w", err)
 	}
 
+	err = c.LagExporter.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate lag exporter config: %w", err)
+	}
+
+	err = c.TimeLag.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate time lag config: %w", err)
+	}
+
+	err = c.LagHistory.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate lag history config: %w", err)
+	}
+
 	return nil
 }
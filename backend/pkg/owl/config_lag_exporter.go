@@ -0,0 +1,60 @@
+package owl
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+type ConfigLagExporter struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Interval at which the lag of all exported consumer groups will be calculated
+	Interval time.Duration `yaml:"interval"`
+
+	// SummedOnly exports the lag summed per topic only, instead of exporting one series per partition
+	SummedOnly bool `yaml:"summedOnly"`
+
+	// AllowedGroups and IgnoredGroups are regexes which must match the whole group id. If AllowedGroups is empty all
+	// groups are allowed. A group matching any of the ignored groups will not be exported.
+	AllowedGroups []string `yaml:"allowedGroups"`
+	IgnoredGroups []string `yaml:"ignoredGroups"`
+}
+
+func (c *ConfigLagExporter) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+
+	_, err := compileRegexes(c.AllowedGroups)
+	if err != nil {
+		return fmt.Errorf("invalid allowed groups: %w", err)
+	}
+	_, err = compileRegexes(c.IgnoredGroups)
+	if err != nil {
+		return fmt.Errorf("invalid ignored groups: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ConfigLagExporter) SetDefaults() {
+	c.Interval = time.Minute
+}
+
+// compileRegexes compiles all expressions so that they only match whole strings.
+func compileRegexes(expressions []string) ([]*regexp.Regexp, error) {
+	regexes := make([]*regexp.Regexp, len(expressions))
+	for i, expr := range expressions {
+		regex, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regex '%v': %w", expr, err)
+		}
+		regexes[i] = regex
+	}
+
+	return regexes, nil
+}
@@ -0,0 +1,46 @@
+package owl
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConfigLagHistory configures the in-memory history of consumer group lags, which is used to tell whether a group is
+// catching up or falling behind.
+type ConfigLagHistory struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Interval at which the lags of all consumer groups will be sampled
+	Interval time.Duration `yaml:"interval"`
+
+	// Retention is the max age of samples. Together with the interval it bounds the number of samples per group.
+	Retention time.Duration `yaml:"retention"`
+
+	// PersistencePath is an optional file to which the history will be written after each sample, so that it
+	// survives restarts.
+	PersistencePath string `yaml:"persistencePath"`
+}
+
+func (c *ConfigLagHistory) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	if c.Retention < c.Interval {
+		return fmt.Errorf("retention must not be shorter than the interval")
+	}
+
+	return nil
+}
+
+func (c *ConfigLagHistory) SetDefaults() {
+	c.Interval = 30 * time.Second
+	c.Retention = 6 * time.Hour
+}
+
+// maxSamples returns the number of samples per group that are required to cover the whole retention.
+func (c *ConfigLagHistory) maxSamples() int {
+	return int(c.Retention/c.Interval) + 1
+}
@@ -0,0 +1,54 @@
+package owl
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConfigTimeLag configures the estimation of consumer group lags in seconds. The estimation requires fetching the
+// records at the committed offsets and at the high water marks, therefore it is disabled by default.
+type ConfigTimeLag struct {
+	Enabled bool `yaml:"enabled"`
+
+	// CacheTTL is the duration for which fetched record timestamps will be cached
+	CacheTTL time.Duration `yaml:"cacheTtl"`
+
+	// FetchTimeout is the maximum duration to wait for the records of a single fetch. Partitions whose records could
+	// not be fetched in time will not report a time lag until the cached result has expired.
+	FetchTimeout time.Duration `yaml:"fetchTimeout"`
+
+	// MaxFetchRounds is the maximum number of fetches per request. Each fetch can only fetch a single offset per
+	// partition, so that partitions which are consumed by multiple groups at different offsets require several fetches.
+	// Timestamps which are not fetched within a request are fetched by subsequent requests.
+	MaxFetchRounds int `yaml:"maxFetchRounds"`
+
+	// MaxFetchDuration is the maximum duration for all fetches of a single request.
+	MaxFetchDuration time.Duration `yaml:"maxFetchDuration"`
+}
+
+func (c *ConfigTimeLag) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CacheTTL <= 0 {
+		return fmt.Errorf("cache ttl must be greater than 0")
+	}
+	if c.FetchTimeout <= 0 {
+		return fmt.Errorf("fetch timeout must be greater than 0")
+	}
+	if c.MaxFetchRounds <= 0 {
+		return fmt.Errorf("max fetch rounds must be greater than 0")
+	}
+	if c.MaxFetchDuration <= 0 {
+		return fmt.Errorf("max fetch duration must be greater than 0")
+	}
+
+	return nil
+}
+
+func (c *ConfigTimeLag) SetDefaults() {
+	c.CacheTTL = 10 * time.Minute
+	c.FetchTimeout = 2 * time.Second
+	c.MaxFetchRounds = 3
+	c.MaxFetchDuration = 5 * time.Second
+}
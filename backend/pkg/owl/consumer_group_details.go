@@ -0,0 +1,292 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// ConsumerGroupDetails describes a single consumer group along with a cross view of its members, assignments and
+// committed offsets per partition.
+type ConsumerGroupDetails struct {
+	GroupID        string                      `json:"groupId"`
+	State          string                      `json:"state"`
+	ProtocolType   string                      `json:"protocolType"`
+	Protocol       string                      `json:"protocol"`
+	Members        []GroupMemberDescription    `json:"members"`
+	Topics         []ConsumerGroupTopicDetails `json:"topics"`
+	AllowedActions []string                    `json:"allowedActions"`
+}
+
+// ConsumerGroupTopicDetails contains all partitions of a topic which is either assigned to a group member or has
+// committed group offsets.
+type ConsumerGroupTopicDetails struct {
+	TopicName string `json:"topicName"`
+	// Error is set if the topic's partitions could not be listed (e.g. because the topic has been deleted). In this
+	// case only the partitions with an assignment or a committed offset are listed.
+	Error      string                          `json:"error,omitempty"`
+	Partitions []ConsumerGroupPartitionDetails `json:"partitions"`
+}
+
+// ConsumerGroupPartitionDetails describes the assignment and the committed offset of a single partition.
+type ConsumerGroupPartitionDetails struct {
+	PartitionID int32 `json:"partitionId"`
+
+	// Assigned member, these are empty if no member is assigned to the partition
+	MemberID   string `json:"memberId,omitempty"`
+	ClientID   string `json:"clientId,omitempty"`
+	ClientHost string `json:"clientHost,omitempty"`
+
+	// CommittedOffset is -1 if the group has not committed an offset for this partition
+	CommittedOffset int64  `json:"committedOffset"`
+	CommitMetadata  string `json:"commitMetadata"`
+
+	// LogEndOffset (high water mark) is -1 if it could not be fetched, see Error
+	LogEndOffset int64 `json:"logEndOffset"`
+
+	// Lag is -1 if there is no committed offset or the log end offset is unknown
+	Lag int64 `json:"lag"`
+
+	// IsUnassigned is true if no member is assigned to the partition
+	IsUnassigned bool `json:"isUnassigned"`
+	// HasOffsetWithoutAssignment is true if the group has committed an offset but no member is assigned to the
+	// partition, e.g. because the consumers are no longer subscribed to the topic
+	HasOffsetWithoutAssignment bool `json:"hasOffsetWithoutAssignment"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// GetConsumerGroupDetails describes a single consumer group. Other than GetConsumerGroupsOverview only this group is
+// described, which is fast regardless of how many groups exist in the cluster.
+func (s *Service) GetConsumerGroupDetails(ctx context.Context, groupID string) (*ConsumerGroupDetails, *rest.Error) {
+	// 1. Describe group and fetch its committed offsets
+	describedGroups, err := s.kafkaSvc.DescribeConsumerGroups(ctx, []string{groupID})
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to describe consumer group: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+	var group *kmsg.DescribeGroupsResponseGroup
+	for _, describedGroup := range describedGroups.GetDescribedGroups() {
+		if describedGroup.Group == groupID {
+			g := describedGroup
+			group = &g
+			break
+		}
+	}
+	if group == nil {
+		return nil, &rest.Error{
+			Err:      fmt.Errorf("consumer group '%v' was not part of the describe groups response", groupID),
+			Status:   http.StatusServiceUnavailable,
+			Message:  "Failed to describe consumer group",
+			IsSilent: false,
+		}
+	}
+	err = kerr.ErrorForCode(group.ErrorCode)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to describe consumer group: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+	if group.State == "Dead" {
+		return nil, &rest.Error{
+			Err:      fmt.Errorf("consumer group '%v' does not exist", groupID),
+			Status:   http.StatusNotFound,
+			Message:  "The requested consumer group does not exist",
+			IsSilent: true,
+		}
+	}
+
+	members, err := s.convertGroupMembers(group.Members)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusInternalServerError,
+			Message:  fmt.Sprintf("Failed to convert group members: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	offsets, err := s.kafkaSvc.ListConsumerGroupOffsets(ctx, groupID)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to list consumer group offsets: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	// 2. Fetch all partitions and their log end offsets of the assigned or committed topics
+	topicNames := make([]string, 0)
+	for topicName := range collectGroupTopics(members, offsets) {
+		topicNames = append(topicNames, topicName)
+	}
+	topicPartitions := make(map[string][]int32)
+	topicErrors := make(map[string]string)
+	if len(topicNames) > 0 {
+		metadata, err := s.kafkaSvc.GetMetadata(ctx, topicNames)
+		if err != nil {
+			return nil, &rest.Error{
+				Err:      err,
+				Status:   http.StatusServiceUnavailable,
+				Message:  fmt.Sprintf("Failed to get topic metadata: %v", err.Error()),
+				IsSilent: false,
+			}
+		}
+		for _, topic := range metadata.Topics {
+			err := kerr.ErrorForCode(topic.ErrorCode)
+			if err != nil {
+				topicErrors[topic.Topic] = err.Error()
+				continue
+			}
+			partitionIDs, err := s.kafkaSvc.PartitionsToPartitionIDs(topic.Partitions)
+			if err != nil {
+				topicErrors[topic.Topic] = err.Error()
+				continue
+			}
+			topicPartitions[topic.Topic] = partitionIDs
+		}
+	}
+
+	marks := make(map[string]map[int32]*kafka.PartitionMarks)
+	if len(topicPartitions) > 0 {
+		marks, err = s.kafkaSvc.GetPartitionMarksBulk(ctx, topicPartitions)
+		if err != nil {
+			return nil, &rest.Error{
+				Err:      err,
+				Status:   http.StatusServiceUnavailable,
+				Message:  fmt.Sprintf("Failed to get partition water marks: %v", err.Error()),
+				IsSilent: false,
+			}
+		}
+	}
+
+	return &ConsumerGroupDetails{
+		GroupID:      group.Group,
+		State:        group.State,
+		ProtocolType: group.ProtocolType,
+		Protocol:     group.Protocol,
+		Members:      members,
+		Topics:       buildConsumerGroupTopicDetails(members, offsets, topicPartitions, topicErrors, marks),
+	}, nil
+}
+
+// collectGroupTopics returns the names of all topics which are assigned to a group member or have committed offsets.
+func collectGroupTopics(members []GroupMemberDescription, offsets *kmsg.OffsetFetchResponse) map[string]struct{} {
+	topics := make(map[string]struct{})
+	for _, member := range members {
+		for _, assignment := range member.Assignments {
+			topics[assignment.TopicName] = struct{}{}
+		}
+	}
+	for _, topic := range offsets.Topics {
+		topics[topic.Topic] = struct{}{}
+	}
+
+	return topics
+}
+
+// buildConsumerGroupTopicDetails joins the member assignments, the committed offsets and the partition marks by
+// partition. Topics and partitions are sorted by name and id.
+func buildConsumerGroupTopicDetails(members []GroupMemberDescription, offsets *kmsg.OffsetFetchResponse,
+	topicPartitions map[string][]int32, topicErrors map[string]string, marks map[string]map[int32]*kafka.PartitionMarks) []ConsumerGroupTopicDetails {
+	partitionsByTopic := make(map[string]map[int32]*ConsumerGroupPartitionDetails)
+	getPartition := func(topicName string, partitionID int32) *ConsumerGroupPartitionDetails {
+		if _, ok := partitionsByTopic[topicName]; !ok {
+			partitionsByTopic[topicName] = make(map[int32]*ConsumerGroupPartitionDetails)
+		}
+		partition, ok := partitionsByTopic[topicName][partitionID]
+		if !ok {
+			partition = &ConsumerGroupPartitionDetails{
+				PartitionID:     partitionID,
+				CommittedOffset: -1,
+				LogEndOffset:    -1,
+				Lag:             -1,
+			}
+			partitionsByTopic[topicName][partitionID] = partition
+		}
+		return partition
+	}
+
+	// 1. All partitions of the topic are listed, so that partitions without an owner become visible
+	for topicName := range collectGroupTopics(members, offsets) {
+		for _, partitionID := range topicPartitions[topicName] {
+			getPartition(topicName, partitionID)
+		}
+	}
+
+	// 2. Assigned members
+	for _, member := range members {
+		for _, assignment := range member.Assignments {
+			for _, partitionID := range assignment.PartitionIDs {
+				partition := getPartition(assignment.TopicName, partitionID)
+				partition.MemberID = member.ID
+				partition.ClientID = member.ClientID
+				partition.ClientHost = member.ClientHost
+			}
+		}
+	}
+
+	// 3. Committed offsets
+	for _, topic := range offsets.Topics {
+		for _, offset := range topic.Partitions {
+			partition := getPartition(topic.Topic, offset.Partition)
+			err := kerr.ErrorForCode(offset.ErrorCode)
+			if err != nil {
+				partition.Error = err.Error()
+				continue
+			}
+			partition.CommittedOffset = offset.Offset
+			if offset.Metadata != nil {
+				partition.CommitMetadata = *offset.Metadata
+			}
+		}
+	}
+
+	// 4. Log end offsets, lags and flags
+	res := make([]ConsumerGroupTopicDetails, 0, len(partitionsByTopic))
+	for topicName, partitions := range partitionsByTopic {
+		topic := ConsumerGroupTopicDetails{
+			TopicName:  topicName,
+			Error:      topicErrors[topicName],
+			Partitions: make([]ConsumerGroupPartitionDetails, 0, len(partitions)),
+		}
+		for partitionID, partition := range partitions {
+			if mark, ok := marks[topicName][partitionID]; ok {
+				if mark.Error != "" && partition.Error == "" {
+					partition.Error = mark.Error
+				}
+				partition.LogEndOffset = mark.High
+			}
+			if partition.CommittedOffset >= 0 && partition.LogEndOffset >= 0 {
+				partition.Lag = partition.LogEndOffset - partition.CommittedOffset
+				if partition.Lag < 0 {
+					// The log end offset might have been fetched before the group offset has been committed
+					partition.Lag = 0
+				}
+			}
+			partition.IsUnassigned = partition.MemberID == ""
+			partition.HasOffsetWithoutAssignment = partition.IsUnassigned && partition.CommittedOffset >= 0
+
+			topic.Partitions = append(topic.Partitions, *partition)
+		}
+		sort.Slice(topic.Partitions, func(i, j int) bool { return topic.Partitions[i].PartitionID < topic.Partitions[j].PartitionID })
+		res = append(res, topic)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].TopicName < res[j].TopicName })
+
+	return res
+}
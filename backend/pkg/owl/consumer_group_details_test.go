@@ -0,0 +1,83 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestBuildConsumerGroupTopicDetails(t *testing.T) {
+	members := []GroupMemberDescription{
+		{
+			ID:         "consumer-1-abc",
+			ClientID:   "consumer-1",
+			ClientHost: "/10.0.0.1",
+			Assignments: []GroupMemberAssignment{
+				{TopicName: "orders", PartitionIDs: []int32{0}},
+			},
+		},
+	}
+	metadata := "checkpoint-42"
+	offsets := &kmsg.OffsetFetchResponse{
+		Topics: []kmsg.OffsetFetchResponseTopic{
+			{
+				Topic: "orders",
+				Partitions: []kmsg.OffsetFetchResponseTopicPartition{
+					{Partition: 0, Offset: 90, Metadata: &metadata},
+					{Partition: 2, Offset: 10},
+				},
+			},
+			{
+				Topic: "deleted-topic",
+				Partitions: []kmsg.OffsetFetchResponseTopicPartition{
+					{Partition: 0, Offset: 5},
+				},
+			},
+		},
+	}
+	topicPartitions := map[string][]int32{"orders": {0, 1, 2}}
+	topicErrors := map[string]string{"deleted-topic": "UNKNOWN_TOPIC_OR_PARTITION"}
+	marks := map[string]map[int32]*kafka.PartitionMarks{
+		"orders": {
+			0: {PartitionID: 0, Low: 0, High: 100},
+			1: {PartitionID: 1, Low: 0, High: 50},
+			2: {PartitionID: 2, Low: -1, High: -1, Error: "NOT_LEADER_FOR_PARTITION"},
+		},
+	}
+
+	topics := buildConsumerGroupTopicDetails(members, offsets, topicPartitions, topicErrors, marks)
+	require.Len(t, topics, 2)
+
+	assert.Equal(t, "deleted-topic", topics[0].TopicName)
+	assert.Equal(t, "UNKNOWN_TOPIC_OR_PARTITION", topics[0].Error)
+	assert.Equal(t, []ConsumerGroupPartitionDetails{
+		{PartitionID: 0, CommittedOffset: 5, LogEndOffset: -1, Lag: -1, IsUnassigned: true, HasOffsetWithoutAssignment: true},
+	}, topics[0].Partitions)
+
+	assert.Equal(t, "orders", topics[1].TopicName)
+	assert.Equal(t, []ConsumerGroupPartitionDetails{
+		{
+			PartitionID:     0,
+			MemberID:        "consumer-1-abc",
+			ClientID:        "consumer-1",
+			ClientHost:      "/10.0.0.1",
+			CommittedOffset: 90,
+			CommitMetadata:  "checkpoint-42",
+			LogEndOffset:    100,
+			Lag:             10,
+		},
+		{PartitionID: 1, CommittedOffset: -1, LogEndOffset: 50, Lag: -1, IsUnassigned: true},
+		{
+			PartitionID:                2,
+			CommittedOffset:            10,
+			LogEndOffset:               -1,
+			Lag:                        -1,
+			IsUnassigned:               true,
+			HasOffsetWithoutAssignment: true,
+			Error:                      "NOT_LEADER_FOR_PARTITION",
+		},
+	}, topics[1].Partitions)
+}
@@ -37,6 +37,14 @@ type TopicLag struct {
 	PartitionCount       int            `json:"partitionCount"`
 	PartitionsWithOffset int            `json:"partitionsWithOffset"` // Number of partitions which have an active group offset
 	PartitionLags        []PartitionLag `json:"partitionLags"`
+
+	// LagSeconds is the highest time lag of all partitions. It is only set if the time lag estimation is enabled and
+	// the time lag is known for at least one partition.
+	LagSeconds *float64 `json:"lagSeconds,omitempty"`
+
+	// summedHighWaterMark sums the high water marks of all partitions with a group offset. It is used to calculate
+	// the production rate in the lag history.
+	summedHighWaterMark int64
 }
 
 // PartitionLag describes the kafka lag for a partition for a single consumer group
@@ -45,6 +53,12 @@ type PartitionLag struct {
 	Error       string `json:"error,omitempty"`
 	PartitionID int32  `json:"partitionId"`
 	Lag         int64  `json:"lag"`
+
+	// LagSeconds is the difference between the timestamps of the latest record and the record at the committed
+	// offset. If the committed record has been compacted, the next available record will be used. Partitions
+	// without lag have a time lag of 0 seconds. It is only set if the time lag estimation is enabled and the
+	// timestamps could be fetched.
+	LagSeconds *float64 `json:"lagSeconds,omitempty"`
 }
 
 // convertOffsets returns a map where the key is the topic name
@@ -124,6 +138,7 @@ func (s *Service) getConsumerGroupLags(ctx context.Context, groups []string) (ma
 					Error:       err.Error(),
 					Offset:      -1,
 				}
+				continue
 			}
 			highWaterMarks[topic.Topic][partition.Partition] = highMark{
 				PartitionID: partition.Partition,
@@ -176,6 +191,7 @@ func (s *Service) getConsumerGroupLags(ctx context.Context, groups []string) (ma
 					lag = 0
 				}
 				t.SummedLag += lag
+				t.summedHighWaterMark += watermark.Offset
 				t.PartitionLags = append(t.PartitionLags, PartitionLag{PartitionID: pID, Lag: lag})
 			}
 			topicLags = append(topicLags, &t)
@@ -187,5 +203,30 @@ func (s *Service) getConsumerGroupLags(ctx context.Context, groups []string) (ma
 		}
 	}
 
+	// 5. Estimate the lags in seconds, which requires fetching the records at the committed offsets
+	if s.timeLagEstimator != nil {
+		requests := make([]timeLagRequest, 0)
+		for group, groupLag := range res {
+			for _, topicLag := range groupLag.TopicLags {
+				for i := range topicLag.PartitionLags {
+					partitionLag := &topicLag.PartitionLags[i]
+					requests = append(requests, timeLagRequest{
+						Topic:           topicLag.Topic,
+						PartitionLag:    partitionLag,
+						CommittedOffset: offsetsByGroup[group][topicLag.Topic][partitionLag.PartitionID],
+						HighWaterMark:   highWaterMarks[topicLag.Topic][partitionLag.PartitionID].Offset,
+					})
+				}
+			}
+		}
+		s.timeLagEstimator.estimate(ctx, requests)
+
+		for _, groupLag := range res {
+			for _, topicLag := range groupLag.TopicLags {
+				setTopicLagSeconds(topicLag)
+			}
+		}
+	}
+
 	return res, nil
 }
@@ -0,0 +1,318 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+const (
+	// ResetOffsetsModeEarliest resets the group offset to the low water mark of the partition
+	ResetOffsetsModeEarliest = "earliest"
+	// ResetOffsetsModeLatest resets the group offset to the high water mark of the partition
+	ResetOffsetsModeLatest = "latest"
+	// ResetOffsetsModeTimestamp resets the group offset to the first offset whose timestamp is >= the given timestamp
+	ResetOffsetsModeTimestamp = "timestamp"
+	// ResetOffsetsModeOffset resets the group offset to an explicitly given offset
+	ResetOffsetsModeOffset = "offset"
+	// ResetOffsetsModeShiftBy moves the currently committed group offset by N (negative N moves backwards)
+	ResetOffsetsModeShiftBy = "shiftBy"
+)
+
+// ResetConsumerGroupOffsetsRequest describes which group offsets shall be reset and how.
+type ResetConsumerGroupOffsetsRequest struct {
+	GroupID string
+
+	// DryRun calculates the new offsets without committing them
+	DryRun bool
+	Topics []ResetConsumerGroupOffsetsTopic
+}
+
+// ResetConsumerGroupOffsetsTopic contains the partitions of a single topic whose group offsets shall be reset.
+type ResetConsumerGroupOffsetsTopic struct {
+	TopicName  string
+	Partitions []ResetConsumerGroupOffsetsPartition
+}
+
+// ResetConsumerGroupOffsetsPartition describes how the group offset for a single partition shall be reset.
+type ResetConsumerGroupOffsetsPartition struct {
+	PartitionID int32
+	Mode        string
+
+	Timestamp int64 // Unix timestamp in ms, only considered for mode timestamp
+	Offset    int64 // Only considered for mode offset
+	ShiftBy   int64 // Only considered for mode shiftBy
+}
+
+// ResetConsumerGroupOffsetsTopicResponse reports the offset changes for all requested partitions of a single topic.
+type ResetConsumerGroupOffsetsTopicResponse struct {
+	TopicName  string                                       `json:"topicName"`
+	Partitions []ResetConsumerGroupOffsetsPartitionResponse `json:"partitions"`
+}
+
+// ResetConsumerGroupOffsetsPartitionResponse reports the offsets before and after the reset of a single partition.
+type ResetConsumerGroupOffsetsPartitionResponse struct {
+	PartitionID int32 `json:"partitionId"`
+
+	// OffsetBefore is the committed offset before the reset or -1 if the group had no offset for this partition
+	OffsetBefore int64 `json:"offsetBefore"`
+	OffsetAfter  int64 `json:"offsetAfter"`
+	LagBefore    int64 `json:"lagBefore"`
+	LagAfter     int64 `json:"lagAfter"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ResetConsumerGroupOffsets calculates the new group offsets for all requested partitions and commits them unless
+// dry run is requested. Offsets can only be reset for groups without active members (state Empty or Dead).
+func (s *Service) ResetConsumerGroupOffsets(ctx context.Context, req ResetConsumerGroupOffsetsRequest) ([]ResetConsumerGroupOffsetsTopicResponse, *rest.Error) {
+	// 1. Ensure that there are no active members in the group
+	state, err := s.getConsumerGroupState(ctx, req.GroupID)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to describe consumer group: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+	if state != "Empty" && state != "Dead" {
+		return nil, &rest.Error{
+			Err:      fmt.Errorf("consumer group is in state '%v'", state),
+			Status:   http.StatusConflict,
+			Message:  fmt.Sprintf("Offsets can only be reset for inactive consumer groups (state Empty or Dead), but the group is in state '%v'", state),
+			IsSilent: false,
+		}
+	}
+
+	// 2. Fetch committed group offsets and the partitions' water marks
+	offsetsRes, err := s.kafkaSvc.ListConsumerGroupOffsets(ctx, req.GroupID)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to list consumer group offsets: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+	committedOffsets := convertOffsets(offsetsRes)
+
+	topicPartitions := make(map[string][]int32, len(req.Topics))
+	for _, topic := range req.Topics {
+		for _, partition := range topic.Partitions {
+			topicPartitions[topic.TopicName] = append(topicPartitions[topic.TopicName], partition.PartitionID)
+		}
+	}
+	marks, err := s.kafkaSvc.GetPartitionMarksBulk(ctx, topicPartitions)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to get partition water marks: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	// 3. Calculate the target offsets for each partition
+	res := make([]ResetConsumerGroupOffsetsTopicResponse, len(req.Topics))
+	commitTopics := make([]kmsg.OffsetCommitRequestTopic, 0, len(req.Topics))
+	for i, topic := range req.Topics {
+		offsetsByTimestamp, err := s.resolveResetTimestamps(ctx, topic)
+		if err != nil {
+			return nil, &rest.Error{
+				Err:      err,
+				Status:   http.StatusServiceUnavailable,
+				Message:  fmt.Sprintf("Failed to get offsets by timestamp for topic '%v': %v", topic.TopicName, err.Error()),
+				IsSilent: false,
+			}
+		}
+
+		partitionResponses := make([]ResetConsumerGroupOffsetsPartitionResponse, len(topic.Partitions))
+		commitPartitions := make([]kmsg.OffsetCommitRequestTopicPartition, 0, len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			offsetBefore := int64(-1)
+			if offset, exists := committedOffsets[topic.TopicName][partition.PartitionID]; exists {
+				offsetBefore = offset
+			}
+			partitionRes := ResetConsumerGroupOffsetsPartitionResponse{
+				PartitionID:  partition.PartitionID,
+				OffsetBefore: offsetBefore,
+				OffsetAfter:  offsetBefore,
+			}
+
+			mark := marks[topic.TopicName][partition.PartitionID]
+			if mark == nil || mark.Error != "" {
+				partitionRes.Error = "failed to get water marks for partition"
+				if mark != nil {
+					partitionRes.Error = mark.Error
+				}
+				partitionResponses[j] = partitionRes
+				continue
+			}
+			partitionRes.LagBefore = calculateLag(offsetBefore, mark.Low, mark.High)
+
+			offsetAfter, err := calculateResetOffset(partition, offsetBefore, offsetsByTimestamp[partition.PartitionID], mark.Low, mark.High)
+			if err != nil {
+				partitionRes.Error = err.Error()
+				partitionResponses[j] = partitionRes
+				continue
+			}
+			partitionRes.OffsetAfter = offsetAfter
+			partitionRes.LagAfter = calculateLag(offsetAfter, mark.Low, mark.High)
+			partitionResponses[j] = partitionRes
+
+			commitPartition := kmsg.NewOffsetCommitRequestTopicPartition()
+			commitPartition.Partition = partition.PartitionID
+			commitPartition.Offset = offsetAfter
+			commitPartitions = append(commitPartitions, commitPartition)
+		}
+		res[i] = ResetConsumerGroupOffsetsTopicResponse{
+			TopicName:  topic.TopicName,
+			Partitions: partitionResponses,
+		}
+
+		if len(commitPartitions) > 0 {
+			commitTopic := kmsg.NewOffsetCommitRequestTopic()
+			commitTopic.Topic = topic.TopicName
+			commitTopic.Partitions = commitPartitions
+			commitTopics = append(commitTopics, commitTopic)
+		}
+	}
+
+	if req.DryRun || len(commitTopics) == 0 {
+		return res, nil
+	}
+
+	// 4. Commit new offsets and attach partition errors to the response
+	commitRes, err := s.kafkaSvc.CommitConsumerGroupOffsets(ctx, req.GroupID, commitTopics)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to commit consumer group offsets: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	errorsByTopicPartition := make(map[string]map[int32]error)
+	for _, topic := range commitRes.Topics {
+		errorsByTopicPartition[topic.Topic] = make(map[int32]error)
+		for _, partition := range topic.Partitions {
+			errorsByTopicPartition[topic.Topic][partition.Partition] = kerr.ErrorForCode(partition.ErrorCode)
+		}
+	}
+	for i, topic := range res {
+		for j, partition := range topic.Partitions {
+			if partition.Error != "" {
+				continue
+			}
+			if err := errorsByTopicPartition[topic.TopicName][partition.PartitionID]; err != nil {
+				res[i].Partitions[j].Error = err.Error()
+				// The offset has not been changed, hence we report the unchanged offset
+				res[i].Partitions[j].OffsetAfter = partition.OffsetBefore
+				res[i].Partitions[j].LagAfter = partition.LagBefore
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// getConsumerGroupState returns the state (e.g. Stable, Empty or Dead) of a single consumer group.
+func (s *Service) getConsumerGroupState(ctx context.Context, groupID string) (string, error) {
+	describedGroups, err := s.kafkaSvc.DescribeConsumerGroups(ctx, []string{groupID})
+	if err != nil {
+		return "", err
+	}
+
+	for _, group := range describedGroups.GetDescribedGroups() {
+		if group.Group != groupID {
+			continue
+		}
+		err := kerr.ErrorForCode(group.ErrorCode)
+		if err != nil {
+			return "", err
+		}
+		return group.State, nil
+	}
+
+	return "", fmt.Errorf("consumer group '%v' was not part of the describe groups response", groupID)
+}
+
+// resolveResetTimestamps requests the offsets for all partitions of the given topic that shall be reset to a timestamp.
+// The result is a map of partitionID -> offset.
+func (s *Service) resolveResetTimestamps(ctx context.Context, topic ResetConsumerGroupOffsetsTopic) (map[int32]int64, error) {
+	partitionIDsByTimestamp := make(map[int64][]int32)
+	for _, partition := range topic.Partitions {
+		if partition.Mode != ResetOffsetsModeTimestamp {
+			continue
+		}
+		partitionIDsByTimestamp[partition.Timestamp] = append(partitionIDsByTimestamp[partition.Timestamp], partition.PartitionID)
+	}
+
+	res := make(map[int32]int64)
+	for timestamp, partitionIDs := range partitionIDsByTimestamp {
+		offsets, err := s.requestOffsetsByTimestamp(ctx, topic.TopicName, partitionIDs, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		for partitionID, offset := range offsets {
+			res[partitionID] = offset
+		}
+	}
+
+	return res, nil
+}
+
+// calculateResetOffset returns the offset that shall be committed for the given partition reset. Offsets are
+// capped to the low and high water marks so that the group will not be out of range.
+func calculateResetOffset(partition ResetConsumerGroupOffsetsPartition, offsetBefore int64, offsetByTimestamp int64, low int64, high int64) (int64, error) {
+	var offset int64
+	switch partition.Mode {
+	case ResetOffsetsModeEarliest:
+		offset = low
+	case ResetOffsetsModeLatest:
+		offset = high
+	case ResetOffsetsModeTimestamp:
+		// Kafka returns -1 if there is no record with a timestamp newer than the requested timestamp
+		offset = offsetByTimestamp
+		if offset < 0 {
+			offset = high
+		}
+	case ResetOffsetsModeOffset:
+		offset = partition.Offset
+	case ResetOffsetsModeShiftBy:
+		if offsetBefore < 0 {
+			return -1, fmt.Errorf("can not shift offset because the group has no committed offset for this partition")
+		}
+		offset = offsetBefore + partition.ShiftBy
+	default:
+		return -1, fmt.Errorf("unknown reset mode '%v'", partition.Mode)
+	}
+
+	if offset < low {
+		offset = low
+	}
+	if offset > high {
+		offset = high
+	}
+
+	return offset, nil
+}
+
+// calculateLag returns the lag for a group offset. If the group has no offset all messages of the partition are
+// considered as lag.
+func calculateLag(groupOffset int64, lowWaterMark int64, highWaterMark int64) int64 {
+	if groupOffset < 0 {
+		return highWaterMark - lowWaterMark
+	}
+	lag := highWaterMark - groupOffset
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
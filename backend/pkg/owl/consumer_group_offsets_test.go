@@ -0,0 +1,65 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateResetOffset(t *testing.T) {
+	// All partitions have the low water mark 100 and the high water mark 200
+	tt := []struct {
+		TestName          string
+		Partition         ResetConsumerGroupOffsetsPartition
+		OffsetBefore      int64
+		OffsetByTimestamp int64
+		Expected          int64
+		IsError           bool
+	}{
+		{"earliest", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeEarliest}, 150, -1, 100, false},
+		{"latest", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeLatest}, 150, -1, 200, false},
+		{"timestamp", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeTimestamp, Timestamp: 1600000000000}, 150, 120, 120, false},
+		{"timestamp newer than all records", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeTimestamp, Timestamp: 1600000000000}, 150, -1, 200, false},
+		{"offset", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeOffset, Offset: 130}, 150, -1, 130, false},
+		{"offset below low water mark", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeOffset, Offset: 5}, 150, -1, 100, false},
+		{"offset above high water mark", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeOffset, Offset: 500}, 150, -1, 200, false},
+		{"shift forward", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeShiftBy, ShiftBy: 20}, 150, -1, 170, false},
+		{"shift backward", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeShiftBy, ShiftBy: -20}, 150, -1, 130, false},
+		{"shift beyond high water mark", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeShiftBy, ShiftBy: 80}, 150, -1, 200, false},
+		{"shift before low water mark", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeShiftBy, ShiftBy: -80}, 150, -1, 100, false},
+		{"shift without committed offset", ResetConsumerGroupOffsetsPartition{Mode: ResetOffsetsModeShiftBy, ShiftBy: 10}, -1, -1, -1, true},
+		{"unknown mode", ResetConsumerGroupOffsetsPartition{Mode: "beginning"}, 150, -1, -1, true},
+	}
+
+	for _, test := range tt {
+		t.Run(test.TestName, func(t *testing.T) {
+			offset, err := calculateResetOffset(test.Partition, test.OffsetBefore, test.OffsetByTimestamp, 100, 200)
+			if test.IsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, offset)
+		})
+	}
+}
+
+func TestCalculateLag(t *testing.T) {
+	tt := []struct {
+		TestName    string
+		GroupOffset int64
+		Low         int64
+		High        int64
+		Expected    int64
+	}{
+		{"behind", 150, 100, 200, 50},
+		{"caught up", 200, 100, 200, 0},
+		{"no committed offset", -1, 100, 200, 100},
+		{"offset beyond high water mark", 250, 100, 200, 0},
+		{"empty partition without committed offset", -1, 200, 200, 0},
+	}
+
+	for _, test := range tt {
+		assert.Equal(t, test.Expected, calculateLag(test.GroupOffset, test.Low, test.High), "unexpected lag for %v", test.TestName)
+	}
+}
@@ -0,0 +1,79 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// CreatePartitionsRequest increases the partition count of a single topic.
+type CreatePartitionsRequest struct {
+	TopicName string
+
+	// PartitionCount is the new total number of partitions, it must be greater than the current partition count
+	PartitionCount int32
+
+	// Assignments optionally contains the replicas (broker ids) for each new partition. If set, there must be exactly
+	// one entry for each new partition.
+	Assignments [][]int32
+
+	// ValidateOnly only validates the request on the brokers without creating the partitions
+	ValidateOnly bool
+}
+
+// CreatePartitions adds partitions to an existing topic.
+func (s *Service) CreatePartitions(ctx context.Context, req CreatePartitionsRequest) *rest.Error {
+	topicReq := kmsg.NewCreatePartitionsRequestTopic()
+	topicReq.Topic = req.TopicName
+	topicReq.Count = req.PartitionCount
+	if len(req.Assignments) > 0 {
+		assignments := make([]kmsg.CreatePartitionsRequestTopicAssignment, len(req.Assignments))
+		for i, replicas := range req.Assignments {
+			assignment := kmsg.NewCreatePartitionsRequestTopicAssignment()
+			assignment.Replicas = replicas
+			assignments[i] = assignment
+		}
+		topicReq.Assignment = assignments
+	}
+
+	kRes, err := s.kafkaSvc.CreatePartitions(ctx, []kmsg.CreatePartitionsRequestTopic{topicReq}, req.ValidateOnly)
+	if err != nil {
+		return &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to execute create partitions request: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	for _, topic := range kRes.Topics {
+		if topic.Topic != req.TopicName {
+			continue
+		}
+		err = kerr.ErrorForCode(topic.ErrorCode)
+		if err != nil {
+			errMessage := err.Error()
+			if topic.ErrorMessage != nil {
+				errMessage = fmt.Sprintf("%v: %v", err.Error(), *topic.ErrorMessage)
+			}
+			return &rest.Error{
+				Err:      err,
+				Status:   httpStatusForKafkaError(err),
+				Message:  fmt.Sprintf("Failed to create partitions: %v", errMessage),
+				IsSilent: false,
+			}
+		}
+		return nil
+	}
+
+	return &rest.Error{
+		Err:      fmt.Errorf("topic '%v' was not part of the create partitions response", req.TopicName),
+		Status:   http.StatusInternalServerError,
+		Message:  "Topic was not part of the create partitions response",
+		IsSilent: false,
+	}
+}
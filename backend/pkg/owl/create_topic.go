@@ -0,0 +1,130 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// CreateTopicRequest carries all options for creating a single topic.
+type CreateTopicRequest struct {
+	TopicName string
+
+	// PartitionCount and ReplicationFactor must be -1 if replica assignments are specified. -1 uses the broker's
+	// default values.
+	PartitionCount     int32
+	ReplicationFactor  int16
+	ReplicaAssignments []CreateTopicRequestReplicaAssignment
+
+	Configs []CreateTopicRequestConfig
+
+	// ValidateOnly only validates the request on the brokers without creating the topic
+	ValidateOnly bool
+}
+
+// CreateTopicRequestReplicaAssignment places a single partition on the given brokers
+type CreateTopicRequestReplicaAssignment struct {
+	PartitionID int32
+	Replicas    []int32
+}
+
+// CreateTopicRequestConfig is a topic config that shall be set on the new topic
+type CreateTopicRequestConfig struct {
+	Name  string
+	Value *string
+}
+
+// CreateTopicResponse describes the topic which has been created (or would have been created if validateOnly is set).
+type CreateTopicResponse struct {
+	TopicName         string                      `json:"topicName"`
+	PartitionCount    int32                       `json:"partitionCount"`    // -1 if the broker does not report it (Kafka < 2.4)
+	ReplicationFactor int16                       `json:"replicationFactor"` // -1 if the broker does not report it (Kafka < 2.4)
+	Configs           []CreateTopicResponseConfig `json:"configs"`
+	ValidateOnly      bool                        `json:"validateOnly"`
+}
+
+// CreateTopicResponseConfig is a config of the created topic as reported by the broker
+type CreateTopicResponseConfig struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value"`
+}
+
+// CreateTopic creates a new topic with the given options.
+func (s *Service) CreateTopic(ctx context.Context, req CreateTopicRequest) (CreateTopicResponse, *rest.Error) {
+	topicReq := kmsg.NewCreateTopicsRequestTopic()
+	topicReq.Topic = req.TopicName
+	topicReq.NumPartitions = req.PartitionCount
+	topicReq.ReplicationFactor = req.ReplicationFactor
+
+	assignments := make([]kmsg.CreateTopicsRequestTopicReplicaAssignment, len(req.ReplicaAssignments))
+	for i, assignment := range req.ReplicaAssignments {
+		assignmentReq := kmsg.NewCreateTopicsRequestTopicReplicaAssignment()
+		assignmentReq.Partition = assignment.PartitionID
+		assignmentReq.Replicas = assignment.Replicas
+		assignments[i] = assignmentReq
+	}
+	topicReq.ReplicaAssignment = assignments
+
+	configs := make([]kmsg.CreateTopicsRequestTopicConfig, len(req.Configs))
+	for i, config := range req.Configs {
+		configReq := kmsg.NewCreateTopicsRequestTopicConfig()
+		configReq.Name = config.Name
+		configReq.Value = config.Value
+		configs[i] = configReq
+	}
+	topicReq.Configs = configs
+
+	kRes, err := s.kafkaSvc.CreateTopics(ctx, []kmsg.CreateTopicsRequestTopic{topicReq}, req.ValidateOnly)
+	if err != nil {
+		return CreateTopicResponse{}, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to execute create topic request: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	if len(kRes.Topics) != 1 {
+		return CreateTopicResponse{}, &rest.Error{
+			Err:      fmt.Errorf("expected exactly one topic in create topics response, but got '%d'", len(kRes.Topics)),
+			Status:   http.StatusInternalServerError,
+			Message:  "Unexpected number of topics in create topics response",
+			IsSilent: false,
+		}
+	}
+	topic := kRes.Topics[0]
+
+	err = kerr.ErrorForCode(topic.ErrorCode)
+	if err != nil {
+		errMessage := err.Error()
+		if topic.ErrorMessage != nil {
+			errMessage = fmt.Sprintf("%v: %v", err.Error(), *topic.ErrorMessage)
+		}
+		return CreateTopicResponse{}, &rest.Error{
+			Err:      err,
+			Status:   httpStatusForKafkaError(err),
+			Message:  fmt.Sprintf("Failed to create topic: %v", errMessage),
+			IsSilent: false,
+		}
+	}
+
+	responseConfigs := make([]CreateTopicResponseConfig, len(topic.Configs))
+	for i, config := range topic.Configs {
+		responseConfigs[i] = CreateTopicResponseConfig{
+			Name:  config.Name,
+			Value: config.Value,
+		}
+	}
+
+	return CreateTopicResponse{
+		TopicName:         topic.Topic,
+		PartitionCount:    topic.NumPartitions,
+		ReplicationFactor: topic.ReplicationFactor,
+		Configs:           responseConfigs,
+		ValidateOnly:      req.ValidateOnly,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// DeleteConsumerGroupOffsetsTopic contains the partitions of a single topic whose group offsets shall be deleted.
+type DeleteConsumerGroupOffsetsTopic struct {
+	TopicName    string
+	PartitionIDs []int32
+}
+
+// DeleteConsumerGroupOffsetsResponse reports the results of deleting the group offsets of a single topic
+type DeleteConsumerGroupOffsetsResponse struct {
+	TopicName  string                                        `json:"topicName"`
+	Partitions []DeleteConsumerGroupOffsetsPartitionResponse `json:"partitions"`
+}
+
+// DeleteConsumerGroupOffsetsPartitionResponse reports whether the group offset of a single partition could be deleted
+type DeleteConsumerGroupOffsetsPartitionResponse struct {
+	PartitionID  int32   `json:"partitionId"`
+	ErrorCode    string  `json:"errorCode"`
+	ErrorMessage *string `json:"errorMessage"`
+}
+
+// DeleteConsumerGroup deletes a consumer group along with all its committed offsets.
+func (s *Service) DeleteConsumerGroup(ctx context.Context, groupID string) error {
+	kRes, err := s.kafkaSvc.DeleteConsumerGroups(ctx, []string{groupID})
+	if err != nil {
+		return err
+	}
+
+	for _, group := range kRes.Groups {
+		if group.Group != groupID {
+			continue
+		}
+		err = kerr.ErrorForCode(group.ErrorCode)
+		if err != nil {
+			return fmt.Errorf("failed to delete consumer group. Inner error: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("consumer group '%v' was not part of the delete groups response", groupID)
+}
+
+// DeleteConsumerGroupOffsets deletes the committed group offsets for the given topic partitions.
+func (s *Service) DeleteConsumerGroupOffsets(ctx context.Context, groupID string, topics []DeleteConsumerGroupOffsetsTopic) ([]DeleteConsumerGroupOffsetsResponse, error) {
+	topicReqs := make([]kmsg.OffsetDeleteRequestTopic, len(topics))
+	for i, topic := range topics {
+		partitionReqs := make([]kmsg.OffsetDeleteRequestTopicPartition, len(topic.PartitionIDs))
+		for j, partitionID := range topic.PartitionIDs {
+			partitionReq := kmsg.NewOffsetDeleteRequestTopicPartition()
+			partitionReq.Partition = partitionID
+			partitionReqs[j] = partitionReq
+		}
+		topicReq := kmsg.NewOffsetDeleteRequestTopic()
+		topicReq.Topic = topic.TopicName
+		topicReq.Partitions = partitionReqs
+		topicReqs[i] = topicReq
+	}
+
+	kRes, err := s.kafkaSvc.DeleteConsumerGroupOffsets(ctx, groupID, topicReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = kerr.ErrorForCode(kRes.ErrorCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete group offsets. Inner error: %w", err)
+	}
+
+	res := make([]DeleteConsumerGroupOffsetsResponse, len(kRes.Topics))
+	for i, topic := range kRes.Topics {
+		partitions := make([]DeleteConsumerGroupOffsetsPartitionResponse, len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			partitions[j] = DeleteConsumerGroupOffsetsPartitionResponse{PartitionID: partition.Partition}
+			kErr := kerr.TypedErrorForCode(partition.ErrorCode)
+			if kErr != nil {
+				description := kErr.Description
+				partitions[j].ErrorCode = kErr.Message
+				partitions[j].ErrorMessage = &description
+			}
+		}
+		res[i] = DeleteConsumerGroupOffsetsResponse{
+			TopicName:  topic.Topic,
+			Partitions: partitions,
+		}
+	}
+
+	return res, nil
+}
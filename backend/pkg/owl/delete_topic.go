@@ -0,0 +1,64 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+// DeleteTopic deletes the given topic including all its data. Kafka does not support a validate only mode for
+// topic deletions, therefore we only check whether the topic exists if validateOnly is set.
+func (s *Service) DeleteTopic(ctx context.Context, topicName string, validateOnly bool) *rest.Error {
+	if validateOnly {
+		_, err := s.kafkaSvc.ListPartitionIDs(ctx, topicName)
+		if err != nil {
+			return &rest.Error{
+				Err:      err,
+				Status:   http.StatusNotFound,
+				Message:  fmt.Sprintf("Failed to get partitions for topic '%v': %v", topicName, err.Error()),
+				IsSilent: false,
+			}
+		}
+		return nil
+	}
+
+	kRes, err := s.kafkaSvc.DeleteTopics(ctx, []string{topicName})
+	if err != nil {
+		return &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Failed to execute delete topic request: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	for _, topic := range kRes.Topics {
+		if topic.Topic == nil || *topic.Topic != topicName {
+			continue
+		}
+		err = kerr.ErrorForCode(topic.ErrorCode)
+		if err != nil {
+			errMessage := err.Error()
+			if topic.ErrorMessage != nil {
+				errMessage = fmt.Sprintf("%v: %v", err.Error(), *topic.ErrorMessage)
+			}
+			return &rest.Error{
+				Err:      err,
+				Status:   httpStatusForKafkaError(err),
+				Message:  fmt.Sprintf("Failed to delete topic: %v", errMessage),
+				IsSilent: false,
+			}
+		}
+		return nil
+	}
+
+	return &rest.Error{
+		Err:      fmt.Errorf("topic '%v' was not part of the delete topics response", topicName),
+		Status:   http.StatusInternalServerError,
+		Message:  "Topic was not part of the delete topics response",
+		IsSilent: false,
+	}
+}
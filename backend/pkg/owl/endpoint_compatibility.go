@@ -38,11 +38,51 @@ func (s *Service) GetEndpointCompatibility(ctx context.Context) (EndpointCompati
 			Method:   "GET",
 			Requests: []kmsg.Request{&kmsg.DescribeConfigsRequest{}},
 		},
+		{
+			URL:      "/api/topics",
+			Method:   "POST",
+			Requests: []kmsg.Request{&kmsg.CreateTopicsRequest{}},
+		},
+		{
+			URL:      "/api/topics/{topicName}",
+			Method:   "DELETE",
+			Requests: []kmsg.Request{&kmsg.DeleteTopicsRequest{}},
+		},
+		{
+			URL:      "/api/topics/{topicName}/partitions",
+			Method:   "POST",
+			Requests: []kmsg.Request{&kmsg.CreatePartitionsRequest{}},
+		},
+		{
+			URL:      "/api/acls",
+			Method:   "POST",
+			Requests: []kmsg.Request{&kmsg.CreateACLsRequest{}},
+		},
+		{
+			URL:      "/api/acls",
+			Method:   "DELETE",
+			Requests: []kmsg.Request{&kmsg.DeleteACLsRequest{}},
+		},
 		{
 			URL:      "/api/consumer-groups",
 			Method:   "GET",
 			Requests: []kmsg.Request{&kmsg.DescribeGroupsRequest{}, &kmsg.ListGroupsRequest{}},
 		},
+		{
+			URL:      "/api/consumer-groups/{groupId}/offsets",
+			Method:   "PATCH",
+			Requests: []kmsg.Request{&kmsg.DescribeGroupsRequest{}, &kmsg.OffsetFetchRequest{}, &kmsg.OffsetCommitRequest{}},
+		},
+		{
+			URL:      "/api/consumer-groups/{groupId}",
+			Method:   "DELETE",
+			Requests: []kmsg.Request{&kmsg.DeleteGroupsRequest{}},
+		},
+		{
+			URL:      "/api/consumer-groups/{groupId}/offsets",
+			Method:   "DELETE",
+			Requests: []kmsg.Request{&kmsg.OffsetDeleteRequest{}},
+		},
 		{
 			URL:      "/api/operations/reassign-partitions",
 			Method:   "GET",
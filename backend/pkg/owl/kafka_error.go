@@ -2,6 +2,8 @@ package owl
 
 import (
 	"fmt"
+	"net/http"
+
 	"github.com/twmb/franz-go/pkg/kerr"
 )
 
@@ -27,3 +29,20 @@ func newKafkaError(errCode int16) *KafkaError {
 		Description: typedError.Description,
 	}
 }
+
+// httpStatusForKafkaError returns a suitable HTTP status code for Kafka errors which are returned by admin requests.
+func httpStatusForKafkaError(err error) int {
+	switch err {
+	case kerr.TopicAlreadyExists:
+		return http.StatusConflict
+	case kerr.UnknownTopicOrPartition, kerr.UnknownTopicID, kerr.GroupIDNotFound:
+		return http.StatusNotFound
+	case kerr.TopicAuthorizationFailed, kerr.GroupAuthorizationFailed, kerr.ClusterAuthorizationFailed:
+		return http.StatusForbidden
+	case kerr.InvalidTopicException, kerr.InvalidPartitions, kerr.InvalidReplicationFactor,
+		kerr.InvalidReplicaAssignment, kerr.InvalidConfig, kerr.InvalidRequest, kerr.PolicyViolation:
+		return http.StatusBadRequest
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
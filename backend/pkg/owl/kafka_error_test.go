@@ -0,0 +1,34 @@
+package owl
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestHttpStatusForKafkaError(t *testing.T) {
+	tt := []struct {
+		err      error
+		expected int
+	}{
+		{kerr.TopicAlreadyExists, http.StatusConflict},
+		{kerr.UnknownTopicOrPartition, http.StatusNotFound},
+		{kerr.GroupIDNotFound, http.StatusNotFound},
+		{kerr.TopicAuthorizationFailed, http.StatusForbidden},
+		{kerr.ClusterAuthorizationFailed, http.StatusForbidden},
+		{kerr.InvalidPartitions, http.StatusBadRequest},
+		{kerr.InvalidReplicationFactor, http.StatusBadRequest},
+		{kerr.InvalidConfig, http.StatusBadRequest},
+		{kerr.PolicyViolation, http.StatusBadRequest},
+		{kerr.RequestTimedOut, http.StatusServiceUnavailable},
+		{kerr.NotController, http.StatusServiceUnavailable},
+		{fmt.Errorf("connection refused"), http.StatusServiceUnavailable},
+	}
+
+	for _, table := range tt {
+		assert.Equal(t, table.expected, httpStatusForKafkaError(table.err), "unexpected status for error '%v'", table.err)
+	}
+}
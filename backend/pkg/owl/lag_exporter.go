@@ -0,0 +1,129 @@
+package owl
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// lagExporter exports the lags of all consumer groups, which are sampled by the lagSampler, as Prometheus metrics.
+// It implements prometheus.Collector so that the metrics of groups which no longer exist will disappear with the
+// next collection.
+type lagExporter struct {
+	svc    *Service
+	cfg    ConfigLagExporter
+	logger *zap.Logger
+
+	allowedGroups []*regexp.Regexp
+	ignoredGroups []*regexp.Regexp
+	lagDesc       *prometheus.Desc
+
+	mutex sync.RWMutex
+	lags  map[string]*ConsumerGroupLag
+}
+
+func newLagExporter(svc *Service, cfg ConfigLagExporter, metricsNamespace string, clusterName string) (*lagExporter, error) {
+	allowedGroups, err := compileRegexes(cfg.AllowedGroups)
+	if err != nil {
+		return nil, err
+	}
+	ignoredGroups, err := compileRegexes(cfg.IgnoredGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := []string{"group", "topic", "partition"}
+	help := "Consumer group lag in number of messages per partition"
+	if cfg.SummedOnly {
+		labels = []string{"group", "topic"}
+		help = "Consumer group lag in number of messages summed over all partitions of a topic"
+	}
+	lagDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "consumergroup", "lag"),
+		help,
+		labels,
+		prometheus.Labels{"cluster": clusterName},
+	)
+
+	return &lagExporter{
+		svc:           svc,
+		cfg:           cfg,
+		logger:        svc.logger.With(zap.String("source", "lag_exporter")),
+		allowedGroups: allowedGroups,
+		ignoredGroups: ignoredGroups,
+		lagDesc:       lagDesc,
+		lags:          make(map[string]*ConsumerGroupLag),
+	}, nil
+}
+
+// onLagSample implements lagSampleConsumer, the lags of the exported groups replace the previous lags.
+func (e *lagExporter) onLagSample(_ time.Time, lags map[string]*ConsumerGroupLag) {
+	exportedLags := make(map[string]*ConsumerGroupLag)
+	for groupID, groupLag := range lags {
+		if e.isGroupExported(groupID) {
+			exportedLags[groupID] = groupLag
+		}
+	}
+
+	e.mutex.Lock()
+	e.lags = exportedLags
+	e.mutex.Unlock()
+}
+
+// isGroupSampled implements lagSampleConsumer
+func (e *lagExporter) isGroupSampled(groupID string) bool {
+	return e.isGroupExported(groupID)
+}
+
+// isGroupExported returns true if the group matches any of the allowed groups (or no allowed groups are configured)
+// and none of the ignored groups.
+func (e *lagExporter) isGroupExported(groupID string) bool {
+	for _, regex := range e.ignoredGroups {
+		if regex.MatchString(groupID) {
+			return false
+		}
+	}
+
+	if len(e.allowedGroups) == 0 {
+		return true
+	}
+	for _, regex := range e.allowedGroups {
+		if regex.MatchString(groupID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Describe implements prometheus.Collector
+func (e *lagExporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- e.lagDesc
+}
+
+// Collect implements prometheus.Collector
+func (e *lagExporter) Collect(ch chan<- prometheus.Metric) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	for groupID, groupLag := range e.lags {
+		for _, topicLag := range groupLag.TopicLags {
+			if e.cfg.SummedOnly {
+				ch <- prometheus.MustNewConstMetric(e.lagDesc, prometheus.GaugeValue, float64(topicLag.SummedLag), groupID, topicLag.Topic)
+				continue
+			}
+
+			for _, partitionLag := range topicLag.PartitionLags {
+				if partitionLag.Error != "" {
+					continue
+				}
+				partitionID := strconv.Itoa(int(partitionLag.PartitionID))
+				ch <- prometheus.MustNewConstMetric(e.lagDesc, prometheus.GaugeValue, float64(partitionLag.Lag), groupID, topicLag.Topic, partitionID)
+			}
+		}
+	}
+}
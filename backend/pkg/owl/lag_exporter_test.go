@@ -0,0 +1,93 @@
+package owl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestLagExporter(t *testing.T, cfg ConfigLagExporter) *lagExporter {
+	exporter, err := newLagExporter(&Service{logger: zap.NewNop()}, cfg, "kowl", "test")
+	require.NoError(t, err)
+	exporter.lags = map[string]*ConsumerGroupLag{
+		"orders-service": {
+			GroupID: "orders-service",
+			TopicLags: []*TopicLag{
+				{
+					Topic:     "orders",
+					SummedLag: 15,
+					PartitionLags: []PartitionLag{
+						{PartitionID: 0, Lag: 10},
+						{PartitionID: 1, Lag: 5},
+						{PartitionID: 2, Lag: -1, Error: "NOT_LEADER_FOR_PARTITION"},
+					},
+				},
+			},
+		},
+	}
+
+	return exporter
+}
+
+func TestLagExporter_IsGroupExported(t *testing.T) {
+	tt := []struct {
+		TestName      string
+		AllowedGroups []string
+		IgnoredGroups []string
+		GroupID       string
+		Expected      bool
+	}{
+		{"no filters", nil, nil, "orders-service", true},
+		{"allowed", []string{"orders-.*"}, nil, "orders-service", true},
+		{"not allowed", []string{"orders-.*"}, nil, "payments-service", false},
+		{"allowed regex must match whole group id", []string{"orders"}, nil, "orders-service", false},
+		{"ignored", nil, []string{".*-console-consumer-.*"}, "kafka-console-consumer-123", false},
+		{"ignored takes precedence over allowed", []string{"orders-.*"}, []string{"orders-test"}, "orders-test", false},
+	}
+
+	for _, test := range tt {
+		t.Run(test.TestName, func(t *testing.T) {
+			exporter := newTestLagExporter(t, ConfigLagExporter{AllowedGroups: test.AllowedGroups, IgnoredGroups: test.IgnoredGroups})
+			assert.Equal(t, test.Expected, exporter.isGroupExported(test.GroupID))
+		})
+	}
+}
+
+func TestLagExporter_Collect(t *testing.T) {
+	exporter := newTestLagExporter(t, ConfigLagExporter{})
+	expected := `
+# HELP kowl_consumergroup_lag Consumer group lag in number of messages per partition
+# TYPE kowl_consumergroup_lag gauge
+kowl_consumergroup_lag{cluster="test",group="orders-service",partition="0",topic="orders"} 10
+kowl_consumergroup_lag{cluster="test",group="orders-service",partition="1",topic="orders"} 5
+`
+	err := testutil.CollectAndCompare(exporter, strings.NewReader(expected), "kowl_consumergroup_lag")
+	assert.NoError(t, err)
+}
+
+func TestLagExporter_CollectSummedOnly(t *testing.T) {
+	exporter := newTestLagExporter(t, ConfigLagExporter{SummedOnly: true})
+	expected := `
+# HELP kowl_consumergroup_lag Consumer group lag in number of messages summed over all partitions of a topic
+# TYPE kowl_consumergroup_lag gauge
+kowl_consumergroup_lag{cluster="test",group="orders-service",topic="orders"} 15
+`
+	err := testutil.CollectAndCompare(exporter, strings.NewReader(expected), "kowl_consumergroup_lag")
+	assert.NoError(t, err)
+}
+
+func TestLagExporter_OnLagSample(t *testing.T) {
+	exporter := newTestLagExporter(t, ConfigLagExporter{IgnoredGroups: []string{"payments-.*"}})
+	exporter.onLagSample(time.Now(), map[string]*ConsumerGroupLag{
+		"orders-service":   {GroupID: "orders-service"},
+		"payments-service": {GroupID: "payments-service"},
+	})
+
+	assert.Equal(t, map[string]*ConsumerGroupLag{"orders-service": {GroupID: "orders-service"}}, exporter.lags)
+	assert.False(t, exporter.isGroupSampled("payments-service"))
+}
@@ -0,0 +1,345 @@
+package owl
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LagSample is the lag of a consumer group summed over all its topics at a point in time.
+type LagSample struct {
+	Timestamp int64 `json:"timestamp"` // Unix timestamp in milliseconds
+	SummedLag int64 `json:"summedLag"`
+
+	// SummedHighWaterMark sums the high water marks of all partitions the group has offsets for. Its growth is the
+	// number of produced messages.
+	SummedHighWaterMark int64 `json:"summedHighWaterMark"`
+
+	// TopicsHash identifies the set of topics the group has offsets for. The sums of samples with different topics
+	// can't be compared, e.g. the summed high water mark jumps if a group starts consuming another topic.
+	TopicsHash string `json:"topicsHash"`
+}
+
+// ConsumerGroupLagHistory contains the lag samples of a consumer group within the requested window, along with the
+// rates calculated from the first and last sample with the group's current topics. Rates are nil if there are not
+// enough samples.
+type ConsumerGroupLagHistory struct {
+	IsEnabled bool        `json:"isEnabled"`
+	GroupID   string      `json:"groupId"`
+	Samples   []LagSample `json:"samples"`
+
+	// ConsumptionRate and ProductionRate are in messages per second
+	ConsumptionRate *float64 `json:"consumptionRate"`
+	ProductionRate  *float64 `json:"productionRate"`
+
+	// TimeToZeroLagSeconds estimates when the group will have caught up, given the current rates. It is nil if the
+	// lag is not decreasing.
+	TimeToZeroLagSeconds *float64 `json:"timeToZeroLagSeconds"`
+}
+
+// GetConsumerGroupLagHistory returns the lag samples of the given group which are not older than the window.
+func (s *Service) GetConsumerGroupLagHistory(groupID string, window time.Duration) *ConsumerGroupLagHistory {
+	if s.lagHistory == nil {
+		return &ConsumerGroupLagHistory{
+			IsEnabled: false,
+			GroupID:   groupID,
+			Samples:   []LagSample{},
+		}
+	}
+
+	since := time.Now().Add(-window)
+	history := calculateLagHistory(s.lagHistory.store.samples(groupID, since))
+	history.GroupID = groupID
+
+	return history
+}
+
+// calculateLagHistory calculates the rates for the given samples, which must be ordered by time. Rates are only
+// calculated from the latest samples with the same topics as the last sample.
+func calculateLagHistory(samples []LagSample) *ConsumerGroupLagHistory {
+	history := &ConsumerGroupLagHistory{
+		IsEnabled: true,
+		Samples:   samples,
+	}
+	if len(samples) < 2 {
+		return history
+	}
+
+	last := samples[len(samples)-1]
+	firstIndex := len(samples) - 1
+	for firstIndex > 0 && samples[firstIndex-1].TopicsHash == last.TopicsHash {
+		firstIndex--
+	}
+	first := samples[firstIndex]
+	seconds := float64(last.Timestamp-first.Timestamp) / 1000
+	if seconds <= 0 {
+		return history
+	}
+
+	productionRate := float64(last.SummedHighWaterMark-first.SummedHighWaterMark) / seconds
+	if productionRate < 0 {
+		// High water marks decrease if a topic has been recreated, in this case the rates can't be calculated
+		return history
+	}
+	lagRate := float64(last.SummedLag-first.SummedLag) / seconds
+	consumptionRate := productionRate - lagRate
+	if consumptionRate < 0 {
+		// Lags of partitions without progress are capped at 0, hence it might seem that messages are "unconsumed"
+		consumptionRate = 0
+	}
+	history.ProductionRate = &productionRate
+	history.ConsumptionRate = &consumptionRate
+
+	switch {
+	case last.SummedLag == 0:
+		timeToZero := float64(0)
+		history.TimeToZeroLagSeconds = &timeToZero
+	case lagRate < 0:
+		timeToZero := float64(last.SummedLag) / -lagRate
+		history.TimeToZeroLagSeconds = &timeToZero
+	}
+
+	return history
+}
+
+// lagHistory stores the consumer group lags, which are sampled by the lagSampler, in a bounded lagHistoryStore.
+type lagHistory struct {
+	svc    *Service
+	cfg    ConfigLagHistory
+	logger *zap.Logger
+	store  *lagHistoryStore
+
+	// lastSample is the time of the latest sample, it is only accessed by the sampler's goroutine
+	lastSample time.Time
+}
+
+func newLagHistory(svc *Service, cfg ConfigLagHistory) *lagHistory {
+	return &lagHistory{
+		svc:    svc,
+		cfg:    cfg,
+		logger: svc.logger.With(zap.String("source", "lag_history")),
+		store:  newLagHistoryStore(cfg.maxSamples(), cfg.Retention),
+	}
+}
+
+// loadPersisted loads the persisted history, if configured. It must be called before the sampler is started.
+func (h *lagHistory) loadPersisted() {
+	if h.cfg.PersistencePath == "" {
+		return
+	}
+	err := h.store.load(h.cfg.PersistencePath)
+	if err != nil {
+		h.logger.Warn("failed to load persisted lag history, starting with an empty history", zap.Error(err))
+	}
+}
+
+// isGroupSampled implements lagSampleConsumer, the history contains all consumer groups.
+func (h *lagHistory) isGroupSampled(_ string) bool {
+	return true
+}
+
+// onLagSample implements lagSampleConsumer and adds a sample for each consumer group. The sampler's interval is
+// shorter than the history's interval if the lag exporter refreshes more often, such samples are skipped so that
+// the stored samples still cover the whole retention.
+func (h *lagHistory) onLagSample(now time.Time, lags map[string]*ConsumerGroupLag) {
+	if !h.lastSample.IsZero() && now.Sub(h.lastSample) < h.cfg.Interval*9/10 {
+		return
+	}
+	h.lastSample = now
+
+	for groupID, groupLag := range lags {
+		h.store.add(groupID, newLagSample(now, groupLag))
+	}
+	h.store.purge(now)
+
+	if h.cfg.PersistencePath != "" {
+		err := h.store.save(h.cfg.PersistencePath)
+		if err != nil {
+			h.logger.Warn("failed to persist lag history", zap.Error(err))
+		}
+	}
+}
+
+// newLagSample sums the lags and high water marks of all topics of the group.
+func newLagSample(now time.Time, groupLag *ConsumerGroupLag) LagSample {
+	sample := LagSample{Timestamp: now.UnixNano() / int64(time.Millisecond)}
+	topics := make([]string, len(groupLag.TopicLags))
+	for i, topicLag := range groupLag.TopicLags {
+		sample.SummedLag += topicLag.SummedLag
+		sample.SummedHighWaterMark += topicLag.summedHighWaterMark
+		topics[i] = topicLag.Topic
+	}
+	sort.Strings(topics)
+
+	hash := fnv.New64a()
+	for _, topic := range topics {
+		// The null byte separates the topic names, it is not allowed within topic names
+		hash.Write([]byte(topic))
+		hash.Write([]byte{0})
+	}
+	sample.TopicsHash = strconv.FormatUint(hash.Sum64(), 16)
+
+	return sample
+}
+
+// lagHistoryStore keeps a ring buffer of samples for each consumer group. Groups whose latest sample is older than
+// the retention (e.g. because they have been deleted) are purged.
+type lagHistoryStore struct {
+	maxSamples int
+	retention  time.Duration
+
+	mutex  sync.RWMutex
+	groups map[string]*lagSampleRing
+}
+
+func newLagHistoryStore(maxSamples int, retention time.Duration) *lagHistoryStore {
+	return &lagHistoryStore{
+		maxSamples: maxSamples,
+		retention:  retention,
+		groups:     make(map[string]*lagSampleRing),
+	}
+}
+
+func (s *lagHistoryStore) add(groupID string, sample LagSample) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ring, exists := s.groups[groupID]
+	if !exists {
+		ring = newLagSampleRing(s.maxSamples)
+		s.groups[groupID] = ring
+	}
+	ring.add(sample)
+}
+
+// samples returns the group's samples which are not older than since, ordered by time.
+func (s *lagHistoryStore) samples(groupID string, since time.Time) []LagSample {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ring, exists := s.groups[groupID]
+	if !exists {
+		return []LagSample{}
+	}
+
+	sinceMs := since.UnixNano() / int64(time.Millisecond)
+	samples := make([]LagSample, 0)
+	for _, sample := range ring.ordered() {
+		if sample.Timestamp >= sinceMs {
+			samples = append(samples, sample)
+		}
+	}
+
+	return samples
+}
+
+// purge removes the history of all groups which have not been sampled within the retention.
+func (s *lagHistoryStore) purge(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	oldestMs := now.Add(-s.retention).UnixNano() / int64(time.Millisecond)
+	for groupID, ring := range s.groups {
+		if ring.latest().Timestamp < oldestMs {
+			delete(s.groups, groupID)
+		}
+	}
+}
+
+// save writes all samples as JSON to the given path. The file is replaced atomically so that a crash while writing
+// does not corrupt the persisted history.
+func (s *lagHistoryStore) save(path string) error {
+	s.mutex.RLock()
+	history := make(map[string][]LagSample, len(s.groups))
+	for groupID, ring := range s.groups {
+		history[groupID] = ring.ordered()
+	}
+	s.mutex.RUnlock()
+
+	content, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to encode lag history: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, content, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write lag history: %w", err)
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return fmt.Errorf("failed to replace lag history: %w", err)
+	}
+
+	return nil
+}
+
+// load adds the samples from the given path, which have been written by save. It is not an error if the file
+// doesn't exist yet.
+func (s *lagHistoryStore) load(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read lag history: %w", err)
+	}
+
+	var history map[string][]LagSample
+	err = json.Unmarshal(content, &history)
+	if err != nil {
+		return fmt.Errorf("failed to decode lag history: %w", err)
+	}
+
+	for groupID, samples := range history {
+		for _, sample := range samples {
+			s.add(groupID, sample)
+		}
+	}
+	s.purge(time.Now())
+
+	return nil
+}
+
+// lagSampleRing is a fixed size ring buffer which overwrites the oldest sample once it is full.
+type lagSampleRing struct {
+	samples []LagSample
+	next    int // Index at which the next sample will be written
+	count   int
+}
+
+func newLagSampleRing(size int) *lagSampleRing {
+	return &lagSampleRing{
+		samples: make([]LagSample, size),
+	}
+}
+
+func (r *lagSampleRing) add(sample LagSample) {
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.count < len(r.samples) {
+		r.count++
+	}
+}
+
+// ordered returns a copy of all samples, starting with the oldest one.
+func (r *lagSampleRing) ordered() []LagSample {
+	ordered := make([]LagSample, 0, r.count)
+	start := (r.next - r.count + len(r.samples)) % len(r.samples)
+	for i := 0; i < r.count; i++ {
+		ordered = append(ordered, r.samples[(start+i)%len(r.samples)])
+	}
+
+	return ordered
+}
+
+func (r *lagSampleRing) latest() LagSample {
+	return r.samples[(r.next-1+len(r.samples))%len(r.samples)]
+}
@@ -0,0 +1,176 @@
+package owl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLagSampleRing(t *testing.T) {
+	ring := newLagSampleRing(3)
+	for i := int64(1); i <= 5; i++ {
+		ring.add(LagSample{Timestamp: i})
+	}
+
+	assert.Equal(t, []LagSample{{Timestamp: 3}, {Timestamp: 4}, {Timestamp: 5}}, ring.ordered())
+	assert.Equal(t, int64(5), ring.latest().Timestamp)
+}
+
+func TestCalculateLagHistory(t *testing.T) {
+	tt := []struct {
+		TestName        string
+		Samples         []LagSample
+		ConsumptionRate *float64
+		ProductionRate  *float64
+		TimeToZeroLag   *float64
+	}{
+		{
+			TestName: "not enough samples",
+			Samples:  []LagSample{{Timestamp: 0, SummedLag: 100, SummedHighWaterMark: 1000}},
+		},
+		{
+			TestName: "catching up",
+			Samples: []LagSample{
+				{Timestamp: 0, SummedLag: 1000, SummedHighWaterMark: 5000},
+				{Timestamp: 30000, SummedLag: 850, SummedHighWaterMark: 5150},
+				{Timestamp: 60000, SummedLag: 700, SummedHighWaterMark: 5300},
+			},
+			// 5 msg/s produced, lag decreases by 5 msg/s
+			ConsumptionRate: floatPtr(10),
+			ProductionRate:  floatPtr(5),
+			TimeToZeroLag:   floatPtr(140),
+		},
+		{
+			TestName: "falling behind",
+			Samples: []LagSample{
+				{Timestamp: 0, SummedLag: 100, SummedHighWaterMark: 5000},
+				{Timestamp: 10000, SummedLag: 200, SummedHighWaterMark: 5200},
+			},
+			ConsumptionRate: floatPtr(10),
+			ProductionRate:  floatPtr(20),
+		},
+		{
+			TestName: "no lag",
+			Samples: []LagSample{
+				{Timestamp: 0, SummedLag: 0, SummedHighWaterMark: 5000},
+				{Timestamp: 10000, SummedLag: 0, SummedHighWaterMark: 5100},
+			},
+			ConsumptionRate: floatPtr(10),
+			ProductionRate:  floatPtr(10),
+			TimeToZeroLag:   floatPtr(0),
+		},
+		{
+			TestName: "group started consuming another topic",
+			Samples: []LagSample{
+				{Timestamp: 0, SummedLag: 100, SummedHighWaterMark: 5000, TopicsHash: "orders"},
+				{Timestamp: 10000, SummedLag: 150, SummedHighWaterMark: 95000, TopicsHash: "orders,payments"},
+				{Timestamp: 20000, SummedLag: 100, SummedHighWaterMark: 95100, TopicsHash: "orders,payments"},
+			},
+			// Only the samples with both topics are used, instead of reporting 90k produced messages
+			ConsumptionRate: floatPtr(15),
+			ProductionRate:  floatPtr(10),
+			TimeToZeroLag:   floatPtr(20),
+		},
+		{
+			TestName: "group consumes different topics since the last sample",
+			Samples: []LagSample{
+				{Timestamp: 0, SummedLag: 100, SummedHighWaterMark: 5000, TopicsHash: "orders"},
+				{Timestamp: 10000, SummedLag: 10, SummedHighWaterMark: 200, TopicsHash: "payments"},
+			},
+		},
+		{
+			TestName: "topic has been recreated",
+			Samples: []LagSample{
+				{Timestamp: 0, SummedLag: 100, SummedHighWaterMark: 5000},
+				{Timestamp: 10000, SummedLag: 0, SummedHighWaterMark: 0},
+			},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.TestName, func(t *testing.T) {
+			history := calculateLagHistory(test.Samples)
+			assert.True(t, history.IsEnabled)
+			assert.Equal(t, test.Samples, history.Samples)
+			assert.Equal(t, test.ConsumptionRate, history.ConsumptionRate)
+			assert.Equal(t, test.ProductionRate, history.ProductionRate)
+			assert.Equal(t, test.TimeToZeroLag, history.TimeToZeroLagSeconds)
+		})
+	}
+}
+
+func TestLagHistory_OnLagSample(t *testing.T) {
+	history := newLagHistory(&Service{logger: zap.NewNop()}, ConfigLagHistory{Interval: 30 * time.Second, Retention: time.Hour})
+	lags := func(topics ...string) map[string]*ConsumerGroupLag {
+		groupLag := &ConsumerGroupLag{GroupID: "orders-service"}
+		for _, topic := range topics {
+			groupLag.TopicLags = append(groupLag.TopicLags, &TopicLag{Topic: topic, SummedLag: 10, summedHighWaterMark: 100})
+		}
+		return map[string]*ConsumerGroupLag{"orders-service": groupLag}
+	}
+
+	// The sampler's interval is shorter, e.g. because the lag exporter refreshes every 10s
+	now := time.Now()
+	history.onLagSample(now, lags("orders", "payments"))
+	history.onLagSample(now.Add(10*time.Second), lags("orders", "payments"))
+	history.onLagSample(now.Add(20*time.Second), lags("orders", "payments"))
+	history.onLagSample(now.Add(30*time.Second), lags("payments", "orders"))
+	history.onLagSample(now.Add(60*time.Second), lags("orders"))
+
+	samples := history.store.samples("orders-service", now.Add(-time.Minute))
+	require.Len(t, samples, 3)
+	assert.Equal(t, int64(20), samples[0].SummedLag)
+	assert.Equal(t, int64(200), samples[0].SummedHighWaterMark)
+	assert.Equal(t, samples[0].TopicsHash, samples[1].TopicsHash, "expected the same hash regardless of the topics' order")
+	assert.NotEqual(t, samples[1].TopicsHash, samples[2].TopicsHash)
+}
+
+func TestLagHistoryStore_SamplesAndPurge(t *testing.T) {
+	now := time.Now()
+	toMs := func(ts time.Time) int64 { return ts.UnixNano() / int64(time.Millisecond) }
+
+	store := newLagHistoryStore(10, time.Hour)
+	store.add("orders-service", LagSample{Timestamp: toMs(now.Add(-50 * time.Minute)), SummedLag: 3})
+	store.add("orders-service", LagSample{Timestamp: toMs(now.Add(-10 * time.Minute)), SummedLag: 2})
+	store.add("orders-service", LagSample{Timestamp: toMs(now), SummedLag: 1})
+	store.add("deleted-service", LagSample{Timestamp: toMs(now.Add(-2 * time.Hour)), SummedLag: 1})
+
+	samples := store.samples("orders-service", now.Add(-30*time.Minute))
+	require.Len(t, samples, 2)
+	assert.Equal(t, int64(2), samples[0].SummedLag)
+	assert.Equal(t, int64(1), samples[1].SummedLag)
+	assert.Empty(t, store.samples("unknown-service", now.Add(-time.Hour)))
+
+	store.purge(now)
+	assert.Empty(t, store.samples("deleted-service", now.Add(-3*time.Hour)))
+	assert.Len(t, store.samples("orders-service", now.Add(-time.Hour)), 3)
+}
+
+func TestLagHistoryStore_Persistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lag-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lag-history.json")
+
+	// Loading a file which doesn't exist yet is fine
+	store := newLagHistoryStore(10, time.Hour)
+	require.NoError(t, store.load(path))
+
+	ts := time.Now().UnixNano() / int64(time.Millisecond)
+	store.add("orders-service", LagSample{Timestamp: ts, SummedLag: 42, SummedHighWaterMark: 1000})
+	require.NoError(t, store.save(path))
+
+	restored := newLagHistoryStore(10, time.Hour)
+	require.NoError(t, restored.load(path))
+	assert.Equal(t, []LagSample{{Timestamp: ts, SummedLag: 42, SummedHighWaterMark: 1000}}, restored.samples("orders-service", time.Now().Add(-time.Minute)))
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,96 @@
+package owl
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lagSampler periodically calculates the lags of consumer groups in the background and passes them to the lag
+// exporter and the lag history. Both share a single sampler, so that the lags (and the time lags, which require
+// fetching records) are only calculated once per interval.
+type lagSampler struct {
+	svc       *Service
+	interval  time.Duration
+	logger    *zap.Logger
+	consumers []lagSampleConsumer
+}
+
+// lagSampleConsumer is a background task which requires the lags of consumer groups.
+type lagSampleConsumer interface {
+	// isGroupSampled returns true if the lag of the given group is required.
+	isGroupSampled(groupID string) bool
+
+	// onLagSample receives the lags of all sampled groups. The lags are shared among all consumers and must not be
+	// modified.
+	onLagSample(now time.Time, lags map[string]*ConsumerGroupLag)
+}
+
+func newLagSampler(svc *Service, interval time.Duration, consumers []lagSampleConsumer) *lagSampler {
+	return &lagSampler{
+		svc:       svc,
+		interval:  interval,
+		logger:    svc.logger.With(zap.String("source", "lag_sampler")),
+		consumers: consumers,
+	}
+}
+
+// Start samples the consumer group lags at the configured interval until the context is done.
+func (s *lagSampler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sample(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample calculates the lags of all groups which are required by any consumer. If that fails the consumers will not
+// be notified, so that they keep the previous lags.
+func (s *lagSampler) sample(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+
+	groups, err := s.svc.kafkaSvc.ListConsumerGroups(ctx)
+	if err != nil {
+		s.logger.Warn("failed to list consumer groups for sampling lags", zap.Error(err))
+		return
+	}
+
+	sampledGroups := make([]string, 0)
+	for _, group := range groups.GetGroupIDs() {
+		if s.isGroupSampled(group) {
+			sampledGroups = append(sampledGroups, group)
+		}
+	}
+
+	lags := make(map[string]*ConsumerGroupLag)
+	if len(sampledGroups) > 0 {
+		lags, err = s.svc.getConsumerGroupLags(ctx, sampledGroups)
+		if err != nil {
+			s.logger.Warn("failed to calculate consumer group lags for sampling", zap.Error(err))
+			return
+		}
+	}
+
+	now := time.Now()
+	for _, consumer := range s.consumers {
+		consumer.onLagSample(now, lags)
+	}
+}
+
+func (s *lagSampler) isGroupSampled(groupID string) bool {
+	for _, consumer := range s.consumers {
+		if consumer.isGroupSampled(groupID) {
+			return true
+		}
+	}
+	return false
+}
@@ -34,6 +34,8 @@ type ListMessageRequest struct {
 	StartTimestamp        int64 // Start offset by unix timestamp in ms
 	MessageCount          int
 	FilterInterpreterCode string
+	KeyEncoding           string // Forces the encoding used for decoding keys, empty for automatic detection
+	ValueEncoding         string // Forces the encoding used for decoding values, empty for automatic detection
 }
 
 // ListMessageResponse returns the requested kafka messages along with some metadata about the operation
@@ -87,6 +89,8 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 		MaxMessageCount:       listReq.MessageCount,
 		Partitions:            consumeRequests,
 		FilterInterpreterCode: listReq.FilterInterpreterCode,
+		KeyEncoding:           listReq.KeyEncoding,
+		ValueEncoding:         listReq.ValueEncoding,
 	}
 
 	progress.OnPhase("Consuming messages")
@@ -289,7 +293,7 @@ func (s *Service) requestOffsetsByTimestamp(ctx context.Context, topicName strin
 		for _, partition := range topic.Partitions {
 			typedErr := kerr.TypedErrorForCode(partition.ErrorCode)
 			if typedErr != nil {
-				return nil, fmt.Errorf("failed to get timestamp for at least one partition. Inner Kafka error: %w", typedErr.Error())
+				return nil, fmt.Errorf("failed to get timestamp for at least one partition. Inner Kafka error: %w", typedErr)
 			}
 			offsetByPartition[partition.Partition] = partition.Offset
 		}
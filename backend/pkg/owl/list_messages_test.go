@@ -1,6 +1,7 @@
 package owl
 
 import (
+	"context"
 	"math"
 	"testing"
 
@@ -9,8 +10,9 @@ import (
 )
 
 func TestCalculateConsumeRequests_AllPartitions_FewNewestMessages(t *testing.T) {
+	svc := Service{}
 	// Request less messages than we have partitions
-	marks := map[int32]kafka.PartitionMarks{
+	marks := map[int32]*kafka.PartitionMarks{
 		0: {PartitionID: 0, Low: 0, High: 300},
 		1: {PartitionID: 1, Low: 0, High: 10},
 		2: {PartitionID: 2, Low: 10, High: 30},
@@ -29,14 +31,16 @@ func TestCalculateConsumeRequests_AllPartitions_FewNewestMessages(t *testing.T)
 		1: {PartitionID: 1, IsDrained: false, StartOffset: marks[1].High - 1, EndOffset: marks[1].High - 1, MaxMessageCount: 1, LowWaterMark: marks[1].Low, HighWaterMark: marks[1].High},
 		2: {PartitionID: 2, IsDrained: false, StartOffset: marks[2].High - 1, EndOffset: marks[2].High - 1, MaxMessageCount: 1, LowWaterMark: marks[2].Low, HighWaterMark: marks[2].High},
 	}
-	actual := calculateConsumeRequests(req, marks)
+	actual, err := svc.calculateConsumeRequests(context.Background(), req, marks)
+	assert.NoError(t, err)
 
 	assert.Equal(t, expected, actual, "expected other result for unbalanced message distribution - all partition IDs")
 }
 
 func TestCalculateConsumeRequests_AllPartitions_Unbalanced(t *testing.T) {
+	svc := Service{}
 	// Unbalanced message distribution across 3 partitions
-	marks := map[int32]kafka.PartitionMarks{
+	marks := map[int32]*kafka.PartitionMarks{
 		0: {PartitionID: 0, Low: 0, High: 300},
 		1: {PartitionID: 1, Low: 0, High: 11},
 		2: {PartitionID: 2, Low: 10, High: 31},
@@ -51,17 +55,19 @@ func TestCalculateConsumeRequests_AllPartitions_Unbalanced(t *testing.T) {
 
 	// Expected result should be able to return all 100 requested messages as evenly distributed as possible
 	expected := map[int32]*kafka.PartitionConsumeRequest{
-		0: {PartitionID: 0, IsDrained: false, LowWaterMark: marks[0].Low, HighWaterMark: marks[0].High, StartOffset: 0, EndOffset: marks[0].High - 1, MaxMessageCount: 70},
-		1: {PartitionID: 1, IsDrained: true, LowWaterMark: marks[1].Low, HighWaterMark: marks[1].High, StartOffset: 0, EndOffset: marks[1].High - 1, MaxMessageCount: 10},
-		2: {PartitionID: 2, IsDrained: true, LowWaterMark: marks[2].Low, HighWaterMark: marks[2].High, StartOffset: 10, EndOffset: marks[2].High - 1, MaxMessageCount: 20},
+		0: {PartitionID: 0, IsDrained: false, LowWaterMark: marks[0].Low, HighWaterMark: marks[0].High, StartOffset: 0, EndOffset: marks[0].High - 1, MaxMessageCount: 68},
+		1: {PartitionID: 1, IsDrained: true, LowWaterMark: marks[1].Low, HighWaterMark: marks[1].High, StartOffset: 0, EndOffset: marks[1].High - 1, MaxMessageCount: 11},
+		2: {PartitionID: 2, IsDrained: true, LowWaterMark: marks[2].Low, HighWaterMark: marks[2].High, StartOffset: 10, EndOffset: marks[2].High - 1, MaxMessageCount: 21},
 	}
-	actual := calculateConsumeRequests(req, marks)
+	actual, err := svc.calculateConsumeRequests(context.Background(), req, marks)
+	assert.NoError(t, err)
 
 	assert.Equal(t, expected, actual, "expected other result for unbalanced message distribution - all partition IDs")
 }
 
 func TestCalculateConsumeRequests_SinglePartition(t *testing.T) {
-	marks := map[int32]kafka.PartitionMarks{
+	svc := Service{}
+	marks := map[int32]*kafka.PartitionMarks{
 		14: {PartitionID: 14, Low: 100, High: 301},
 	}
 	lowMark := marks[14].Low
@@ -87,11 +93,11 @@ func TestCalculateConsumeRequests_SinglePartition(t *testing.T) {
 			},
 		},
 
-		// Custom start offset with drained - 50 messages
+		// Custom start offset with drained - 51 messages
 		{
 			&ListMessageRequest{TopicName: "test", PartitionID: 14, StartOffset: 250, MessageCount: 200},
 			map[int32]*kafka.PartitionConsumeRequest{
-				14: {PartitionID: 14, IsDrained: true, StartOffset: 250, EndOffset: highMark - 1, MaxMessageCount: 50, LowWaterMark: lowMark, HighWaterMark: highMark},
+				14: {PartitionID: 14, IsDrained: true, StartOffset: 250, EndOffset: highMark - 1, MaxMessageCount: 51, LowWaterMark: lowMark, HighWaterMark: highMark},
 			},
 		},
 
@@ -115,7 +121,7 @@ func TestCalculateConsumeRequests_SinglePartition(t *testing.T) {
 		{
 			&ListMessageRequest{TopicName: "test", PartitionID: 14, StartOffset: StartOffsetOldest, MessageCount: 500},
 			map[int32]*kafka.PartitionConsumeRequest{
-				14: {PartitionID: 14, IsDrained: true, StartOffset: lowMark, EndOffset: highMark - 1, MaxMessageCount: 200, LowWaterMark: lowMark, HighWaterMark: highMark},
+				14: {PartitionID: 14, IsDrained: true, StartOffset: lowMark, EndOffset: highMark - 1, MaxMessageCount: 201, LowWaterMark: lowMark, HighWaterMark: highMark},
 			},
 		},
 
@@ -129,15 +135,17 @@ func TestCalculateConsumeRequests_SinglePartition(t *testing.T) {
 	}
 
 	for i, table := range tt {
-		actual := calculateConsumeRequests(table.req, marks)
+		actual, err := svc.calculateConsumeRequests(context.Background(), table.req, marks)
+		assert.NoError(t, err)
 		assert.Equal(t, table.expected, actual, "expected other result for single partition test. Case: ", i)
 	}
 }
 
 func TestCalculateConsumeRequests_AllPartitions_WithFilter(t *testing.T) {
+	svc := Service{}
 	// Request less messages than we have partitions, if filter code is set we handle consume requests different than
 	// usual - as we don't care about the distribution between partitions.
-	marks := map[int32]kafka.PartitionMarks{
+	marks := map[int32]*kafka.PartitionMarks{
 		0: {PartitionID: 0, Low: 0, High: 300},
 		1: {PartitionID: 1, Low: 0, High: 300},
 		2: {PartitionID: 2, Low: 0, High: 300},
@@ -179,7 +187,8 @@ func TestCalculateConsumeRequests_AllPartitions_WithFilter(t *testing.T) {
 	}
 
 	for i, table := range tt {
-		actual := calculateConsumeRequests(table.req, marks)
+		actual, err := svc.calculateConsumeRequests(context.Background(), table.req, marks)
+		assert.NoError(t, err)
 		assert.Equal(t, table.expected, actual, "expected other result for all partitions with filter enable. Case: ", i)
 	}
 }
@@ -0,0 +1,142 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// ProduceRecordsRequest carries all records that shall be produced to a single topic along with the producer options.
+type ProduceRecordsRequest struct {
+	TopicName   string
+	Partitioner string
+	Compression string
+	Records     []ProduceRecord
+}
+
+// ProduceRecord is a single record that shall be produced
+type ProduceRecord struct {
+	PartitionID int32 // -1 lets the partitioner choose the partition
+	Key         *kafka.ProducePayload
+	Value       *kafka.ProducePayload // nil produces a tombstone
+	Headers     []ProduceRecordHeader
+}
+
+// ProduceRecordHeader is a single record header that shall be produced
+type ProduceRecordHeader struct {
+	Key   string
+	Value kafka.ProducePayload
+}
+
+// ProduceRecordResponse reports the partition and offset a record has been written to or the error why it couldn't be
+// produced.
+type ProduceRecordResponse struct {
+	PartitionID int32  `json:"partitionId"`
+	Offset      int64  `json:"offset"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ProduceRecords serializes all payloads of the given records and produces them to the requested topic.
+func (s *Service) ProduceRecords(ctx context.Context, req ProduceRecordsRequest) ([]ProduceRecordResponse, *rest.Error) {
+	partitionIDs, err := s.kafkaSvc.ListPartitionIDs(ctx, req.TopicName)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusNotFound,
+			Message:  fmt.Sprintf("Failed to get partitions for topic '%v': %v", req.TopicName, err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	records := make([]*kgo.Record, len(req.Records))
+	for i, record := range req.Records {
+		if record.PartitionID >= int32(len(partitionIDs)) {
+			return nil, &rest.Error{
+				Err:      fmt.Errorf("requested partition '%d' does not exist", record.PartitionID),
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Record with index '%d' targets partition '%d', but topic has only '%d' partitions", i, record.PartitionID, len(partitionIDs)),
+				IsSilent: false,
+			}
+		}
+
+		kgoRecord, err := s.serializeRecord(req.TopicName, record)
+		if err != nil {
+			return nil, &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to serialize record with index '%d': %v", i, err.Error()),
+				IsSilent: false,
+			}
+		}
+		records[i] = kgoRecord
+	}
+
+	opts := kafka.ProduceRecordsOptions{
+		Partitioner: req.Partitioner,
+		Compression: req.Compression,
+	}
+	results, err := s.kafkaSvc.ProduceRecords(ctx, records, opts)
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusServiceUnavailable,
+			Message:  fmt.Sprintf("Produce request has failed: %v", err.Error()),
+			IsSilent: false,
+		}
+	}
+
+	res := make([]ProduceRecordResponse, len(results))
+	for i, result := range results {
+		errMessage := ""
+		if result.Error != nil {
+			errMessage = result.Error.Error()
+		}
+		res[i] = ProduceRecordResponse{
+			PartitionID: result.PartitionID,
+			Offset:      result.Offset,
+			Error:       errMessage,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Service) serializeRecord(topicName string, record ProduceRecord) (*kgo.Record, error) {
+	kgoRecord := &kgo.Record{
+		Topic:     topicName,
+		Partition: record.PartitionID,
+	}
+
+	if record.Key != nil {
+		key, err := s.kafkaSvc.Serializer.SerializePayload(*record.Key, topicName, proto.RecordKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize key: %w", err)
+		}
+		kgoRecord.Key = key
+	}
+
+	if record.Value != nil {
+		value, err := s.kafkaSvc.Serializer.SerializePayload(*record.Value, topicName, proto.RecordValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize value: %w", err)
+		}
+		kgoRecord.Value = value
+	}
+
+	headers := make([]kgo.RecordHeader, len(record.Headers))
+	for i, header := range record.Headers {
+		value, err := s.kafkaSvc.Serializer.SerializePayload(header.Value, topicName, proto.RecordValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize header '%v': %w", header.Key, err)
+		}
+		headers[i] = kgo.RecordHeader{Key: header.Key, Value: value}
+	}
+	kgoRecord.Headers = headers
+
+	return kgoRecord, nil
+}
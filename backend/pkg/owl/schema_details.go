@@ -4,18 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/schema"
 )
 
 type SchemaDetails struct {
-	Subject            string      `json:"string"`
-	SchemaID           int         `json:"schemaId"`
-	Version            int         `json:"version"`
-	Compatibility      string      `json:"compatibility"`
-	Schema             interface{} `json:"schema"`
-	RegisteredVersions []int       `json:"registeredVersions"`
+	Subject            string                   `json:"string"`
+	SchemaID           int                      `json:"schemaId"`
+	Version            int                      `json:"version"`
+	Compatibility      string                   `json:"compatibility"`
+	SchemaType         string                   `json:"schemaType"`
+	References         []schema.SchemaReference `json:"references"`
+	RegisteredVersions []int                    `json:"registeredVersions"`
+
+	// Schema is the parsed JSON object for Avro and JSON schemas and the schema string for Protobuf schemas
+	Schema interface{} `json:"schema"`
+
+	// Diff contains the changes from the compared version to this version, if a version to compare to was requested.
+	// Diffs are only supported for Avro schemas.
+	Diff *schema.AvroSchemaDiff `json:"diff,omitempty"`
 }
 
-func (s *Service) GetSchemaDetails(_ context.Context, subject string, version string) (*SchemaDetails, error) {
+// GetSchemaDetails returns the schema of the given subject version. If compareToVersion is set, the schema is
+// compared to that version of the subject as well.
+func (s *Service) GetSchemaDetails(_ context.Context, subject string, version string, compareToVersion string) (*SchemaDetails, error) {
 	if s.kafkaSvc.SchemaService == nil {
 		return nil, ErrSchemaRegistryNotConfigured
 	}
@@ -35,10 +47,29 @@ func (s *Service) GetSchemaDetails(_ context.Context, subject string, version st
 		return nil, fmt.Errorf("failed to get compatibility for given subject: %w", err)
 	}
 
-	var parsedSchema interface{}
-	err = json.Unmarshal([]byte(versionedSchema.Schema), &parsedSchema)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal schema to JSON object: %w", err)
+	var parsedSchema interface{} = versionedSchema.Schema
+	if versionedSchema.SchemaType != schema.SchemaTypeProtobuf {
+		err = json.Unmarshal([]byte(versionedSchema.Schema), &parsedSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal schema to JSON object: %w", err)
+		}
+	}
+
+	references := versionedSchema.References
+	if references == nil {
+		references = []schema.SchemaReference{}
+	}
+
+	var diff *schema.AvroSchemaDiff
+	if compareToVersion != "" && versionedSchema.SchemaType == schema.SchemaTypeAvro {
+		compareToSchema, err := s.kafkaSvc.SchemaService.GetSchemaBySubject(subject, compareToVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get versioned schema to compare to: %w", err)
+		}
+		diff, err = schema.DiffAvroSchemas(compareToSchema.Schema, versionedSchema.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare schema versions: %w", err)
+		}
 	}
 
 	return &SchemaDetails{
@@ -46,7 +77,10 @@ func (s *Service) GetSchemaDetails(_ context.Context, subject string, version st
 		SchemaID:           versionedSchema.SchemaID,
 		Version:            versionedSchema.Version,
 		Compatibility:      cfgRes.Compatibility,
+		SchemaType:         versionedSchema.SchemaType,
+		References:         references,
 		RegisteredVersions: versions.Versions,
 		Schema:             parsedSchema,
+		Diff:               diff,
 	}, nil
 }
@@ -0,0 +1,106 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+)
+
+// RegisterSchema registers a new schema version under the given subject and returns the schema's id.
+func (s *Service) RegisterSchema(_ context.Context, subject string, req schema.RegisterSchemaRequest) (*schema.RegisterSchemaResponse, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.RegisterSchema(subject, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema: %w", err)
+	}
+
+	return res, nil
+}
+
+// DeleteSubject deletes all versions of the given subject and returns the deleted versions.
+func (s *Service) DeleteSubject(_ context.Context, subject string, permanent bool) (*schema.DeleteSubjectResponse, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.DeleteSubject(subject, permanent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete subject: %w", err)
+	}
+
+	return res, nil
+}
+
+// DeleteSubjectVersion deletes a single version of the given subject.
+func (s *Service) DeleteSubjectVersion(_ context.Context, subject string, version string, permanent bool) (*schema.DeleteSubjectVersionResponse, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.DeleteSubjectVersion(subject, version, permanent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete subject version: %w", err)
+	}
+
+	return res, nil
+}
+
+// PutSchemaRegistryConfig sets the global compatibility level.
+func (s *Service) PutSchemaRegistryConfig(_ context.Context, compatibility string) (*schema.PutConfigResponse, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.PutConfig(schema.PutConfigRequest{Compatibility: compatibility})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set global compatibility level: %w", err)
+	}
+
+	return res, nil
+}
+
+// PutSchemaRegistrySubjectConfig sets the compatibility level of the given subject.
+func (s *Service) PutSchemaRegistrySubjectConfig(_ context.Context, subject string, compatibility string) (*schema.PutConfigResponse, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.PutSubjectConfig(subject, schema.PutConfigRequest{Compatibility: compatibility})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set compatibility level for subject: %w", err)
+	}
+
+	return res, nil
+}
+
+// PutSchemaRegistryMode sets the global mode of the schema registry.
+func (s *Service) PutSchemaRegistryMode(_ context.Context, mode string) (*schema.ModeResponse, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.PutMode(schema.PutModeRequest{Mode: mode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set schema registry mode: %w", err)
+	}
+
+	return res, nil
+}
+
+// CheckSchemaCompatibility tests whether the schema is compatible with the given version of the subject.
+func (s *Service) CheckSchemaCompatibility(_ context.Context, subject string, version string, req schema.RegisterSchemaRequest) (*schema.CompatibilityCheckResponse, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.CheckCompatibility(subject, version, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema compatibility: %w", err)
+	}
+
+	return res, nil
+}
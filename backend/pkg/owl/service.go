@@ -1,10 +1,13 @@
 package owl
 
 import (
+	"context"
 	"fmt"
 	"github.com/cloudhut/kowl/backend/pkg/git"
 	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"time"
 )
 
 // Service offers all methods to serve the responses for the REST API. This usually only involves fetching
@@ -13,6 +16,11 @@ type Service struct {
 	kafkaSvc *kafka.Service
 	gitSvc   *git.Service // Git service can be nil if not configured
 	logger   *zap.Logger
+
+	lagExporter      *lagExporter      // Lag exporter can be nil if not enabled
+	timeLagEstimator *timeLagEstimator // Time lag estimator can be nil if not enabled
+	lagHistory       *lagHistory       // Lag history can be nil if not enabled
+	lagSampler       *lagSampler       // Lag sampler is nil if neither the lag exporter nor the lag history is enabled
 }
 
 // NewService for the Owl package
@@ -26,16 +34,62 @@ func NewService(cfg Config, logger *zap.Logger, kafkaSvc *kafka.Service) (*Servi
 		}
 		gitSvc = svc
 	}
-	return &Service{
+	svc := &Service{
 		kafkaSvc: kafkaSvc,
 		gitSvc:   gitSvc,
 		logger:   logger,
-	}, nil
+	}
+
+	if cfg.LagExporter.Enabled {
+		exporter, err := newLagExporter(svc, cfg.LagExporter, kafkaSvc.MetricsNamespace, kafkaSvc.ClusterName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lag exporter: %w", err)
+		}
+		err = prometheus.Register(exporter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register lag exporter metrics: %w", err)
+		}
+		svc.lagExporter = exporter
+	}
+
+	if cfg.TimeLag.Enabled {
+		svc.timeLagEstimator = newTimeLagEstimator(cfg.TimeLag, logger, kafkaSvc.FetchRecordTimestamps)
+	}
+
+	if cfg.LagHistory.Enabled {
+		svc.lagHistory = newLagHistory(svc, cfg.LagHistory)
+	}
+
+	// The lag exporter and the lag history share the sampled lags, which are sampled at the shorter of both intervals
+	var lagSampleConsumers []lagSampleConsumer
+	var sampleInterval time.Duration
+	if svc.lagExporter != nil {
+		lagSampleConsumers = append(lagSampleConsumers, svc.lagExporter)
+		sampleInterval = cfg.LagExporter.Interval
+	}
+	if svc.lagHistory != nil {
+		lagSampleConsumers = append(lagSampleConsumers, svc.lagHistory)
+		if sampleInterval == 0 || cfg.LagHistory.Interval < sampleInterval {
+			sampleInterval = cfg.LagHistory.Interval
+		}
+	}
+	if len(lagSampleConsumers) > 0 {
+		svc.lagSampler = newLagSampler(svc, sampleInterval, lagSampleConsumers)
+	}
+
+	return svc, nil
 }
 
 // Start starts all the (background) tasks which are required for this service to work properly. If any of these
 // tasks can not be setup an error will be returned which will cause the application to exit.
 func (s *Service) Start() error {
+	if s.lagHistory != nil {
+		s.lagHistory.loadPersisted()
+	}
+	if s.lagSampler != nil {
+		go s.lagSampler.Start(context.Background())
+	}
+
 	if s.gitSvc == nil {
 		return nil
 	}
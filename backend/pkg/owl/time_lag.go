@@ -0,0 +1,251 @@
+package owl
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fetchTimestampsFunc returns the timestamp of the first record at or after each of the given offsets
+// (topic -> partitionID -> offset). See kafka.Service.FetchRecordTimestamps.
+type fetchTimestampsFunc func(ctx context.Context, startOffsets map[string]map[int32]int64) (map[string]map[int32]time.Time, error)
+
+// timeLagEstimator estimates how far consumer groups are behind in seconds, by comparing the timestamp of the record
+// at the group's committed offset with the timestamp of the latest record in the partition. Because the records
+// of an offset do not change, fetched timestamps are cached by their offset.
+type timeLagEstimator struct {
+	cfg             ConfigTimeLag
+	logger          *zap.Logger
+	fetchTimestamps fetchTimestampsFunc
+
+	cache *recordTimestampCache
+}
+
+func newTimeLagEstimator(cfg ConfigTimeLag, logger *zap.Logger, fetchTimestamps fetchTimestampsFunc) *timeLagEstimator {
+	return &timeLagEstimator{
+		cfg:             cfg,
+		logger:          logger.With(zap.String("source", "time_lag_estimator")),
+		fetchTimestamps: fetchTimestamps,
+		cache:           newRecordTimestampCache(cfg.CacheTTL),
+	}
+}
+
+// timeLagRequest references a calculated partition lag whose LagSeconds shall be estimated.
+type timeLagRequest struct {
+	Topic           string
+	PartitionLag    *PartitionLag
+	CommittedOffset int64
+	HighWaterMark   int64
+}
+
+// estimate sets LagSeconds on all requested partition lags for which the timestamps are known or could be fetched.
+// Partitions without lag (e.g. empty partitions) have a time lag of 0 seconds without fetching any records.
+func (e *timeLagEstimator) estimate(ctx context.Context, requests []timeLagRequest) {
+	e.cache.purgeExpired()
+
+	// 1. Collect all offsets whose timestamps are not cached yet
+	missingOffsets := make(map[string]map[int32][]int64)
+	addMissingOffset := func(topic string, partitionID int32, offset int64) {
+		if _, isCached := e.cache.get(topic, partitionID, offset); isCached {
+			return
+		}
+		if _, ok := missingOffsets[topic]; !ok {
+			missingOffsets[topic] = make(map[int32][]int64)
+		}
+		missingOffsets[topic][partitionID] = append(missingOffsets[topic][partitionID], offset)
+	}
+	for _, req := range requests {
+		if req.PartitionLag.Lag <= 0 || req.CommittedOffset < 0 {
+			continue
+		}
+		addMissingOffset(req.Topic, req.PartitionLag.PartitionID, req.CommittedOffset)
+		addMissingOffset(req.Topic, req.PartitionLag.PartitionID, req.HighWaterMark-1)
+	}
+
+	// 2. Fetch missing timestamps, each round fetches at most one offset per partition. Rounds and their total
+	// duration are bounded, so that the request isn't blocked by groups which are spread over many offsets. The
+	// remaining timestamps will be fetched by subsequent requests, until then these partitions have no time lag.
+	rounds := splitIntoFetchRounds(missingOffsets)
+	if len(rounds) > e.cfg.MaxFetchRounds {
+		e.logger.Debug("deferring record timestamp fetches to subsequent requests",
+			zap.Int("required_rounds", len(rounds)),
+			zap.Int("max_fetch_rounds", e.cfg.MaxFetchRounds))
+		rounds = rounds[:e.cfg.MaxFetchRounds]
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, e.cfg.MaxFetchDuration)
+	defer cancel()
+	for _, round := range rounds {
+		if !e.fetchRound(fetchCtx, round) {
+			break
+		}
+	}
+
+	// 3. Calculate the time lags using the cached timestamps
+	for _, req := range requests {
+		req.PartitionLag.LagSeconds = e.calculateLagSeconds(req)
+	}
+}
+
+// fetchRound fetches the timestamps of the given offsets and caches the results. It returns false if the context
+// is done and hence no further rounds should be fetched.
+func (e *timeLagEstimator) fetchRound(ctx context.Context, startOffsets map[string]map[int32]int64) bool {
+	roundCtx, cancel := context.WithTimeout(ctx, e.cfg.FetchTimeout)
+	defer cancel()
+
+	timestamps, err := e.fetchTimestamps(roundCtx, startOffsets)
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		e.logger.Warn("failed to fetch record timestamps for estimating the time lag", zap.Error(err))
+		return false
+	}
+
+	// Offsets without a fetched record are cached as well, so that they don't slow down subsequent requests
+	for topic, partitions := range startOffsets {
+		for partitionID, offset := range partitions {
+			timestamp, found := timestamps[topic][partitionID]
+			e.cache.set(topic, partitionID, offset, timestamp, found)
+		}
+	}
+
+	return true
+}
+
+// calculateLagSeconds returns the time lag for the given partition or nil if it is unknown.
+func (e *timeLagEstimator) calculateLagSeconds(req timeLagRequest) *float64 {
+	if req.PartitionLag.Error != "" {
+		return nil
+	}
+	if req.PartitionLag.Lag <= 0 {
+		lagSeconds := float64(0)
+		return &lagSeconds
+	}
+	if req.CommittedOffset < 0 {
+		return nil
+	}
+
+	committed, _ := e.cache.get(req.Topic, req.PartitionLag.PartitionID, req.CommittedOffset)
+	latest, _ := e.cache.get(req.Topic, req.PartitionLag.PartitionID, req.HighWaterMark-1)
+	if !committed.Found || !latest.Found {
+		return nil
+	}
+
+	// Record timestamps are not necessarily monotonic (e.g. if they are set by the producers), but a negative time
+	// lag doesn't make sense
+	lagSeconds := latest.Timestamp.Sub(committed.Timestamp).Seconds()
+	if lagSeconds < 0 {
+		lagSeconds = 0
+	}
+	return &lagSeconds
+}
+
+// setTopicLagSeconds sets the topic's LagSeconds to the highest time lag of all its partitions.
+func setTopicLagSeconds(topicLag *TopicLag) {
+	topicLag.LagSeconds = nil
+	for _, partitionLag := range topicLag.PartitionLags {
+		if partitionLag.LagSeconds == nil {
+			continue
+		}
+		if topicLag.LagSeconds == nil || *partitionLag.LagSeconds > *topicLag.LagSeconds {
+			lagSeconds := *partitionLag.LagSeconds
+			topicLag.LagSeconds = &lagSeconds
+		}
+	}
+}
+
+// splitIntoFetchRounds distributes the offsets (topic -> partitionID -> offsets) into rounds, so that each round
+// contains at most one offset per partition. A consumer can only start at a single offset per partition. Offsets are
+// distributed in descending order, the latest offset of a partition is required for the time lag of all groups and
+// is therefore fetched in the first round.
+func splitIntoFetchRounds(offsets map[string]map[int32][]int64) []map[string]map[int32]int64 {
+	rounds := make([]map[string]map[int32]int64, 0)
+	for topic, partitions := range offsets {
+		for partitionID, partitionOffsets := range partitions {
+			sort.Slice(partitionOffsets, func(i, j int) bool { return partitionOffsets[i] > partitionOffsets[j] })
+
+			round := 0
+			for i, offset := range partitionOffsets {
+				if i > 0 && offset == partitionOffsets[i-1] {
+					continue
+				}
+				if round == len(rounds) {
+					rounds = append(rounds, make(map[string]map[int32]int64))
+				}
+				if _, ok := rounds[round][topic]; !ok {
+					rounds[round][topic] = make(map[int32]int64)
+				}
+				rounds[round][topic][partitionID] = offset
+				round++
+			}
+		}
+	}
+
+	return rounds
+}
+
+// recordTimestampCache caches the timestamps of records by topic, partition and offset. The timestamp of an offset
+// never changes, hence the TTL only bounds the cache's size and retries offsets whose records were not found.
+type recordTimestampCache struct {
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	entries map[recordTimestampCacheKey]recordTimestampCacheEntry
+}
+
+type recordTimestampCacheKey struct {
+	Topic       string
+	PartitionID int32
+	Offset      int64
+}
+
+type recordTimestampCacheEntry struct {
+	Timestamp time.Time
+	Found     bool
+	ExpiresAt time.Time
+}
+
+func newRecordTimestampCache(ttl time.Duration) *recordTimestampCache {
+	return &recordTimestampCache{
+		ttl:     ttl,
+		entries: make(map[recordTimestampCacheKey]recordTimestampCacheEntry),
+	}
+}
+
+// get returns the cached entry and whether the offset is cached.
+func (c *recordTimestampCache) get(topic string, partitionID int32, offset int64) (recordTimestampCacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[recordTimestampCacheKey{Topic: topic, PartitionID: partitionID, Offset: offset}]
+	if !exists || time.Now().After(entry.ExpiresAt) {
+		return recordTimestampCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *recordTimestampCache) set(topic string, partitionID int32, offset int64, timestamp time.Time, found bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[recordTimestampCacheKey{Topic: topic, PartitionID: partitionID, Offset: offset}] = recordTimestampCacheEntry{
+		Timestamp: timestamp,
+		Found:     found,
+		ExpiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *recordTimestampCache) purgeExpired() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.ExpiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
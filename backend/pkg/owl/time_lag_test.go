@@ -0,0 +1,178 @@
+package owl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeTopicRecords returns the timestamps of the first record at or after the requested offsets from the given
+// records (partitionID -> offset -> timestamp) of the topic "orders". It counts the fetched rounds.
+func fakeTopicRecords(records map[int32]map[int64]time.Time, rounds *int) fetchTimestampsFunc {
+	return func(ctx context.Context, startOffsets map[string]map[int32]int64) (map[string]map[int32]time.Time, error) {
+		*rounds++
+		res := make(map[string]map[int32]time.Time)
+		for topic, partitions := range startOffsets {
+			res[topic] = make(map[int32]time.Time)
+			for partitionID, startOffset := range partitions {
+				var firstOffset int64 = -1
+				for offset := range records[partitionID] {
+					if offset >= startOffset && (firstOffset == -1 || offset < firstOffset) {
+						firstOffset = offset
+					}
+				}
+				if firstOffset != -1 {
+					res[topic][partitionID] = records[partitionID][firstOffset]
+				}
+			}
+		}
+		return res, nil
+	}
+}
+
+func newTestTimeLagConfig() ConfigTimeLag {
+	return ConfigTimeLag{CacheTTL: time.Minute, FetchTimeout: time.Second, MaxFetchRounds: 3, MaxFetchDuration: 5 * time.Second}
+}
+
+func TestTimeLagEstimator_Estimate(t *testing.T) {
+	base := time.Unix(1600000000, 0)
+	records := map[int32]map[int64]time.Time{
+		0: {10: base, 11: base.Add(time.Minute), 19: base.Add(14 * time.Minute)},
+		// Offsets 20-24 have been compacted
+		1: {25: base.Add(2 * time.Minute), 29: base.Add(5 * time.Minute)},
+		// The latest offset is a transaction marker, hence there is no record for the high water mark
+		2: {5: base},
+	}
+	rounds := 0
+	estimator := newTimeLagEstimator(newTestTimeLagConfig(), zap.NewNop(), fakeTopicRecords(records, &rounds))
+
+	partitionLags := []PartitionLag{
+		{PartitionID: 0, Lag: 10},
+		{PartitionID: 1, Lag: 10},
+		{PartitionID: 2, Lag: 2},
+		{PartitionID: 3, Lag: 0}, // Empty partition
+		{PartitionID: 4, Lag: -1, Error: "NOT_LEADER_FOR_PARTITION"},
+	}
+	requests := []timeLagRequest{
+		{Topic: "orders", PartitionLag: &partitionLags[0], CommittedOffset: 10, HighWaterMark: 20},
+		{Topic: "orders", PartitionLag: &partitionLags[1], CommittedOffset: 20, HighWaterMark: 30},
+		{Topic: "orders", PartitionLag: &partitionLags[2], CommittedOffset: 5, HighWaterMark: 7},
+		{Topic: "orders", PartitionLag: &partitionLags[3], CommittedOffset: 0, HighWaterMark: 0},
+		{Topic: "orders", PartitionLag: &partitionLags[4], CommittedOffset: 3, HighWaterMark: -1},
+	}
+	estimator.estimate(context.Background(), requests)
+
+	require.NotNil(t, partitionLags[0].LagSeconds)
+	assert.Equal(t, float64(14*60), *partitionLags[0].LagSeconds)
+	require.NotNil(t, partitionLags[1].LagSeconds)
+	assert.Equal(t, float64(3*60), *partitionLags[1].LagSeconds, "compacted records should be skipped")
+	assert.Nil(t, partitionLags[2].LagSeconds)
+	require.NotNil(t, partitionLags[3].LagSeconds)
+	assert.Equal(t, float64(0), *partitionLags[3].LagSeconds)
+	assert.Nil(t, partitionLags[4].LagSeconds)
+	assert.Equal(t, 2, rounds)
+
+	topicLag := &TopicLag{Topic: "orders", PartitionLags: partitionLags}
+	setTopicLagSeconds(topicLag)
+	require.NotNil(t, topicLag.LagSeconds)
+	assert.Equal(t, float64(14*60), *topicLag.LagSeconds)
+
+	// All timestamps (including the not found ones) are cached now
+	for i := range partitionLags {
+		partitionLags[i].LagSeconds = nil
+	}
+	estimator.estimate(context.Background(), requests)
+	assert.Equal(t, 2, rounds)
+	require.NotNil(t, partitionLags[0].LagSeconds)
+	assert.Equal(t, float64(14*60), *partitionLags[0].LagSeconds)
+}
+
+func TestTimeLagEstimator_MaxFetchRounds(t *testing.T) {
+	base := time.Unix(1600000000, 0)
+	records := map[int32]map[int64]time.Time{
+		0: {10: base, 20: base.Add(time.Minute), 30: base.Add(2 * time.Minute), 39: base.Add(3 * time.Minute)},
+	}
+	rounds := 0
+	cfg := newTestTimeLagConfig()
+	cfg.MaxFetchRounds = 2
+	estimator := newTimeLagEstimator(cfg, zap.NewNop(), fakeTopicRecords(records, &rounds))
+
+	// Three groups consume the partition at different offsets, which requires four rounds including the latest offset
+	partitionLags := []PartitionLag{{PartitionID: 0, Lag: 30}, {PartitionID: 0, Lag: 20}, {PartitionID: 0, Lag: 10}}
+	requests := []timeLagRequest{
+		{Topic: "orders", PartitionLag: &partitionLags[0], CommittedOffset: 10, HighWaterMark: 40},
+		{Topic: "orders", PartitionLag: &partitionLags[1], CommittedOffset: 20, HighWaterMark: 40},
+		{Topic: "orders", PartitionLag: &partitionLags[2], CommittedOffset: 30, HighWaterMark: 40},
+	}
+
+	estimator.estimate(context.Background(), requests)
+	assert.Equal(t, 2, rounds)
+	assert.Nil(t, partitionLags[0].LagSeconds)
+	assert.Nil(t, partitionLags[1].LagSeconds)
+	require.NotNil(t, partitionLags[2].LagSeconds, "expected the time lag of the group closest to the latest offset")
+	assert.Equal(t, float64(60), *partitionLags[2].LagSeconds)
+
+	// The next request continues with the remaining offsets
+	estimator.estimate(context.Background(), requests)
+	assert.Equal(t, 4, rounds)
+	for i, expected := range []float64{180, 120, 60} {
+		require.NotNil(t, partitionLags[i].LagSeconds)
+		assert.Equal(t, expected, *partitionLags[i].LagSeconds)
+	}
+}
+
+func TestTimeLagEstimator_MaxFetchDuration(t *testing.T) {
+	cfg := newTestTimeLagConfig()
+	cfg.MaxFetchDuration = 50 * time.Millisecond
+	rounds := 0
+	estimator := newTimeLagEstimator(cfg, zap.NewNop(), func(ctx context.Context, _ map[string]map[int32]int64) (map[string]map[int32]time.Time, error) {
+		// Like a fetch of records which haven't been written yet
+		rounds++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	partitionLag := PartitionLag{PartitionID: 0, Lag: 10}
+	start := time.Now()
+	estimator.estimate(context.Background(), []timeLagRequest{
+		{Topic: "orders", PartitionLag: &partitionLag, CommittedOffset: 10, HighWaterMark: 20},
+	})
+
+	assert.Less(t, int64(time.Since(start)), int64(cfg.FetchTimeout), "expected the fetches to be bounded by the max fetch duration")
+	assert.Equal(t, 1, rounds, "expected no further rounds after the max fetch duration")
+	assert.Nil(t, partitionLag.LagSeconds)
+}
+
+func TestSplitIntoFetchRounds(t *testing.T) {
+	rounds := splitIntoFetchRounds(map[string]map[int32][]int64{
+		"orders":   {0: {30, 10, 30, 20}, 1: {5}},
+		"payments": {0: {7, 3}},
+	})
+
+	expected := []map[string]map[int32]int64{
+		{"orders": {0: 30, 1: 5}, "payments": {0: 7}},
+		{"orders": {0: 20}, "payments": {0: 3}},
+		{"orders": {0: 10}},
+	}
+	assert.Equal(t, expected, rounds)
+}
+
+func TestRecordTimestampCache_Expiry(t *testing.T) {
+	cache := newRecordTimestampCache(10 * time.Millisecond)
+	cache.set("orders", 0, 10, time.Unix(1600000000, 0), true)
+
+	entry, isCached := cache.get("orders", 0, 10)
+	assert.True(t, isCached)
+	assert.True(t, entry.Found)
+
+	time.Sleep(20 * time.Millisecond)
+	_, isCached = cache.get("orders", 0, 10)
+	assert.False(t, isCached)
+
+	cache.purgeExpired()
+	assert.Empty(t, cache.entries)
+}
@@ -3,15 +3,24 @@ package proto
 import (
 	"flag"
 	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/filesystem"
 	"github.com/cloudhut/kowl/backend/pkg/git"
 )
 
 type Config struct {
-	Enabled bool       `json:"enabled"`
-	Git     git.Config `json:"git"`
+	Enabled bool `yaml:"enabled"`
+
+	// Git and FileSystem are sources for .proto files, which are compiled by Kowl
+	Git        git.Config        `yaml:"git"`
+	FileSystem filesystem.Config `yaml:"fileSystem"`
+
+	// DescriptorSets are directories containing precompiled FileDescriptorSet files (.pb or .desc), as created by
+	// `protoc --include_imports --descriptor_set_out`. This way no .proto files have to be available at runtime.
+	DescriptorSets filesystem.Config `yaml:"descriptorSets"`
 
 	// Mappings define what proto types shall be used for each Kafka topic.
-	Mappings []ConfigTopicMapping `json:"mappings"`
+	Mappings []ConfigTopicMapping `yaml:"mappings"`
 }
 
 // RegisterFlags registers all nested config flags.
@@ -24,17 +33,32 @@ func (c *Config) Validate() error {
 		return nil
 	}
 
-	if c.Enabled && !c.Git.Enabled {
-		return fmt.Errorf("protobuf deserializer is enabled, but git is disabled. At least one source for protos must be configured")
+	if !c.Git.Enabled && !c.FileSystem.Enabled && !c.DescriptorSets.Enabled {
+		return fmt.Errorf("protobuf deserializer is enabled, but git, filesystem and descriptor sets are disabled. At least one source for protos must be configured")
 	}
 
 	if len(c.Mappings) == 0 {
 		return fmt.Errorf("protobuf deserializer is enabled, but no topic mappings have been configured")
 	}
 
+	err := c.FileSystem.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate filesystem config: %w", err)
+	}
+
+	err = c.DescriptorSets.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate descriptor sets config: %w", err)
+	}
+
 	return nil
 }
 
 func (c *Config) SetDefaults() {
 	c.Git.SetDefaults()
+	c.FileSystem.SetDefaults()
+	c.DescriptorSets.SetDefaults()
+
+	// Descriptor sets include all imported files, hence they are usually much larger than a single .proto file
+	c.DescriptorSets.MaxFileSize = 10 * 1000 * 1000 // 10MB
 }
@@ -2,13 +2,17 @@ package proto
 
 import (
 	"fmt"
+	"sync"
+
+	"github.com/cloudhut/kowl/backend/pkg/filesystem"
 	"github.com/cloudhut/kowl/backend/pkg/git"
+	protobuf "github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/msgregistry"
 	"go.uber.org/zap"
-	"sync"
 )
 
 type RecordPropertyType int
@@ -22,8 +26,10 @@ type Service struct {
 	cfg    Config
 	logger *zap.Logger
 
-	mappingsByTopic map[string]ConfigTopicMapping
-	gitSvc          *git.Service
+	mappingsByTopic  map[string]ConfigTopicMapping
+	gitSvc           *git.Service
+	fsSvc            *filesystem.Service
+	descriptorSetSvc *filesystem.Service
 
 	registryMutex sync.RWMutex
 	registry      *msgregistry.MessageRegistry
@@ -36,6 +42,8 @@ func NewService(cfg Config, logger *zap.Logger) (*Service, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new git service: %w", err)
 	}
+	fsSvc := filesystem.NewService(cfg.FileSystem, logger, nil)
+	descriptorSetSvc := filesystem.NewService(cfg.DescriptorSets, logger, nil)
 
 	mappingsByTopic := make(map[string]ConfigTopicMapping)
 	for _, mapping := range cfg.Mappings {
@@ -46,8 +54,10 @@ func NewService(cfg Config, logger *zap.Logger) (*Service, error) {
 		cfg:    cfg,
 		logger: logger,
 
-		mappingsByTopic: mappingsByTopic,
-		gitSvc:          gitSvc,
+		mappingsByTopic:  mappingsByTopic,
+		gitSvc:           gitSvc,
+		fsSvc:            fsSvc,
+		descriptorSetSvc: descriptorSetSvc,
 
 		// registry has to be created afterwards
 		registry: nil,
@@ -60,13 +70,25 @@ func (s *Service) Start() error {
 		return fmt.Errorf("failed to start git service: %w", err)
 	}
 
+	err = s.fsSvc.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start filesystem service: %w", err)
+	}
+
+	err = s.descriptorSetSvc.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start filesystem service for descriptor sets: %w", err)
+	}
+
 	err = s.createProtoRegistry()
 	if err != nil {
-		return fmt.Errorf("failed to start git service: %w", err)
+		return fmt.Errorf("failed to create proto registry: %w", err)
 	}
 
-	// Git service periodically pulls the repo. If there are any file changes the proto registry will be rebuilt.
+	// All sources are periodically refreshed. If there are any file changes the proto registry will be rebuilt.
 	s.gitSvc.OnFilesUpdatedHook = s.tryCreateProtoRegistry
+	s.fsSvc.OnFilesUpdatedHook = s.tryCreateProtoRegistry
+	s.descriptorSetSvc.OnFilesUpdatedHook = s.tryCreateProtoRegistry
 
 	return nil
 }
@@ -91,6 +113,28 @@ func (s *Service) UnmarshalPayload(payload []byte, topicName string, property Re
 	return jsonBytes, nil
 }
 
+// MarshalPayload converts the given JSON payload into the binary protobuf representation of the proto type that is
+// mapped to the given topic and record property.
+func (s *Service) MarshalPayload(jsonPayload []byte, topicName string, property RecordPropertyType) ([]byte, error) {
+	messageDescriptor, err := s.getMessageDescriptor(topicName, property)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message descriptor for payload: %w", err)
+	}
+
+	msg := dynamic.NewMessage(messageDescriptor)
+	err = msg.UnmarshalJSON(jsonPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON payload into protobuf message: %w", err)
+	}
+
+	binaryPayload, err := msg.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+
+	return binaryPayload, nil
+}
+
 func (s *Service) getMessageDescriptor(topicName string, property RecordPropertyType) (*desc.MessageDescriptor, error) {
 	mapping, exists := s.mappingsByTopic[topicName]
 	if !exists {
@@ -133,14 +177,26 @@ func (s *Service) tryCreateProtoRegistry() {
 }
 
 func (s *Service) createProtoRegistry() error {
-	files := s.gitSvc.GetFilesByFilename()
-	s.logger.Debug("fetched .proto files from git service cache",
+	// .proto files from git and the filesystem are compiled together, so that they can import each other
+	files := make(map[string]string)
+	for _, file := range s.gitSvc.GetFilesByFilename() {
+		files[file.Path] = string(file.Payload)
+	}
+	for path, file := range s.fsSvc.GetFilesByPath() {
+		if _, exists := files[path]; exists {
+			s.logger.Warn("proto file exists in git and filesystem, the file from the filesystem will be used",
+				zap.String("file", path))
+		}
+		files[path] = string(file.Payload)
+	}
+	s.logger.Debug("fetched .proto files from git and filesystem",
 		zap.Int("fetched_proto_files", len(files)))
 
 	fileDescriptors, err := s.protoFileToDescriptor(files)
 	if err != nil {
 		return fmt.Errorf("failed to compile proto files to descriptors: %w", err)
 	}
+	fileDescriptors = append(fileDescriptors, s.descriptorSetsToDescriptors(s.descriptorSetSvc.GetFilesByPath())...)
 
 	// Create registry and add types from file descriptors
 	registry := msgregistry.NewMessageRegistryWithDefaults()
@@ -187,12 +243,10 @@ func (s *Service) createProtoRegistry() error {
 //
 // ProtoPath is the path that contains all .proto files. This directory will be searched for imports.
 // Filename is the .proto file within the protoPath that shall be parsed.
-func (s *Service) protoFileToDescriptor(files map[string]git.File) ([]*desc.FileDescriptor, error) {
-	filesStr := make(map[string]string, len(files))
-	filePaths := make([]string, 0, len(filesStr))
-	for _, file := range files {
-		filesStr[file.Path] = string(file.Payload)
-		filePaths = append(filePaths, file.Path)
+func (s *Service) protoFileToDescriptor(files map[string]string) ([]*desc.FileDescriptor, error) {
+	filePaths := make([]string, 0, len(files))
+	for path := range files {
+		filePaths = append(filePaths, path)
 	}
 
 	errorReporter := func(err protoparse.ErrorWithPos) error {
@@ -205,7 +259,7 @@ func (s *Service) protoFileToDescriptor(files map[string]git.File) ([]*desc.File
 	}
 
 	parser := protoparse.Parser{
-		Accessor:              protoparse.FileContentsFromMap(filesStr),
+		Accessor:              protoparse.FileContentsFromMap(files),
 		InferImportPaths:      true,
 		ValidateUnlinkedFiles: true,
 		IncludeSourceCodeInfo: true,
@@ -219,6 +273,35 @@ func (s *Service) protoFileToDescriptor(files map[string]git.File) ([]*desc.File
 	return descriptors, nil
 }
 
+// descriptorSetsToDescriptors unmarshals the given FileDescriptorSet files and returns the descriptors of all files
+// within them. Descriptor sets must include all imported files (protoc's --include_imports), otherwise they can not be
+// linked. Invalid descriptor sets are logged and skipped.
+func (s *Service) descriptorSetsToDescriptors(files map[string]filesystem.File) []*desc.FileDescriptor {
+	descriptors := make([]*desc.FileDescriptor, 0)
+	for path, file := range files {
+		descriptorSet := &dpb.FileDescriptorSet{}
+		err := protobuf.Unmarshal(file.Payload, descriptorSet)
+		if err != nil {
+			s.logger.Warn("failed to unmarshal file descriptor set", zap.String("file", path), zap.Error(err))
+			continue
+		}
+
+		fileDescriptors, err := desc.CreateFileDescriptorsFromSet(descriptorSet)
+		if err != nil {
+			s.logger.Warn("failed to create descriptors from file descriptor set", zap.String("file", path), zap.Error(err))
+			continue
+		}
+		for _, descriptor := range fileDescriptors {
+			descriptors = append(descriptors, descriptor)
+		}
+	}
+	s.logger.Debug("fetched descriptors from file descriptor sets",
+		zap.Int("fetched_descriptor_sets", len(files)),
+		zap.Int("file_descriptors", len(descriptors)))
+
+	return descriptors
+}
+
 // protoFileToDescriptorWithBinary parses a .proto file and compiles it to a descriptor using the protoc binary. Protoc must
 // be available as command or this will fail.
 // Imported dependencies (such as Protobuf timestamp) are included so that the descriptors are self-contained.
@@ -0,0 +1,130 @@
+package proto
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	protobuf "github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const customerProto = `syntax = "proto3";
+package shop;
+
+message Customer {
+  string name = 1;
+}
+`
+
+const orderProto = `syntax = "proto3";
+package shop;
+
+import "shop/customer.proto";
+
+message Order {
+  string id = 1;
+  Customer customer = 2;
+}
+`
+
+const paymentProto = `syntax = "proto3";
+package billing;
+
+import "google/protobuf/timestamp.proto";
+
+message Payment {
+  int64 amount = 1;
+  google.protobuf.Timestamp paid_at = 2;
+}
+`
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := Config{Enabled: true, Mappings: []ConfigTopicMapping{{TopicName: "orders", ValueProtoType: "shop.Order"}}}
+	cfg.SetDefaults()
+	assert.Error(t, cfg.Validate(), "expected an error if no source for protos is enabled")
+
+	cfg.FileSystem.Enabled = true
+	assert.Error(t, cfg.Validate(), "expected an error if the filesystem has no paths")
+
+	cfg.FileSystem.Paths = []string{"/protos"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.FileSystem.Enabled = false
+	cfg.DescriptorSets.Enabled = true
+	cfg.DescriptorSets.Paths = []string{"/descriptors"}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestService_LocalSources(t *testing.T) {
+	protoDir, err := ioutil.TempDir("", "protos")
+	require.NoError(t, err)
+	defer os.RemoveAll(protoDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(protoDir, "shop"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(protoDir, "shop", "customer.proto"), []byte(customerProto), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(protoDir, "shop", "order.proto"), []byte(orderProto), 0644))
+
+	// Create a descriptor set the same way as protoc --include_imports would do
+	descriptorDir, err := ioutil.TempDir("", "descriptors")
+	require.NoError(t, err)
+	defer os.RemoveAll(descriptorDir)
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"billing/payment.proto": paymentProto})}
+	fileDescriptors, err := parser.ParseFiles("billing/payment.proto")
+	require.NoError(t, err)
+	descriptorSet := &dpb.FileDescriptorSet{}
+	for _, dependency := range fileDescriptors[0].GetDependencies() {
+		descriptorSet.File = append(descriptorSet.File, dependency.AsFileDescriptorProto())
+	}
+	descriptorSet.File = append(descriptorSet.File, fileDescriptors[0].AsFileDescriptorProto())
+	descriptorSetBytes, err := protobuf.Marshal(descriptorSet)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(descriptorDir, "billing.desc"), descriptorSetBytes, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(descriptorDir, "invalid.pb"), []byte{0xff, 0xff}, 0644))
+
+	cfg := Config{
+		Enabled: true,
+		Mappings: []ConfigTopicMapping{
+			{TopicName: "orders", ValueProtoType: "shop.Order", KeyProtoType: "shop.Customer"},
+			{TopicName: "payments", ValueProtoType: "billing.Payment"},
+		},
+	}
+	cfg.SetDefaults()
+	cfg.FileSystem.Enabled = true
+	cfg.FileSystem.Paths = []string{protoDir}
+	cfg.FileSystem.RefreshInterval = 0
+	cfg.FileSystem.AllowedFileExtensions = []string{"proto"}
+	cfg.DescriptorSets.Enabled = true
+	cfg.DescriptorSets.Paths = []string{descriptorDir}
+	cfg.DescriptorSets.RefreshInterval = 0
+	cfg.DescriptorSets.AllowedFileExtensions = []string{"pb", "desc"}
+	require.NoError(t, cfg.Validate())
+
+	svc, err := NewService(cfg, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, svc.Start())
+
+	// Order with id "o-1" and customer "ann"
+	payload := []byte{0x0a, 0x03, 'o', '-', '1', 0x12, 0x05, 0x0a, 0x03, 'a', 'n', 'n'}
+	jsonBytes, err := svc.UnmarshalPayload(payload, "orders", RecordValue)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": "o-1", "customer": {"name": "ann"}}`, string(jsonBytes))
+
+	jsonBytes, err = svc.UnmarshalPayload(payload[7:], "orders", RecordKey)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "ann"}`, string(jsonBytes))
+
+	// Payment with amount 42 paid at 2021-05-03T00:00:00Z, whose timestamp type is imported in the descriptor set
+	binaryPayload, err := svc.MarshalPayload([]byte(`{"amount": "42", "paidAt": "2021-05-03T00:00:00Z"}`), "payments", RecordValue)
+	require.NoError(t, err)
+	jsonBytes, err = svc.UnmarshalPayload(binaryPayload, "payments", RecordValue)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "42", "paidAt": "2021-05-03T00:00:00Z"}`, string(jsonBytes))
+
+	_, err = svc.UnmarshalPayload(payload, "payments", RecordKey)
+	assert.Error(t, err, "expected an error if no key type is mapped")
+}
@@ -0,0 +1,533 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// AvroChangeFieldAdded is reported for fields which only exist in the new schema
+	AvroChangeFieldAdded = "FIELD_ADDED"
+	// AvroChangeFieldRemoved is reported for fields which only exist in the old schema
+	AvroChangeFieldRemoved = "FIELD_REMOVED"
+	// AvroChangeTypeChanged is reported if the type changed and neither type can be promoted to the other
+	AvroChangeTypeChanged = "TYPE_CHANGED"
+	// AvroChangeTypePromoted is reported if the type changed, but one type can be promoted to the other (e.g. int
+	// to long or adding a union branch)
+	AvroChangeTypePromoted = "TYPE_PROMOTED"
+	// AvroChangeDefaultChanged is reported if a field's default value has been added, removed or changed
+	AvroChangeDefaultChanged = "DEFAULT_CHANGED"
+	// AvroChangeEnumSymbolAdded is reported for enum symbols which only exist in the new schema
+	AvroChangeEnumSymbolAdded = "ENUM_SYMBOL_ADDED"
+	// AvroChangeEnumSymbolRemoved is reported for enum symbols which only exist in the old schema
+	AvroChangeEnumSymbolRemoved = "ENUM_SYMBOL_REMOVED"
+)
+
+// AvroSchemaDiff is a structured diff between two Avro schemas. A change breaks backward compatibility if consumers
+// using the new schema can not read data written with the old schema. It breaks forward compatibility if consumers
+// using the old schema can not read data written with the new schema.
+type AvroSchemaDiff struct {
+	Changes              []AvroSchemaChange `json:"changes"`
+	IsBackwardCompatible bool               `json:"isBackwardCompatible"`
+	IsForwardCompatible  bool               `json:"isForwardCompatible"`
+}
+
+// AvroSchemaChange is a single change between two Avro schemas.
+type AvroSchemaChange struct {
+	Type string `json:"type"`
+
+	// Path to the changed field, nested fields are separated by dots. Array items are denoted by [] and map values
+	// by {}, e.g. "customer.addresses[].street"
+	Path     string      `json:"path"`
+	OldValue interface{} `json:"oldValue,omitempty"`
+	NewValue interface{} `json:"newValue,omitempty"`
+
+	BreaksBackward bool `json:"breaksBackward"`
+	BreaksForward  bool `json:"breaksForward"`
+}
+
+// avroPromotions lists for each primitive writer type the reader types it can be promoted to, see:
+// https://avro.apache.org/docs/current/spec.html#Schema+Resolution
+var avroPromotions = map[string][]string{
+	"int":    {"long", "float", "double"},
+	"long":   {"float", "double"},
+	"float":  {"double"},
+	"string": {"bytes"},
+	"bytes":  {"string"},
+}
+
+var avroPrimitiveTypes = map[string]struct{}{
+	"null": {}, "boolean": {}, "int": {}, "long": {}, "float": {}, "double": {}, "bytes": {}, "string": {},
+}
+
+// DiffAvroSchemas compares two Avro schemas (as JSON strings) and returns all changes from the old to the new schema.
+func DiffAvroSchemas(oldSchema string, newSchema string) (*AvroSchemaDiff, error) {
+	var oldParsed, newParsed interface{}
+	err := json.Unmarshal([]byte(oldSchema), &oldParsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse old schema: %w", err)
+	}
+	err = json.Unmarshal([]byte(newSchema), &newParsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new schema: %w", err)
+	}
+
+	d := avroDiffer{
+		oldTypes: make(map[string]interface{}),
+		newTypes: make(map[string]interface{}),
+		visited:  make(map[string]struct{}),
+		changes:  make([]AvroSchemaChange, 0),
+	}
+	collectAvroNamedTypes(oldParsed, "", d.oldTypes)
+	collectAvroNamedTypes(newParsed, "", d.newTypes)
+	d.diffTypes("", oldParsed, newParsed)
+
+	diff := &AvroSchemaDiff{
+		Changes:              d.changes,
+		IsBackwardCompatible: true,
+		IsForwardCompatible:  true,
+	}
+	for _, change := range d.changes {
+		if change.BreaksBackward {
+			diff.IsBackwardCompatible = false
+		}
+		if change.BreaksForward {
+			diff.IsForwardCompatible = false
+		}
+	}
+
+	return diff, nil
+}
+
+type avroDiffer struct {
+	// oldTypes and newTypes contain all named types (records, enums and fixed) by their full and simple name
+	oldTypes map[string]interface{}
+	newTypes map[string]interface{}
+
+	// visited contains the names of the already compared old and new records. Each pair of records is compared only
+	// once, so that recursive types terminate. Changes of a record that is used by multiple fields are therefore only
+	// reported for the first field.
+	visited map[string]struct{}
+	changes []AvroSchemaChange
+}
+
+// collectAvroNamedTypes registers all named types of the schema by their full and their simple name.
+func collectAvroNamedTypes(schema interface{}, namespace string, types map[string]interface{}) {
+	switch s := schema.(type) {
+	case []interface{}:
+		for _, branch := range s {
+			collectAvroNamedTypes(branch, namespace, types)
+		}
+	case map[string]interface{}:
+		typeName, _ := s["type"].(string)
+		switch typeName {
+		case "record", "error", "enum", "fixed":
+			fullName, ns := avroFullName(s, namespace)
+			types[fullName] = s
+			types[avroSimpleName(fullName)] = s
+			if fields, ok := s["fields"].([]interface{}); ok {
+				for _, field := range fields {
+					if fieldMap, ok := field.(map[string]interface{}); ok {
+						collectAvroNamedTypes(fieldMap["type"], ns, types)
+					}
+				}
+			}
+		case "array":
+			collectAvroNamedTypes(s["items"], namespace, types)
+		case "map":
+			collectAvroNamedTypes(s["values"], namespace, types)
+		default:
+			collectAvroNamedTypes(s["type"], namespace, types)
+		}
+	}
+}
+
+// avroFullName returns the full name of a named type along with the namespace that applies to its children.
+func avroFullName(s map[string]interface{}, enclosingNamespace string) (string, string) {
+	name, _ := s["name"].(string)
+	if strings.Contains(name, ".") {
+		return name, name[:strings.LastIndex(name, ".")]
+	}
+	namespace := enclosingNamespace
+	if ns, ok := s["namespace"].(string); ok {
+		namespace = ns
+	}
+	if namespace == "" {
+		return name, namespace
+	}
+	return namespace + "." + name, namespace
+}
+
+func avroSimpleName(fullName string) string {
+	return fullName[strings.LastIndex(fullName, ".")+1:]
+}
+
+// resolveAvroType replaces references to named types with their definition and unwraps primitive types that are
+// written as object (e.g. {"type": "string", "logicalType": "uuid"}).
+func resolveAvroType(schema interface{}, types map[string]interface{}) interface{} {
+	switch s := schema.(type) {
+	case string:
+		if _, isPrimitive := avroPrimitiveTypes[s]; isPrimitive {
+			return s
+		}
+		if definition, exists := types[s]; exists {
+			return definition
+		}
+		return s
+	case map[string]interface{}:
+		if typeName, ok := s["type"].(string); ok {
+			if _, isPrimitive := avroPrimitiveTypes[typeName]; isPrimitive {
+				return typeName
+			}
+		}
+		return s
+	default:
+		return s
+	}
+}
+
+// avroTypeKind returns the kind of the resolved type, which is either a primitive type name, "record", "enum",
+// "fixed", "array", "map" or "union".
+func avroTypeKind(schema interface{}) string {
+	switch s := schema.(type) {
+	case string:
+		return s
+	case []interface{}:
+		return "union"
+	case map[string]interface{}:
+		typeName, _ := s["type"].(string)
+		if typeName == "error" {
+			return "record"
+		}
+		return typeName
+	default:
+		return ""
+	}
+}
+
+// avroTypeString returns a short, human readable representation of the type.
+func avroTypeString(schema interface{}, types map[string]interface{}) string {
+	resolved := resolveAvroType(schema, types)
+	switch avroTypeKind(resolved) {
+	case "union":
+		branches := make([]string, 0)
+		for _, branch := range resolved.([]interface{}) {
+			branches = append(branches, avroTypeString(branch, types))
+		}
+		return "[" + strings.Join(branches, ", ") + "]"
+	case "array":
+		return "array<" + avroTypeString(resolved.(map[string]interface{})["items"], types) + ">"
+	case "map":
+		return "map<" + avroTypeString(resolved.(map[string]interface{})["values"], types) + ">"
+	case "record", "enum", "fixed":
+		name, _ := resolved.(map[string]interface{})["name"].(string)
+		return name
+	default:
+		kind := avroTypeKind(resolved)
+		if kind == "" {
+			return fmt.Sprintf("%v", resolved)
+		}
+		return kind
+	}
+}
+
+// canRead returns true if data written with the writer schema can be read with the reader schema. Differences in
+// the fields of records and the symbols of enums are reported separately, so only the names are compared for those.
+func canRead(writer interface{}, writerTypes map[string]interface{}, reader interface{}, readerTypes map[string]interface{}) bool {
+	writer = resolveAvroType(writer, writerTypes)
+	reader = resolveAvroType(reader, readerTypes)
+	writerKind := avroTypeKind(writer)
+	readerKind := avroTypeKind(reader)
+
+	if writerKind == "union" {
+		for _, branch := range writer.([]interface{}) {
+			if !canRead(branch, writerTypes, reader, readerTypes) {
+				return false
+			}
+		}
+		return true
+	}
+	if readerKind == "union" {
+		for _, branch := range reader.([]interface{}) {
+			if canRead(writer, writerTypes, branch, readerTypes) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if writerKind != readerKind {
+		for _, promotion := range avroPromotions[writerKind] {
+			if promotion == readerKind {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch writerKind {
+	case "array":
+		return canRead(writer.(map[string]interface{})["items"], writerTypes, reader.(map[string]interface{})["items"], readerTypes)
+	case "map":
+		return canRead(writer.(map[string]interface{})["values"], writerTypes, reader.(map[string]interface{})["values"], readerTypes)
+	case "record", "enum":
+		return avroSimpleName(fmt.Sprint(writer.(map[string]interface{})["name"])) == avroSimpleName(fmt.Sprint(reader.(map[string]interface{})["name"]))
+	case "fixed":
+		writerMap := writer.(map[string]interface{})
+		readerMap := reader.(map[string]interface{})
+		return avroSimpleName(fmt.Sprint(writerMap["name"])) == avroSimpleName(fmt.Sprint(readerMap["name"])) &&
+			reflect.DeepEqual(writerMap["size"], readerMap["size"])
+	}
+
+	return true
+}
+
+func (d *avroDiffer) diffTypes(path string, oldSchema interface{}, newSchema interface{}) {
+	oldResolved := resolveAvroType(oldSchema, d.oldTypes)
+	newResolved := resolveAvroType(newSchema, d.newTypes)
+	oldKind := avroTypeKind(oldResolved)
+	newKind := avroTypeKind(newResolved)
+
+	if oldKind == newKind {
+		switch oldKind {
+		case "record":
+			if canRead(oldResolved, d.oldTypes, newResolved, d.newTypes) {
+				d.diffRecords(path, oldResolved.(map[string]interface{}), newResolved.(map[string]interface{}))
+				return
+			}
+		case "enum":
+			if canRead(oldResolved, d.oldTypes, newResolved, d.newTypes) {
+				d.diffEnums(path, oldResolved.(map[string]interface{}), newResolved.(map[string]interface{}))
+				return
+			}
+		case "array":
+			d.diffTypes(path+"[]", oldResolved.(map[string]interface{})["items"], newResolved.(map[string]interface{})["items"])
+			return
+		case "map":
+			d.diffTypes(path+"{}", oldResolved.(map[string]interface{})["values"], newResolved.(map[string]interface{})["values"])
+			return
+		case "union":
+			if avroTypeString(oldResolved, d.oldTypes) == avroTypeString(newResolved, d.newTypes) {
+				// Same branches, but the branches' records or enums may have changed
+				newBranches := newResolved.([]interface{})
+				for i, oldBranch := range oldResolved.([]interface{}) {
+					d.diffTypes(path, oldBranch, newBranches[i])
+				}
+				return
+			}
+		}
+	}
+
+	oldTypeStr := avroTypeString(oldResolved, d.oldTypes)
+	newTypeStr := avroTypeString(newResolved, d.newTypes)
+	if oldTypeStr == newTypeStr {
+		return
+	}
+
+	change := AvroSchemaChange{
+		Type:           AvroChangeTypeChanged,
+		Path:           path,
+		OldValue:       oldTypeStr,
+		NewValue:       newTypeStr,
+		BreaksBackward: !canRead(oldResolved, d.oldTypes, newResolved, d.newTypes),
+		BreaksForward:  !canRead(newResolved, d.newTypes, oldResolved, d.oldTypes),
+	}
+	if !change.BreaksBackward || !change.BreaksForward {
+		change.Type = AvroChangeTypePromoted
+	}
+	d.changes = append(d.changes, change)
+
+	// A nullable record may be made required (or vice versa), the record's fields are compared nevertheless
+	oldRecord := findAvroUnionRecord(oldResolved, d.oldTypes)
+	newRecord := findAvroUnionRecord(newResolved, d.newTypes)
+	if oldRecord != nil && newRecord != nil && canRead(oldRecord, d.oldTypes, newRecord, d.newTypes) {
+		d.diffRecords(path, oldRecord, newRecord)
+	}
+}
+
+// findAvroUnionRecord returns the record if the schema is a record or a union with exactly one record branch.
+func findAvroUnionRecord(schema interface{}, types map[string]interface{}) map[string]interface{} {
+	switch avroTypeKind(schema) {
+	case "record":
+		return schema.(map[string]interface{})
+	case "union":
+		var record map[string]interface{}
+		for _, branch := range schema.([]interface{}) {
+			resolved := resolveAvroType(branch, types)
+			if avroTypeKind(resolved) != "record" {
+				continue
+			}
+			if record != nil {
+				return nil
+			}
+			record = resolved.(map[string]interface{})
+		}
+		return record
+	}
+
+	return nil
+}
+
+func (d *avroDiffer) diffRecords(path string, oldRecord map[string]interface{}, newRecord map[string]interface{}) {
+	oldName, _ := avroFullName(oldRecord, "")
+	newName, _ := avroFullName(newRecord, "")
+	visitedKey := oldName + "|" + newName
+	if _, isVisited := d.visited[visitedKey]; isVisited {
+		return
+	}
+	d.visited[visitedKey] = struct{}{}
+
+	oldFields := avroFields(oldRecord)
+	newFields := avroFields(newRecord)
+
+	matchedOldFields := make(map[string]struct{})
+	for _, newField := range newFields {
+		fieldPath := joinAvroPath(path, newField.Name)
+		oldField, exists := findAvroField(oldFields, newField)
+		if !exists {
+			d.changes = append(d.changes, AvroSchemaChange{
+				Type:     AvroChangeFieldAdded,
+				Path:     fieldPath,
+				NewValue: avroTypeString(newField.Type, d.newTypes),
+				// Readers using the new schema can't fill the field when reading old data without a default
+				BreaksBackward: !newField.HasDefault,
+				BreaksForward:  false,
+			})
+			continue
+		}
+		matchedOldFields[oldField.Name] = struct{}{}
+
+		d.diffTypes(fieldPath, oldField.Type, newField.Type)
+		if oldField.HasDefault != newField.HasDefault || !reflect.DeepEqual(oldField.Default, newField.Default) {
+			d.changes = append(d.changes, AvroSchemaChange{
+				Type:     AvroChangeDefaultChanged,
+				Path:     fieldPath,
+				OldValue: oldField.Default,
+				NewValue: newField.Default,
+			})
+		}
+	}
+
+	for _, oldField := range oldFields {
+		if _, isMatched := matchedOldFields[oldField.Name]; isMatched {
+			continue
+		}
+		d.changes = append(d.changes, AvroSchemaChange{
+			Type:     AvroChangeFieldRemoved,
+			Path:     joinAvroPath(path, oldField.Name),
+			OldValue: avroTypeString(oldField.Type, d.oldTypes),
+			// Readers using the old schema can't fill the field when reading new data without a default
+			BreaksBackward: false,
+			BreaksForward:  !oldField.HasDefault,
+		})
+	}
+}
+
+func (d *avroDiffer) diffEnums(path string, oldEnum map[string]interface{}, newEnum map[string]interface{}) {
+	oldSymbols := avroEnumSymbols(oldEnum)
+	newSymbols := avroEnumSymbols(newEnum)
+	_, oldHasDefault := oldEnum["default"]
+	_, newHasDefault := newEnum["default"]
+
+	for _, symbol := range sortedDifference(newSymbols, oldSymbols) {
+		d.changes = append(d.changes, AvroSchemaChange{
+			Type:     AvroChangeEnumSymbolAdded,
+			Path:     path,
+			NewValue: symbol,
+			// Readers using the old schema fail on unknown symbols, unless the enum has a default
+			BreaksForward: !oldHasDefault,
+		})
+	}
+	for _, symbol := range sortedDifference(oldSymbols, newSymbols) {
+		d.changes = append(d.changes, AvroSchemaChange{
+			Type:           AvroChangeEnumSymbolRemoved,
+			Path:           path,
+			OldValue:       symbol,
+			BreaksBackward: !newHasDefault,
+		})
+	}
+}
+
+type avroField struct {
+	Name       string
+	Aliases    []string
+	Type       interface{}
+	HasDefault bool
+	Default    interface{}
+}
+
+func avroFields(record map[string]interface{}) []avroField {
+	fields := make([]avroField, 0)
+	rawFields, _ := record["fields"].([]interface{})
+	for _, rawField := range rawFields {
+		fieldMap, ok := rawField.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := avroField{Type: fieldMap["type"]}
+		field.Name, _ = fieldMap["name"].(string)
+		field.Default, field.HasDefault = fieldMap["default"]
+		aliases, _ := fieldMap["aliases"].([]interface{})
+		for _, alias := range aliases {
+			if aliasStr, ok := alias.(string); ok {
+				field.Aliases = append(field.Aliases, aliasStr)
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// findAvroField returns the old field that matches the new field's name or one of its aliases (renamed field).
+func findAvroField(oldFields []avroField, newField avroField) (avroField, bool) {
+	for _, oldField := range oldFields {
+		if oldField.Name == newField.Name {
+			return oldField, true
+		}
+	}
+	for _, alias := range newField.Aliases {
+		for _, oldField := range oldFields {
+			if oldField.Name == alias {
+				return oldField, true
+			}
+		}
+	}
+
+	return avroField{}, false
+}
+
+func avroEnumSymbols(enum map[string]interface{}) map[string]struct{} {
+	symbols := make(map[string]struct{})
+	rawSymbols, _ := enum["symbols"].([]interface{})
+	for _, symbol := range rawSymbols {
+		if symbolStr, ok := symbol.(string); ok {
+			symbols[symbolStr] = struct{}{}
+		}
+	}
+
+	return symbols
+}
+
+// sortedDifference returns all elements of a which are not in b
+func sortedDifference(a map[string]struct{}, b map[string]struct{}) []string {
+	res := make([]string, 0)
+	for key := range a {
+		if _, exists := b[key]; !exists {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func joinAvroPath(path string, fieldName string) string {
+	if path == "" {
+		return fieldName
+	}
+	return path + "." + fieldName
+}
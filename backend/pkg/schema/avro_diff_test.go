@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const avroDiffOldSchema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "com.shop",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "quantity", "type": "int"},
+		{"name": "price", "type": "double"},
+		{"name": "note", "type": "string", "default": ""},
+		{"name": "legacyCode", "type": "string"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["CREATED", "SHIPPED", "CANCELLED"]}},
+		{"name": "customer", "type": {
+			"type": "record",
+			"name": "Customer",
+			"fields": [
+				{"name": "name", "type": "string"},
+				{"name": "email", "type": ["null", "string"], "default": null}
+			]
+		}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}}
+	]
+}`
+
+const avroDiffNewSchema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "com.shop",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "quantity", "type": "long"},
+		{"name": "price", "type": "float"},
+		{"name": "note", "type": "string", "default": "none"},
+		{"name": "createdAt", "type": "long"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["CREATED", "SHIPPED", "RETURNED"]}},
+		{"name": "customer", "type": {
+			"type": "record",
+			"name": "Customer",
+			"fields": [
+				{"name": "fullName", "type": "string", "aliases": ["name"]},
+				{"name": "email", "type": ["null", "string"], "default": null},
+				{"name": "vip", "type": "boolean", "default": false}
+			]
+		}},
+		{"name": "tags", "type": {"type": "array", "items": "int"}}
+	]
+}`
+
+func TestDiffAvroSchemas(t *testing.T) {
+	diff, err := DiffAvroSchemas(avroDiffOldSchema, avroDiffNewSchema)
+	require.NoError(t, err)
+
+	expected := []AvroSchemaChange{
+		{Type: AvroChangeTypePromoted, Path: "quantity", OldValue: "int", NewValue: "long", BreaksForward: true},
+		{Type: AvroChangeTypePromoted, Path: "price", OldValue: "double", NewValue: "float", BreaksBackward: true},
+		{Type: AvroChangeDefaultChanged, Path: "note", OldValue: "", NewValue: "none"},
+		{Type: AvroChangeFieldAdded, Path: "createdAt", NewValue: "long", BreaksBackward: true},
+		{Type: AvroChangeEnumSymbolAdded, Path: "status", NewValue: "RETURNED", BreaksForward: true},
+		{Type: AvroChangeEnumSymbolRemoved, Path: "status", OldValue: "CANCELLED", BreaksBackward: true},
+		{Type: AvroChangeFieldAdded, Path: "customer.vip", NewValue: "boolean"},
+		{Type: AvroChangeTypeChanged, Path: "tags[]", OldValue: "string", NewValue: "int", BreaksBackward: true, BreaksForward: true},
+		{Type: AvroChangeFieldRemoved, Path: "legacyCode", OldValue: "string", BreaksForward: true},
+	}
+	assert.Equal(t, expected, diff.Changes)
+	assert.False(t, diff.IsBackwardCompatible)
+	assert.False(t, diff.IsForwardCompatible)
+}
+
+func TestDiffAvroSchemas_Compatible(t *testing.T) {
+	oldSchema := `{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": ["null", "int"], "default": null}
+	]}`
+	newSchema := `{"type": "record", "name": "User", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": ["null", "int"], "default": null},
+		{"name": "country", "type": "string", "default": "unknown"}
+	]}`
+
+	diff, err := DiffAvroSchemas(oldSchema, newSchema)
+	require.NoError(t, err)
+	assert.Equal(t, []AvroSchemaChange{
+		{Type: AvroChangeFieldAdded, Path: "country", NewValue: "string"},
+	}, diff.Changes)
+	assert.True(t, diff.IsBackwardCompatible)
+	assert.True(t, diff.IsForwardCompatible)
+}
+
+func TestDiffAvroSchemas_NullableRecord(t *testing.T) {
+	// The address becomes optional and gains a field, named types are referenced by name
+	oldSchema := `{"type": "record", "name": "User", "fields": [
+		{"name": "address", "type": {"type": "record", "name": "Address", "fields": [{"name": "street", "type": "string"}]}},
+		{"name": "billingAddress", "type": "Address"}
+	]}`
+	newSchema := `{"type": "record", "name": "User", "fields": [
+		{"name": "address", "type": ["null", {"type": "record", "name": "Address", "fields": [
+			{"name": "street", "type": "string"},
+			{"name": "zip", "type": "string", "default": ""}
+		]}], "default": null},
+		{"name": "billingAddress", "type": "Address"}
+	]}`
+
+	diff, err := DiffAvroSchemas(oldSchema, newSchema)
+	require.NoError(t, err)
+	assert.Equal(t, []AvroSchemaChange{
+		{Type: AvroChangeTypePromoted, Path: "address", OldValue: "Address", NewValue: "[null, Address]", BreaksForward: true},
+		{Type: AvroChangeFieldAdded, Path: "address.zip", NewValue: "string"},
+		{Type: AvroChangeDefaultChanged, Path: "address", OldValue: nil, NewValue: nil},
+	}, diff.Changes, "changes of the address record must be reported only once")
+	assert.True(t, diff.IsBackwardCompatible)
+	assert.False(t, diff.IsForwardCompatible)
+}
+
+func TestDiffAvroSchemas_RecursiveRecord(t *testing.T) {
+	oldSchema := `{"type": "record", "name": "Node", "namespace": "com.list", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"], "default": null}
+	]}`
+	newSchema := `{"type": "record", "name": "Node", "namespace": "com.list", "fields": [
+		{"name": "value", "type": "long"},
+		{"name": "next", "type": ["null", "com.list.Node"], "default": null},
+		{"name": "children", "type": {"type": "array", "items": "Node"}, "default": []}
+	]}`
+
+	diff, err := DiffAvroSchemas(oldSchema, newSchema)
+	require.NoError(t, err)
+	assert.Equal(t, []AvroSchemaChange{
+		{Type: AvroChangeTypePromoted, Path: "value", OldValue: "int", NewValue: "long", BreaksForward: true},
+		{Type: AvroChangeFieldAdded, Path: "children", NewValue: "array<Node>"},
+	}, diff.Changes)
+}
@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheCollector exports the hit, miss and entry counts of the schema service's caches as Prometheus metrics.
+type cacheCollector struct {
+	svc *Service
+
+	hitsDesc    *prometheus.Desc
+	missesDesc  *prometheus.Desc
+	entriesDesc *prometheus.Desc
+}
+
+// NewCacheCollector returns a prometheus.Collector for the service's cache stats. The cache label is either 'schemas'
+// or 'subjects'.
+func NewCacheCollector(svc *Service, metricsNamespace string, clusterName string) prometheus.Collector {
+	constLabels := prometheus.Labels{"cluster": clusterName}
+	return &cacheCollector{
+		svc: svc,
+		hitsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "schema_registry", "cache_hits_total"),
+			"Number of schema registry lookups which have been served from the cache",
+			[]string{"cache"},
+			constLabels,
+		),
+		missesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "schema_registry", "cache_misses_total"),
+			"Number of schema registry lookups which have not been cached",
+			[]string{"cache"},
+			constLabels,
+		),
+		entriesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "schema_registry", "cache_entries"),
+			"Number of entries in the schema registry cache",
+			[]string{"cache"},
+			constLabels,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hitsDesc
+	ch <- c.missesDesc
+	ch <- c.entriesDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	schemas, subjects := c.svc.CacheStats()
+	for cacheName, stats := range map[string]CacheStats{"schemas": schemas, "subjects": subjects} {
+		ch <- prometheus.MustNewConstMetric(c.hitsDesc, prometheus.CounterValue, float64(stats.Hits), cacheName)
+		ch <- prometheus.MustNewConstMetric(c.missesDesc, prometheus.CounterValue, float64(stats.Misses), cacheName)
+		ch <- prometheus.MustNewConstMetric(c.entriesDesc, prometheus.GaugeValue, float64(stats.Entries), cacheName)
+	}
+}
@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestService returns a service with the default cache config, whose registry client is mocked by httpmock.
+func newTestService(t *testing.T, baseURL string) *Service {
+	cfg := Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}
+	cfg.SetDefaults()
+	svc, err := NewSevice(cfg)
+	require.NoError(t, err)
+
+	httpmock.ActivateNonDefault(svc.registryClient.client.GetClient())
+
+	return svc
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newCache(2)
+	c.set("a", 1, 0)
+	c.set("b", 2, 0)
+	_, _ = c.get("a")
+	c.set("c", 3, 0)
+
+	_, exists := c.get("b")
+	assert.False(t, exists, "expected least recently used entry to be evicted")
+	v, exists := c.get("a")
+	assert.True(t, exists)
+	assert.Equal(t, 1, v)
+
+	assert.Equal(t, CacheStats{Entries: 2, Hits: 2, Misses: 1}, c.stats())
+	assert.Equal(t, 2, c.purge())
+	assert.Equal(t, 0, c.stats().Entries)
+}
+
+func TestCache_Expiry(t *testing.T) {
+	now := time.Now()
+	c := newCache(10)
+	c.now = func() time.Time { return now }
+	c.set("subjects", []string{"orders-value"}, time.Minute)
+	c.set("schema-1", "schema", 0)
+
+	now = now.Add(time.Minute)
+	_, exists := c.get("subjects")
+	assert.False(t, exists, "expected entry to be expired")
+	_, exists = c.get("schema-1")
+	assert.True(t, exists, "expected entry without ttl not to expire")
+}
+
+func TestService_SubjectCacheInvalidation(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	svc := newTestService(t, baseURL)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"orders-value"}))
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/orders-value/versions",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]int{"id": 1}))
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.GetSubjects()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/subjects"])
+
+	// Registering a schema may add a subject, hence the subjects must be fetched again
+	_, err := svc.RegisterSchema("orders-value", RegisterSchemaRequest{Schema: `"string"`})
+	require.NoError(t, err)
+	_, err = svc.GetSubjects()
+	require.NoError(t, err)
+	assert.Equal(t, 2, httpmock.GetCallCountInfo()["GET "+baseURL+"/subjects"])
+
+	schemas, subjects := svc.CacheStats()
+	assert.Equal(t, 0, schemas.Entries)
+	assert.Equal(t, CacheStats{Entries: 1, Hits: 2, Misses: 2}, subjects)
+}
+
+// TestService_ConcurrentLookups must be run with the race detector, it hammers the caches with concurrent lookups of
+// a few schemas while the cache is small enough to evict entries all the time.
+func TestService_ConcurrentLookups(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	cfg := Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}
+	cfg.SetDefaults()
+	cfg.Cache.MaxEntries = 4
+	svc, err := NewSevice(cfg)
+	require.NoError(t, err)
+
+	httpmock.ActivateNonDefault(svc.registryClient.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	const schemaCount = 8
+	for id := 1; id <= schemaCount; id++ {
+		httpmock.RegisterResponder("GET", fmt.Sprintf("%v/schemas/ids/%d", baseURL, id),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{
+				"schema": fmt.Sprintf(`{"type": "record", "name": "Record%d", "fields": [{"name": "id", "type": "long"}]}`, id),
+			}))
+	}
+	httpmock.RegisterResponder("GET", baseURL+"/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"orders-value"}))
+
+	wg := sync.WaitGroup{}
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				id := uint32((worker+i)%schemaCount + 1)
+				codec, err := svc.GetAvroSchemaByID(id)
+				if assert.NoError(t, err) {
+					assert.Contains(t, codec.Schema(), fmt.Sprintf(`"Record%d"`, id))
+				}
+				_, err = svc.GetSubjects()
+				assert.NoError(t, err)
+				if i%25 == 0 {
+					svc.PurgeCache()
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	schemas, _ := svc.CacheStats()
+	assert.LessOrEqual(t, schemas.Entries, cfg.Cache.MaxEntries)
+}
@@ -1,10 +1,14 @@
 package schema
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
+	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -12,8 +16,9 @@ import (
 
 // Client that talks to the (Confluent) Schema Registry via REST
 type Client struct {
-	cfg    Config
-	client *resty.Client
+	cfg       Config
+	client    *resty.Client
+	transport *failoverTransport
 }
 
 type RestError struct {
@@ -26,8 +31,9 @@ func (e RestError) Error() string {
 }
 
 func newClient(cfg Config) (*Client, error) {
-	// TODO: Add support to fallback to other registry urls if provided
-	registryUrl := cfg.URLs[0] // Array length is checked in config validate()
+	// Requests are built against the first registry url, the failover transport sends them to the other urls if
+	// necessary. Array length is checked in config validate()
+	registryUrl := cfg.URLs[0]
 
 	client := resty.New().
 		SetHostURL(registryUrl).
@@ -56,14 +62,40 @@ func newClient(cfg Config) (*Client, error) {
 		client.SetTLSClientConfig(&tls.Config{RootCAs: pool})
 	}
 
+	// The failover transport must be set after the TLS config, which can only be set on the underlying http transport
+	transport, err := newFailoverTransport(client.GetClient().Transport, cfg.URLs)
+	if err != nil {
+		return nil, err
+	}
+	client.SetTransport(transport)
+
 	return &Client{
-		cfg:    cfg,
-		client: client,
+		cfg:       cfg,
+		client:    client,
+		transport: transport,
 	}, nil
 }
 
+// Schema types as returned by the schema registry. Avro schemas are returned without a schema type, as this has been
+// the only supported type in older registry versions.
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeJSON     = "JSON"
+)
+
+// SchemaReference references a schema of another subject version, such as an imported .proto file or a named Avro
+// type.
+type SchemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
 type SchemaResponse struct {
-	Schema string `json:"schema"`
+	Schema     string            `json:"schema"`
+	SchemaType string            `json:"schemaType"`
+	References []SchemaReference `json:"references"`
 }
 
 // GetSchemaByID returns the schema string identified by the input ID.
@@ -87,22 +119,28 @@ func (c *Client) GetSchemaByID(id uint32) (*SchemaResponse, error) {
 	if !ok {
 		return nil, fmt.Errorf("failed to parse schema response")
 	}
+	if parsed.SchemaType == "" {
+		parsed.SchemaType = SchemaTypeAvro
+	}
 
 	return parsed, nil
 }
 
 type SchemaVersionedResponse struct {
-	Subject  string `json:"subject"`
-	SchemaID int    `json:"id"`
-	Version  int    `json:"version"`
-	Schema   string `json:"schema"`
+	Subject    string            `json:"subject"`
+	SchemaID   int               `json:"id"`
+	Version    int               `json:"version"`
+	Schema     string            `json:"schema"`
+	SchemaType string            `json:"schemaType"`
+	References []SchemaReference `json:"references"`
 }
 
 // GetSchemaByID returns the schema for the specified version of this subject. The unescaped schema only is returned.
 // subject (string) – Name of the subject
 // version (versionId) – Version of the schema to be returned. Valid values for versionId are between [1,2^31-1] or
-// 		the string “latest”, which returns the last registered schema under the specified subject.
-//		Note that there may be a new latest schema that gets registered right after this request is served.
+//
+//	the string “latest”, which returns the last registered schema under the specified subject.
+//	Note that there may be a new latest schema that gets registered right after this request is served.
 func (c *Client) GetSchemaBySubject(subject string, version string) (*SchemaVersionedResponse, error) {
 	url := fmt.Sprintf("/subjects/%s/versions/%s", subject, version)
 	res, err := c.client.R().SetResult(&SchemaVersionedResponse{}).Get(url)
@@ -122,6 +160,9 @@ func (c *Client) GetSchemaBySubject(subject string, version string) (*SchemaVers
 	if !ok {
 		return nil, fmt.Errorf("failed to parse schema by subject response")
 	}
+	if parsed.SchemaType == "" {
+		parsed.SchemaType = SchemaTypeAvro
+	}
 
 	return parsed, nil
 }
@@ -276,10 +317,267 @@ func (c *Client) GetSubjectConfig(subject string) (*ConfigResponse, error) {
 	return parsed, nil
 }
 
-// CheckConnectivity checks whether the schema registry can be access by GETing the /subjects
-func (c *Client) CheckConnectivity() error {
+type RegisterSchemaRequest struct {
+	Schema     string            `json:"schema"`
+	SchemaType string            `json:"schemaType,omitempty"`
+	References []SchemaReference `json:"references,omitempty"`
+}
+
+type RegisterSchemaResponse struct {
+	SchemaID int `json:"id"`
+}
+
+// RegisterSchema registers a new schema under the specified subject. If the schema is already registered under this
+// subject, the id of the existing schema is returned. The registry rejects schemas which are incompatible with the
+// subject's compatibility level.
+func (c *Client) RegisterSchema(subject string, req RegisterSchemaRequest) (*RegisterSchemaResponse, error) {
+	url := fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject))
+	res, err := c.client.R().SetBody(req).SetResult(&RegisterSchemaResponse{}).Post(url)
+	if err != nil {
+		return nil, fmt.Errorf("register schema request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("register schema request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*RegisterSchemaResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse register schema response")
+	}
+
+	return parsed, nil
+}
+
+type DeleteSubjectResponse struct {
+	Versions []int
+}
+
+// DeleteSubject deletes all versions of the specified subject. A soft delete must be run before a subject can be
+// deleted permanently (hard delete).
+func (c *Client) DeleteSubject(subject string, permanent bool) (*DeleteSubjectResponse, error) {
+	url := fmt.Sprintf("/subjects/%s", url.PathEscape(subject))
+	res, err := c.client.R().
+		SetQueryParam("permanent", strconv.FormatBool(permanent)).
+		SetResult([]int{}).
+		Delete(url)
+	if err != nil {
+		return nil, fmt.Errorf("delete subject request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("delete subject request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*[]int)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse delete subject response")
+	}
+
+	return &DeleteSubjectResponse{
+		Versions: *parsed,
+	}, nil
+}
+
+type DeleteSubjectVersionResponse struct {
+	Version int
+}
+
+// DeleteSubjectVersion deletes a specific version of the subject. A soft delete must be run before a version can be
+// deleted permanently (hard delete).
+// version (versionId) – Version of the schema to be deleted. Valid values for versionId are between [1,2^31-1] or
+//
+//	the string “latest”.
+func (c *Client) DeleteSubjectVersion(subject string, version string, permanent bool) (*DeleteSubjectVersionResponse, error) {
+	url := fmt.Sprintf("/subjects/%s/versions/%s", url.PathEscape(subject), url.PathEscape(version))
+	var deletedVersion int
+	res, err := c.client.R().
+		SetQueryParam("permanent", strconv.FormatBool(permanent)).
+		SetResult(&deletedVersion).
+		Delete(url)
+	if err != nil {
+		return nil, fmt.Errorf("delete subject version request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("delete subject version request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	return &DeleteSubjectVersionResponse{
+		Version: deletedVersion,
+	}, nil
+}
+
+type PutConfigRequest struct {
+	// Compatibility level, see ConfigResponse for all valid values
+	Compatibility string `json:"compatibility"`
+}
+
+type PutConfigResponse struct {
+	Compatibility string `json:"compatibility"`
+}
+
+// PutConfig updates the global compatibility level.
+func (c *Client) PutConfig(req PutConfigRequest) (*PutConfigResponse, error) {
+	res, err := c.client.R().SetBody(req).SetResult(&PutConfigResponse{}).Put("/config")
+	if err != nil {
+		return nil, fmt.Errorf("put config failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("put config failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*PutConfigResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse put config response")
+	}
+
+	return parsed, nil
+}
+
+// PutSubjectConfig updates the compatibility level for the specified subject.
+func (c *Client) PutSubjectConfig(subject string, req PutConfigRequest) (*PutConfigResponse, error) {
+	url := fmt.Sprintf("/config/%s", url.PathEscape(subject))
+	res, err := c.client.R().SetBody(req).SetResult(&PutConfigResponse{}).Put(url)
+	if err != nil {
+		return nil, fmt.Errorf("put config for subject failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("put config for subject failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*PutConfigResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse put config for subject response")
+	}
+
+	return parsed, nil
+}
+
+type PutModeRequest struct {
+	// Possible values are: IMPORT, READONLY, READWRITE
+	Mode string `json:"mode"`
+}
+
+// PutMode updates the mode for Schema Registry at a global level.
+func (c *Client) PutMode(req PutModeRequest) (*ModeResponse, error) {
+	res, err := c.client.R().SetBody(req).SetResult(&ModeResponse{}).Put("/mode")
+	if err != nil {
+		return nil, fmt.Errorf("put mode request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("put mode request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*ModeResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse put mode response")
+	}
+
+	return parsed, nil
+}
+
+type CompatibilityCheckResponse struct {
+	IsCompatible bool `json:"is_compatible"`
+	// Messages explain why the schema is incompatible. They are only returned by schema registries which support
+	// the verbose parameter (Confluent Platform 6.1+).
+	Messages []string `json:"messages"`
+}
+
+// CheckCompatibility tests the schema against a specific version of the subject using the subject's compatibility
+// level. The version may be "latest".
+func (c *Client) CheckCompatibility(subject string, version string, req RegisterSchemaRequest) (*CompatibilityCheckResponse, error) {
+	url := fmt.Sprintf("/compatibility/subjects/%s/versions/%s", url.PathEscape(subject), url.PathEscape(version))
+	res, err := c.client.R().
+		SetQueryParam("verbose", "true").
+		SetBody(req).
+		SetResult(&CompatibilityCheckResponse{}).
+		Post(url)
+	if err != nil {
+		return nil, fmt.Errorf("check compatibility request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("check compatibility request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*CompatibilityCheckResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse check compatibility response")
+	}
+
+	return parsed, nil
+}
+
+// URLHealth is the result of the connectivity check against a single registry url.
+type URLHealth struct {
+	URL       string `json:"url"`
+	IsHealthy bool   `json:"isHealthy"`
+	Error     string `json:"error,omitempty"`
+}
+
+// CheckConnectivity checks whether each of the registry urls can be accessed by GETing the /subjects. An error is
+// returned if none of them is accessible.
+func (c *Client) CheckConnectivity(ctx context.Context) ([]URLHealth, error) {
+	healths := make([]URLHealth, len(c.cfg.URLs))
+	wg := sync.WaitGroup{}
+	for i, registryURL := range c.cfg.URLs {
+		wg.Add(1)
+		go func(i int, registryURL string) {
+			defer wg.Done()
+			healths[i] = URLHealth{URL: registryURL, IsHealthy: true}
+			err := c.checkURLConnectivity(withPinnedTarget(ctx, i))
+			if err != nil {
+				healths[i].IsHealthy = false
+				healths[i].Error = err.Error()
+			}
+		}(i, registryURL)
+	}
+	wg.Wait()
+
+	for _, health := range healths {
+		if health.IsHealthy {
+			return healths, nil
+		}
+	}
+
+	return healths, fmt.Errorf("none of the %d schema registry urls is accessible, first error: %v", len(healths), healths[0].Error)
+}
+
+func (c *Client) checkURLConnectivity(ctx context.Context) error {
 	url := "subjects"
-	res, err := c.client.R().Get(url)
+	res, err := c.client.R().SetContext(ctx).Get(url)
 	if err != nil {
 		return err
 	}
@@ -1,11 +1,14 @@
 package schema
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"testing"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestClient_GetSchemaByID(t *testing.T) {
@@ -27,7 +30,7 @@ func TestClient_GetSchemaByID(t *testing.T) {
 			})
 		})
 
-	expected := &SchemaResponse{Schema: schemaStr}
+	expected := &SchemaResponse{Schema: schemaStr, SchemaType: SchemaTypeAvro}
 	actual, err := c.GetSchemaByID(1000)
 	assert.NoError(t, err, "expected no error when fetching schema by id")
 	assert.Equal(t, expected, actual)
@@ -77,3 +80,178 @@ func TestClient_GetSubjectVersions(t *testing.T) {
 	assert.NoError(t, err, "expected no error when fetching subject versions")
 	assert.Equal(t, expected, actual)
 }
+
+func TestClient_RegisterSchema(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, _ := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	schemaStr := "{\"type\": \"string\"}"
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/orders-value/versions",
+		func(req *http.Request) (*http.Response, error) {
+			var body RegisterSchemaRequest
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+			}
+			if body.Schema != schemaStr {
+				return httpmock.NewJsonResponse(http.StatusUnprocessableEntity, RestError{ErrorCode: 42201, Message: "Invalid schema"})
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]int{"id": 12})
+		})
+
+	expected := &RegisterSchemaResponse{SchemaID: 12}
+	actual, err := c.RegisterSchema("orders-value", RegisterSchemaRequest{Schema: schemaStr})
+	assert.NoError(t, err, "expected no error when registering schema")
+	assert.Equal(t, expected, actual)
+
+	_, err = c.RegisterSchema("orders-value", RegisterSchemaRequest{Schema: "invalid"})
+	var restErr *RestError
+	require.True(t, errors.As(err, &restErr), "expected schema registry error")
+	assert.Equal(t, 42201, restErr.ErrorCode)
+}
+
+func TestClient_DeleteSubject(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, _ := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	versions := []int{1, 2}
+	permanentParams := make([]string, 0)
+	httpmock.RegisterResponder("DELETE", baseURL+"/subjects/orders-value",
+		func(req *http.Request) (*http.Response, error) {
+			permanentParams = append(permanentParams, req.URL.Query().Get("permanent"))
+			return httpmock.NewJsonResponse(http.StatusOK, versions)
+		})
+
+	for _, permanent := range []bool{false, true} {
+		expected := &DeleteSubjectResponse{Versions: versions}
+		actual, err := c.DeleteSubject("orders-value", permanent)
+		assert.NoError(t, err, "expected no error when deleting subject")
+		assert.Equal(t, expected, actual)
+	}
+	assert.Equal(t, []string{"false", "true"}, permanentParams)
+}
+
+func TestClient_DeleteSubjectVersion(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, _ := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("DELETE", baseURL+"/subjects/orders-value/versions/3",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("permanent") != "true" {
+				return httpmock.NewJsonResponse(http.StatusBadRequest, nil)
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, 3)
+		})
+	httpmock.RegisterResponder("DELETE", baseURL+"/subjects/orders-value/versions/4",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusNotFound, RestError{ErrorCode: 40402, Message: "Version 4 not found."})
+		})
+
+	expected := &DeleteSubjectVersionResponse{Version: 3}
+	actual, err := c.DeleteSubjectVersion("orders-value", "3", true)
+	assert.NoError(t, err, "expected no error when deleting subject version")
+	assert.Equal(t, expected, actual)
+
+	_, err = c.DeleteSubjectVersion("orders-value", "4", false)
+	var restErr *RestError
+	require.True(t, errors.As(err, &restErr), "expected schema registry error")
+	assert.Equal(t, 40402, restErr.ErrorCode)
+}
+
+func TestClient_PutConfig(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, _ := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	echoCompatibility := func(req *http.Request) (*http.Response, error) {
+		var body PutConfigRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+		}
+		return httpmock.NewJsonResponse(http.StatusOK, map[string]string{"compatibility": body.Compatibility})
+	}
+	httpmock.RegisterResponder("PUT", baseURL+"/config", echoCompatibility)
+	httpmock.RegisterResponder("PUT", baseURL+"/config/orders-value", echoCompatibility)
+
+	actual, err := c.PutConfig(PutConfigRequest{Compatibility: "FULL"})
+	assert.NoError(t, err, "expected no error when setting global config")
+	assert.Equal(t, &PutConfigResponse{Compatibility: "FULL"}, actual)
+
+	actual, err = c.PutSubjectConfig("orders-value", PutConfigRequest{Compatibility: "NONE"})
+	assert.NoError(t, err, "expected no error when setting subject config")
+	assert.Equal(t, &PutConfigResponse{Compatibility: "NONE"}, actual)
+}
+
+func TestClient_PutMode(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, _ := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("PUT", baseURL+"/mode",
+		func(req *http.Request) (*http.Response, error) {
+			var body PutModeRequest
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]string{"mode": body.Mode})
+		})
+
+	expected := &ModeResponse{Mode: "READONLY"}
+	actual, err := c.PutMode(PutModeRequest{Mode: "READONLY"})
+	assert.NoError(t, err, "expected no error when setting mode")
+	assert.Equal(t, expected, actual)
+}
+
+func TestClient_CheckCompatibility(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, _ := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", baseURL+"/compatibility/subjects/orders-value/versions/latest",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("verbose") != "true" {
+				return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{"is_compatible": false})
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"is_compatible": false,
+				"messages":      []string{"READER_FIELD_MISSING_DEFAULT_VALUE"},
+			})
+		})
+
+	expected := &CompatibilityCheckResponse{IsCompatible: false, Messages: []string{"READER_FIELD_MISSING_DEFAULT_VALUE"}}
+	actual, err := c.CheckCompatibility("orders-value", "latest", RegisterSchemaRequest{Schema: "{\"type\": \"string\"}"})
+	assert.NoError(t, err, "expected no error when checking compatibility")
+	assert.Equal(t, expected, actual)
+}
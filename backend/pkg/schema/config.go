@@ -17,6 +17,8 @@ type Config struct {
 
 	// TLS / Custom CA
 	TLS TLSConfig `yaml:"tls"`
+
+	Cache CacheConfig `yaml:"cache"`
 }
 
 // RegisterFlags registers all nested config flags.
@@ -34,5 +36,15 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("schema registry is enabled but no URL is configured")
 	}
 
+	err := c.Cache.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate cache config: %w", err)
+	}
+
 	return nil
 }
+
+// SetDefaults for the schema registry config
+func (c *Config) SetDefaults() {
+	c.Cache.SetDefaults()
+}
@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+)
+
+// CacheConfig configures the caching of schemas and subject metadata, which are fetched from the schema registry.
+type CacheConfig struct {
+	// MaxEntries is the maximum number of entries in each of the caches. Schemas are cached by id along with their
+	// compiled codecs, subject metadata by subject. The least recently used entries are evicted first.
+	MaxEntries int `yaml:"maxEntries"`
+
+	// SubjectTTL is the duration for which subjects, subject versions and subject configs are cached. Set to 0 to
+	// disable caching of subject metadata.
+	SubjectTTL time.Duration `yaml:"subjectTtl"`
+}
+
+// Validate the cache config
+func (c *CacheConfig) Validate() error {
+	if c.MaxEntries <= 0 {
+		return fmt.Errorf("max entries must be greater than 0")
+	}
+	if c.SubjectTTL < 0 {
+		return fmt.Errorf("subject ttl must not be negative")
+	}
+
+	return nil
+}
+
+// SetDefaults for the cache config
+func (c *CacheConfig) SetDefaults() {
+	c.MaxEntries = 1000
+	c.SubjectTTL = 30 * time.Second
+}
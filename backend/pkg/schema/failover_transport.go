@@ -0,0 +1,179 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// circuitBreakerThreshold is the number of consecutive failures after which a registry url will be skipped
+	circuitBreakerThreshold = 3
+	// circuitBreakerOpenDuration is the time a registry url will be skipped. Afterwards a single request is sent to
+	// test whether the url has recovered.
+	circuitBreakerOpenDuration = 30 * time.Second
+)
+
+// failoverTransport sends requests to the configured registry urls in order. Urls which failed repeatedly are skipped
+// for a while (circuit breaking). Idempotent requests are retried against the next url on connection errors and
+// 5xx responses, all other requests are sent only once because they may have been processed already.
+type failoverTransport struct {
+	next    http.RoundTripper
+	targets []*registryTarget
+	now     func() time.Time
+}
+
+// registryTarget is a registry url along with the state of its circuit breaker.
+type registryTarget struct {
+	url *url.URL
+
+	mutex               sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+}
+
+// pinnedTargetKey is the context key for requests which must be sent to a specific registry url (without failover).
+type pinnedTargetKey struct{}
+
+func withPinnedTarget(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, pinnedTargetKey{}, index)
+}
+
+func newFailoverTransport(next http.RoundTripper, registryURLs []string) (*failoverTransport, error) {
+	targets := make([]*registryTarget, len(registryURLs))
+	for i, registryURL := range registryURLs {
+		parsed, err := url.Parse(strings.TrimSuffix(registryURL, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema registry url '%v': %w", registryURL, err)
+		}
+		targets[i] = &registryTarget{url: parsed}
+	}
+
+	return &failoverTransport{
+		next:    next,
+		targets: targets,
+		now:     time.Now,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper. Requests are built against the first registry url and rewritten to the
+// target they are sent to.
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if index, ok := req.Context().Value(pinnedTargetKey{}).(int); ok {
+		return t.send(req, t.targets[index])
+	}
+
+	targets := t.orderedTargets()
+	attempts := 1
+	if isIdempotent(req.Method) {
+		// Retry at least once, even if there is a single registry url only
+		attempts = len(targets)
+		if attempts < 2 {
+			attempts = 2
+		}
+	}
+
+	var res *http.Response
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 && res != nil {
+			// Discard the failed response, as it's going to be replaced by the response of the retry
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+		if req.Context().Err() != nil {
+			return nil, req.Context().Err()
+		}
+
+		res, err = t.send(req, targets[i%len(targets)])
+		if !isFailure(res, err) {
+			return res, nil
+		}
+	}
+
+	return res, err
+}
+
+// send rewrites the request to the given target and records the outcome in the target's circuit breaker.
+func (t *failoverTransport) send(req *http.Request, target *registryTarget) (*http.Response, error) {
+	targetReq := req.Clone(req.Context())
+	targetReq.URL.Scheme = target.url.Scheme
+	targetReq.URL.Host = target.url.Host
+	targetReq.URL.Path = target.url.Path + strings.TrimPrefix(req.URL.Path, t.targets[0].url.Path)
+	// Keep escaped characters, such as slashes in subject names, as they are
+	targetReq.URL.RawPath = target.url.EscapedPath() + strings.TrimPrefix(req.URL.EscapedPath(), t.targets[0].url.EscapedPath())
+	targetReq.Host = ""
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request body: %w", err)
+		}
+		targetReq.Body = body
+	}
+
+	res, err := t.next.RoundTrip(targetReq)
+	if isFailure(res, err) {
+		target.recordFailure(t.now())
+	} else {
+		target.recordSuccess()
+	}
+
+	return res, err
+}
+
+// orderedTargets returns all targets in the configured order, but those with an open circuit breaker come last. They
+// are kept as a last resort, in case all registry urls are considered unavailable.
+func (t *failoverTransport) orderedTargets() []*registryTarget {
+	now := t.now()
+	available := make([]*registryTarget, 0, len(t.targets))
+	unavailable := make([]*registryTarget, 0)
+	for _, target := range t.targets {
+		if target.isOpen(now) {
+			unavailable = append(unavailable, target)
+			continue
+		}
+		available = append(available, target)
+	}
+
+	return append(available, unavailable...)
+}
+
+func (r *registryTarget) isOpen(now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return now.Before(r.openUntil)
+}
+
+func (r *registryTarget) recordFailure(now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.consecutiveFailures++
+	if r.consecutiveFailures >= circuitBreakerThreshold {
+		// A failed request after the open duration has passed (half open) opens the circuit again right away
+		r.openUntil = now.Add(circuitBreakerOpenDuration)
+	}
+}
+
+func (r *registryTarget) recordSuccess() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.consecutiveFailures = 0
+	r.openUntil = time.Time{}
+}
+
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+func isFailure(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	registryURL1 = "https://schema-registry-1.company.com"
+	registryURL2 = "https://schema-registry-2.company.com"
+	registryURL3 = "https://schema-registry-3.company.com/registry"
+)
+
+// newFailoverTestClient returns a client for three registry urls, whose requests are sent to the returned mock
+// transport.
+func newFailoverTestClient(t *testing.T) (*Client, *httpmock.MockTransport) {
+	c, err := newClient(Config{
+		Enabled: true,
+		URLs:    []string{registryURL1, registryURL2, registryURL3},
+	})
+	require.NoError(t, err)
+
+	mock := httpmock.NewMockTransport()
+	c.transport.next = mock
+
+	return c, mock
+}
+
+func subjectsResponder(subjects ...string) httpmock.Responder {
+	return httpmock.NewJsonResponderOrPanic(http.StatusOK, subjects)
+}
+
+func TestFailoverTransport_RetriesGetOnNextURL(t *testing.T) {
+	c, mock := newFailoverTestClient(t)
+	mock.RegisterResponder("GET", registryURL1+"/subjects", httpmock.NewErrorResponder(errors.New("connection refused")))
+	mock.RegisterResponder("GET", registryURL2+"/subjects", httpmock.NewStringResponder(http.StatusServiceUnavailable, ""))
+	mock.RegisterResponder("GET", registryURL3+"/subjects", subjectsResponder("orders-value"))
+
+	res, err := c.GetSubjects()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-value"}, res.Subjects)
+
+	calls := mock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["GET "+registryURL1+"/subjects"])
+	assert.Equal(t, 1, calls["GET "+registryURL2+"/subjects"])
+	assert.Equal(t, 1, calls["GET "+registryURL3+"/subjects"])
+}
+
+func TestFailoverTransport_DoesNotRetryWrites(t *testing.T) {
+	c, mock := newFailoverTestClient(t)
+	mock.RegisterResponder("POST", registryURL1+"/subjects/orders-value/versions", httpmock.NewStringResponder(http.StatusInternalServerError, `{"error_code": 50001, "message": "Error in the backend data store"}`))
+	mock.RegisterResponder("POST", registryURL2+"/subjects/orders-value/versions", httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]int{"id": 1}))
+
+	_, err := c.RegisterSchema("orders-value", RegisterSchemaRequest{Schema: `"string"`})
+	assert.Error(t, err)
+	assert.Equal(t, 0, mock.GetCallCountInfo()["POST "+registryURL2+"/subjects/orders-value/versions"])
+}
+
+func TestFailoverTransport_CircuitBreaker(t *testing.T) {
+	c, mock := newFailoverTestClient(t)
+	now := time.Now()
+	c.transport.now = func() time.Time { return now }
+
+	mock.RegisterResponder("GET", registryURL1+"/subjects", httpmock.NewErrorResponder(errors.New("connection refused")))
+	mock.RegisterResponder("GET", registryURL2+"/subjects", subjectsResponder("orders-value"))
+
+	// After reaching the threshold, the first url is skipped
+	for i := 0; i < circuitBreakerThreshold+2; i++ {
+		_, err := c.GetSubjects()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, circuitBreakerThreshold, mock.GetCallCountInfo()["GET "+registryURL1+"/subjects"])
+	assert.Equal(t, circuitBreakerThreshold+2, mock.GetCallCountInfo()["GET "+registryURL2+"/subjects"])
+
+	// Once the open duration has passed, the recovered first url is used again. Registering the responder again resets
+	// its call count.
+	mock.RegisterResponder("GET", registryURL1+"/subjects", subjectsResponder("payments-value"))
+	now = now.Add(circuitBreakerOpenDuration)
+	res, err := c.GetSubjects()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments-value"}, res.Subjects)
+	assert.Equal(t, 1, mock.GetCallCountInfo()["GET "+registryURL1+"/subjects"])
+}
+
+func TestFailoverTransport_KeepsEscapedPath(t *testing.T) {
+	c, mock := newFailoverTestClient(t)
+	now := time.Now()
+	c.transport.now = func() time.Time { return now }
+
+	// Open the circuit breakers of the first two urls, so that the request is sent to the third url with a path prefix
+	for i := 0; i < circuitBreakerThreshold; i++ {
+		c.transport.targets[0].recordFailure(now)
+		c.transport.targets[1].recordFailure(now)
+	}
+
+	var requestedURL string
+	mock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		requestedURL = req.URL.String()
+		return httpmock.NewJsonResponse(http.StatusOK, map[string]int{"id": 1})
+	})
+
+	_, err := c.RegisterSchema("orders/value", RegisterSchemaRequest{Schema: `"string"`})
+	require.NoError(t, err)
+	assert.Equal(t, registryURL3+"/subjects/orders%2Fvalue/versions", requestedURL)
+}
+
+func TestClient_CheckConnectivity(t *testing.T) {
+	c, mock := newFailoverTestClient(t)
+	mock.RegisterResponder("GET", registryURL1+"/subjects", subjectsResponder())
+	mock.RegisterResponder("GET", registryURL2+"/subjects", httpmock.NewErrorResponder(errors.New("connection refused")))
+	mock.RegisterResponder("GET", registryURL3+"/subjects", httpmock.NewStringResponder(http.StatusUnauthorized, "Unauthorized"))
+
+	healths, err := c.CheckConnectivity(context.Background())
+	require.NoError(t, err)
+	require.Len(t, healths, 3)
+	assert.Equal(t, URLHealth{URL: registryURL1, IsHealthy: true}, healths[0])
+	assert.False(t, healths[1].IsHealthy)
+	assert.Contains(t, healths[1].Error, "connection refused")
+	assert.False(t, healths[2].IsHealthy)
+	assert.Contains(t, healths[2].Error, "401")
+
+	// Each url is checked exactly once, the connectivity check must not fail over
+	assert.Equal(t, 1, mock.GetCallCountInfo()["GET "+registryURL2+"/subjects"])
+
+	mock.RegisterResponder("GET", registryURL1+"/subjects", httpmock.NewErrorResponder(errors.New("connection refused")))
+	_, err = c.CheckConnectivity(context.Background())
+	assert.Error(t, err, "expected an error if none of the urls is accessible")
+}
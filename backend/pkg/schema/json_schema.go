@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// jsonSchemaBaseURL is the url of the registered schema. Referenced schemas are added relative to it by their
+// reference name, so that a $ref such as "customer.json" resolves to the referenced schema of that name.
+const jsonSchemaBaseURL = "registry:///"
+
+// JSONSchema validates decoded JSON documents against a JSON schema from the schema registry. All drafts from draft 4
+// up to 2020-12 are supported, schemas without $schema are treated as draft 7 like the Confluent serializers do.
+// Patterns must be RE2 regular expressions (Go's regexp syntax), which can be matched in linear time.
+type JSONSchema struct {
+	schema *jsonschema.Schema
+
+	// compileErr is set if the schema could not be compiled. Registered schemas are immutable, hence the error is kept
+	// instead of compiling the schema again for each payload.
+	compileErr error
+}
+
+// GetJSONSchemaByID compiles the JSON schema with the given id along with all the schemas it references.
+func (s *Service) GetJSONSchemaByID(schemaID uint32) (*JSONSchema, error) {
+	v, err := s.getCachedSchema(fmt.Sprintf("json-%d", schemaID), func() (interface{}, error) {
+		schemaRes, err := s.GetSchemaByID(schemaID)
+		if err != nil {
+			return nil, err
+		}
+		if schemaRes.SchemaType != SchemaTypeJSON {
+			return nil, fmt.Errorf("schema with id %d is of type '%v' and not a json schema", schemaID, schemaRes.SchemaType)
+		}
+
+		references := make(map[string]string)
+		err = s.collectReferencedSchemas(schemaRes.References, references)
+		if err != nil {
+			return nil, err
+		}
+
+		jsonSchema, err := CompileJSONSchema(schemaRes.Schema, references)
+		if err != nil {
+			return &JSONSchema{compileErr: err}, nil
+		}
+
+		return jsonSchema, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*JSONSchema), nil
+}
+
+// CompileJSONSchema compiles the given JSON schema. References are the schemas which can be referenced by their name,
+// all other documents outside of the schema can't be loaded.
+func CompileJSONSchema(schemaStr string, references map[string]string) (*JSONSchema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("'%v' is not a referenced schema", url)
+	}
+
+	for name, referencedSchema := range references {
+		err := compiler.AddResource(jsonSchemaReferenceURL(name), strings.NewReader(referencedSchema))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add referenced schema '%v': %w", name, err)
+		}
+	}
+	err := compiler.AddResource(jsonSchemaBaseURL, strings.NewReader(schemaStr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json schema: %w", err)
+	}
+
+	compiled, err := compiler.Compile(jsonSchemaBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile json schema: %w", err)
+	}
+
+	return &JSONSchema{schema: compiled}, nil
+}
+
+// jsonSchemaReferenceURL returns the url under which a referenced schema is added. Reference names are usually
+// relative (e.g. customer.json), but may be absolute urls as well.
+func jsonSchemaReferenceURL(name string) string {
+	u, err := url.Parse(name)
+	if err == nil && u.IsAbs() {
+		return name
+	}
+	return jsonSchemaBaseURL + strings.TrimPrefix(name, "/")
+}
+
+// Validate checks the decoded JSON document (as returned by json.Unmarshal into an interface{}) against the schema.
+// The returned error describes all violations along with their location in the document.
+func (s *JSONSchema) Validate(document interface{}) error {
+	if s.compileErr != nil {
+		return fmt.Errorf("document could not be validated: %w", s.compileErr)
+	}
+
+	return s.schema.Validate(document)
+}
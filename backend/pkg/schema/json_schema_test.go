@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema_Validate(t *testing.T) {
+	schemaStr := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["id", "status"],
+  "additionalProperties": false,
+  "properties": {
+    "id": {"type": "string", "pattern": "^order-[0-9]+$"},
+    "status": {"enum": ["OPEN", "SHIPPED"]},
+    "quantity": {"type": "integer", "minimum": 1},
+    "price": {"type": "number", "exclusiveMinimum": 0},
+    "tags": {"type": "array", "items": {"type": "string", "minLength": 1}, "maxItems": 2},
+    "shippingAddress": {"$ref": "#/definitions/address"},
+    "note": {"type": ["string", "null"]}
+  },
+  "definitions": {
+    "address": {
+      "type": "object",
+      "required": ["city"],
+      "properties": {"city": {"type": "string"}}
+    }
+  }
+}`
+	jsonSchema, err := CompileJSONSchema(schemaStr, nil)
+	require.NoError(t, err)
+
+	tt := []struct {
+		TestName    string
+		Document    string
+		ErrContains string
+	}{
+		{
+			TestName: "valid",
+			Document: `{"id": "order-1", "status": "OPEN", "quantity": 2, "price": 9.99, "tags": ["a"], "shippingAddress": {"city": "Berlin"}, "note": null}`,
+		},
+		{
+			TestName:    "missing required property",
+			Document:    `{"id": "order-1"}`,
+			ErrContains: "missing properties: 'status'",
+		},
+		{
+			TestName:    "pattern mismatch",
+			Document:    `{"id": "invoice-1", "status": "OPEN"}`,
+			ErrContains: "'/id' does not validate",
+		},
+		{
+			TestName:    "enum mismatch",
+			Document:    `{"id": "order-1", "status": "LOST"}`,
+			ErrContains: "'/status' does not validate",
+		},
+		{
+			TestName:    "integer expected",
+			Document:    `{"id": "order-1", "status": "OPEN", "quantity": 1.5}`,
+			ErrContains: "expected integer, but got number",
+		},
+		{
+			TestName:    "additional property",
+			Document:    `{"id": "order-1", "status": "OPEN", "discount": 5}`,
+			ErrContains: "additionalProperties 'discount' not allowed",
+		},
+		{
+			TestName:    "invalid array item",
+			Document:    `{"id": "order-1", "status": "OPEN", "tags": [""]}`,
+			ErrContains: "'/tags/0' does not validate",
+		},
+		{
+			TestName:    "invalid referenced schema",
+			Document:    `{"id": "order-1", "status": "OPEN", "shippingAddress": {}}`,
+			ErrContains: "missing properties: 'city'",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.TestName, func(t *testing.T) {
+			var document interface{}
+			require.NoError(t, json.Unmarshal([]byte(test.Document), &document))
+
+			err := jsonSchema.Validate(document)
+			if test.ErrContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ErrContains)
+		})
+	}
+}
+
+func TestJSONSchema_Keywords(t *testing.T) {
+	schemaStr := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "email": {"type": "string", "format": "email"},
+    "kind": {"oneOf": [{"const": "private"}, {"const": "business"}]},
+    "vatId": {"type": "string"}
+  },
+  "patternProperties": {"^x-": {"type": "string"}},
+  "if": {"properties": {"kind": {"const": "business"}}},
+  "then": {"required": ["vatId"]},
+  "dependentRequired": {"vatId": ["kind"]}
+}`
+	jsonSchema, err := CompileJSONSchema(schemaStr, nil)
+	require.NoError(t, err)
+
+	tt := []struct {
+		TestName    string
+		Document    string
+		ErrContains string
+	}{
+		{"valid", `{"email": "jane@example.com", "kind": "business", "vatId": "DE123", "x-source": "crm"}`, ""},
+		{"format", `{"email": "not an email"}`, "/email"},
+		{"pattern properties", `{"x-source": 1}`, "/x-source"},
+		{"if then", `{"kind": "business"}`, "vatId"},
+		{"dependent required", `{"vatId": "DE123"}`, "kind"},
+		{"one of", `{"kind": "public"}`, "/kind"},
+	}
+
+	for _, test := range tt {
+		var document interface{}
+		require.NoError(t, json.Unmarshal([]byte(test.Document), &document))
+
+		err := jsonSchema.Validate(document)
+		if test.ErrContains == "" {
+			assert.NoError(t, err, "expected no error for case '%v'", test.TestName)
+			continue
+		}
+		require.Error(t, err, "expected an error for case '%v'", test.TestName)
+		assert.Contains(t, err.Error(), test.ErrContains, "unexpected error for case '%v'", test.TestName)
+	}
+}
+
+func TestJSONSchema_References(t *testing.T) {
+	references := map[string]string{
+		"address.json": `{"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}, "country": {"$ref": "country.json"}}}`,
+		"country.json": `{"type": "string", "minLength": 2, "maxLength": 2}`,
+		"https://example.com/schemas/phone.json": `{"type": "string", "pattern": "^\\+[0-9]+$"}`,
+	}
+	schemaStr := `{
+  "type": "object",
+  "properties": {
+    "address": {"$ref": "address.json"},
+    "phone": {"$ref": "https://example.com/schemas/phone.json"}
+  }
+}`
+	jsonSchema, err := CompileJSONSchema(schemaStr, references)
+	require.NoError(t, err)
+
+	var document interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"address": {"city": "Berlin", "country": "DE"}, "phone": "+4930123"}`), &document))
+	assert.NoError(t, jsonSchema.Validate(document))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"address": {"city": "Berlin", "country": "GER"}}`), &document))
+	assert.Error(t, jsonSchema.Validate(document), "expected an error for an invalid value of a nested reference")
+
+	require.NoError(t, json.Unmarshal([]byte(`{"phone": "030123"}`), &document))
+	assert.Error(t, jsonSchema.Validate(document), "expected an error for an invalid value of an absolute reference")
+
+	// Schemas that are not referenced by the registered schema must not be loaded
+	_, err = CompileJSONSchema(`{"$ref": "https://example.com/schemas/unknown.json"}`, references)
+	assert.Error(t, err)
+	_, err = CompileJSONSchema(`{"$ref": "customer.json"}`, nil)
+	assert.Error(t, err)
+}
+
+func TestJSONSchema_CompileErrors(t *testing.T) {
+	_, err := CompileJSONSchema(`{"type": "object"`, nil)
+	assert.Error(t, err, "expected an error for invalid JSON")
+
+	// Patterns are RE2 regular expressions, ECMA 262 lookaheads are not supported
+	_, err = CompileJSONSchema(`{"type": "string", "pattern": "^(?=.*[0-9])[a-z0-9]+$"}`, nil)
+	assert.Error(t, err)
+
+	jsonSchema := &JSONSchema{compileErr: err}
+	err = jsonSchema.Validate("abc1")
+	require.Error(t, err, "documents must not be considered valid if the schema could not be compiled")
+	assert.Contains(t, err.Error(), "document could not be validated")
+}
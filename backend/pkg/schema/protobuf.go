@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// GetProtoDescriptorByID compiles the .proto schema with the given id, including all referenced (imported) schemas.
+func (s *Service) GetProtoDescriptorByID(schemaID uint32) (*desc.FileDescriptor, error) {
+	v, err := s.getCachedSchema(fmt.Sprintf("proto-%d", schemaID), func() (interface{}, error) {
+		schemaRes, err := s.GetSchemaByID(schemaID)
+		if err != nil {
+			return nil, err
+		}
+		if schemaRes.SchemaType != SchemaTypeProtobuf {
+			return nil, fmt.Errorf("schema with id %d is of type '%v' and not a protobuf schema", schemaID, schemaRes.SchemaType)
+		}
+
+		descriptor, err := s.compileProtoSchema(schemaID, schemaRes)
+		if err != nil {
+			return nil, err
+		}
+
+		return descriptor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*desc.FileDescriptor), nil
+}
+
+// GetProtoMessageDescriptor returns the message type within the .proto schema that is referenced by the message
+// indexes (see ReadProtobufMessageIndexes).
+func (s *Service) GetProtoMessageDescriptor(schemaID uint32, messageIndexes []int) (*desc.MessageDescriptor, error) {
+	fileDescriptor, err := s.GetProtoDescriptorByID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	return findProtoMessageByIndexes(fileDescriptor, messageIndexes)
+}
+
+func (s *Service) compileProtoSchema(schemaID uint32, schemaRes *SchemaResponse) (*desc.FileDescriptor, error) {
+	files := make(map[string]string)
+	err := s.collectReferencedSchemas(schemaRes.References, files)
+	if err != nil {
+		return nil, err
+	}
+
+	// The referenced schemas are imported by their reference name, the root schema has no name though. Schema ids
+	// can't be a reference name, as these are supposed to be file paths.
+	filename := fmt.Sprintf("%d.proto", schemaID)
+	files[filename] = schemaRes.Schema
+
+	// Well known types such as google/protobuf/timestamp.proto are provided by the parser if they are not referenced
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(files),
+	}
+	descriptors, err := parser.ParseFiles(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile proto schema: %w", err)
+	}
+
+	return descriptors[0], nil
+}
+
+// ReadProtobufMessageIndexes reads the message indexes which the Confluent Protobuf serializer writes between the
+// schema id and the actual message. The indexes are the path to the message type within the .proto schema, e.g.
+// [1, 0] refers to the first nested message of the second message. The count and all indexes are zigzag encoded
+// varints, the frequent case [0] is written as a single zero byte. It returns the indexes and the number of bytes read.
+func ReadProtobufMessageIndexes(payload []byte) ([]int, int, error) {
+	count, offset := binary.Varint(payload)
+	if offset <= 0 {
+		return nil, 0, fmt.Errorf("failed to read message index count")
+	}
+	if count == 0 {
+		return []int{0}, offset, nil
+	}
+	// Each index takes at least one byte
+	if count < 0 || count > int64(len(payload)-offset) {
+		return nil, 0, fmt.Errorf("invalid message index count %d", count)
+	}
+
+	indexes := make([]int, 0, count)
+	for i := int64(0); i < count; i++ {
+		index, n := binary.Varint(payload[offset:])
+		if n <= 0 {
+			return nil, 0, fmt.Errorf("failed to read message index %d", i)
+		}
+		indexes = append(indexes, int(index))
+		offset += n
+	}
+
+	return indexes, offset, nil
+}
+
+func findProtoMessageByIndexes(fileDescriptor *desc.FileDescriptor, messageIndexes []int) (*desc.MessageDescriptor, error) {
+	if len(messageIndexes) == 0 {
+		return nil, fmt.Errorf("no message indexes given")
+	}
+
+	var message *desc.MessageDescriptor
+	messageTypes := fileDescriptor.GetMessageTypes()
+	for _, index := range messageIndexes {
+		if index < 0 || index >= len(messageTypes) {
+			return nil, fmt.Errorf("message index %d out of range, schema has %d message types at this level", index, len(messageTypes))
+		}
+		message = messageTypes[index]
+		messageTypes = message.GetNestedMessageTypes()
+	}
+
+	return message, nil
+}
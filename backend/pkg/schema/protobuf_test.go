@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProtobufMessageIndexes(t *testing.T) {
+	tt := []struct {
+		TestName  string
+		Payload   []byte
+		Indexes   []int
+		BytesRead int
+		ExpectErr bool
+	}{
+		{
+			TestName:  "first message shortcut",
+			Payload:   []byte{0x00, 0x08, 0x96, 0x01},
+			Indexes:   []int{0},
+			BytesRead: 1,
+		},
+		{
+			// Count 2 (zigzag 0x04), indexes 1 (0x02) and 0 (0x00)
+			TestName:  "nested message",
+			Payload:   []byte{0x04, 0x02, 0x00, 0x08, 0x96, 0x01},
+			Indexes:   []int{1, 0},
+			BytesRead: 3,
+		},
+		{
+			TestName:  "truncated indexes",
+			Payload:   []byte{0x06, 0x02},
+			ExpectErr: true,
+		},
+		{
+			TestName:  "empty payload",
+			Payload:   []byte{},
+			ExpectErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.TestName, func(t *testing.T) {
+			indexes, n, err := ReadProtobufMessageIndexes(test.Payload)
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.Indexes, indexes)
+			assert.Equal(t, test.BytesRead, n)
+		})
+	}
+}
+
+func TestService_GetProtoMessageDescriptor(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	svc := newTestService(t, baseURL)
+	defer httpmock.DeactivateAndReset()
+
+	orderSchema := `syntax = "proto3";
+package shop;
+
+import "customer.proto";
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  string id = 1;
+  shop.Customer customer = 2;
+  google.protobuf.Timestamp created_at = 3;
+
+  message Item {
+    string sku = 1;
+  }
+}`
+	customerSchema := `syntax = "proto3";
+package shop;
+
+message Customer {
+  string name = 1;
+}`
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1000",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"schema":     orderSchema,
+			"schemaType": SchemaTypeProtobuf,
+			"references": []map[string]interface{}{
+				{"name": "customer.proto", "subject": "customer-value", "version": 2},
+			},
+		}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/customer-value/versions/2",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"subject":    "customer-value",
+			"id":         999,
+			"version":    2,
+			"schema":     customerSchema,
+			"schemaType": SchemaTypeProtobuf,
+		}))
+
+	descriptor, err := svc.GetProtoMessageDescriptor(1000, []int{0})
+	require.NoError(t, err)
+	assert.Equal(t, "shop.Order", descriptor.GetFullyQualifiedName())
+	assert.Equal(t, "shop.Customer", descriptor.FindFieldByName("customer").GetMessageType().GetFullyQualifiedName())
+
+	descriptor, err = svc.GetProtoMessageDescriptor(1000, []int{0, 0})
+	require.NoError(t, err)
+	assert.Equal(t, "shop.Order.Item", descriptor.GetFullyQualifiedName())
+
+	_, err = svc.GetProtoMessageDescriptor(1000, []int{1})
+	assert.Error(t, err, "expected an error for a message index which is out of range")
+
+	// The compiled schema is cached
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/schemas/ids/1000"])
+
+	_, err = svc.GetAvroSchemaByID(1000)
+	assert.Error(t, err, "expected an error when requesting a protobuf schema as avro schema")
+}
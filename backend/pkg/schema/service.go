@@ -1,7 +1,10 @@
 package schema
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+
 	"github.com/linkedin/goavro/v2"
 	"golang.org/x/sync/singleflight"
 )
@@ -14,8 +17,12 @@ type Service struct {
 
 	registryClient *Client
 
-	// Schema Cache by schema id
-	cacheByID map[uint32]*goavro.Codec
+	// schemaCache contains the schemas and their compiled codecs by schema id. Schemas registered under an id are
+	// immutable, hence these entries never get stale.
+	schemaCache *cache
+	// subjectCache contains subjects, subject versions and subject configs, which may change at any time. These
+	// entries expire after the configured TTL and are invalidated by write operations.
+	subjectCache *cache
 }
 
 // NewService to access schema registry. Returns an error if connection can't be established.
@@ -29,41 +36,123 @@ func NewSevice(cfg Config) (*Service, error) {
 		cfg:            cfg,
 		requestGroup:   singleflight.Group{},
 		registryClient: client,
-		cacheByID:      make(map[uint32]*goavro.Codec),
+		schemaCache:    newCache(cfg.Cache.MaxEntries),
+		subjectCache:   newCache(cfg.Cache.MaxEntries),
 	}, nil
 }
 
-// CheckConnectivity to schema registry. Returns no error if connectivity is fine.
-func (s *Service) CheckConnectivity() error {
-	return s.registryClient.CheckConnectivity()
+// CheckConnectivity to all schema registry urls. Returns no error if at least one of them is accessible.
+func (s *Service) CheckConnectivity(ctx context.Context) ([]URLHealth, error) {
+	return s.registryClient.CheckConnectivity(ctx)
 }
 
-func (s *Service) GetAvroSchemaByID(schemaID uint32) (*goavro.Codec, error) {
+// getCachedSchema returns the cached entry for the given key or calls compile and caches its result. Concurrent
+// requests for the same key are deduplicated, so that the schema registry is asked only once.
+func (s *Service) getCachedSchema(key string, compile func() (interface{}, error)) (interface{}, error) {
+	if v, exists := s.schemaCache.get(key); exists {
+		return v, nil
+	}
+
 	// Singleflight makes sure to not run the function body if there are concurrent requests. We use this to avoid
 	// duplicate requests against the schema registry
-	key := fmt.Sprintf("get-avro-schema-%d", schemaID)
 	v, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
-		if codec, exists := s.cacheByID[schemaID]; exists {
-			return codec, nil
+		v, err := compile()
+		if err != nil {
+			return nil, err
+		}
+		s.schemaCache.set(key, v, 0)
+
+		return v, nil
+	})
+
+	return v, err
+}
+
+// collectReferencedSchemas fetches all referenced schemas recursively and adds them to the given map, keyed by their
+// reference name. Protobuf schemas import the referenced schemas by this name, JSON schemas use it in $refs.
+func (s *Service) collectReferencedSchemas(references []SchemaReference, schemas map[string]string) error {
+	for _, reference := range references {
+		if _, exists := schemas[reference.Name]; exists {
+			continue
+		}
+
+		schemaRes, err := s.registryClient.GetSchemaBySubject(reference.Subject, strconv.Itoa(reference.Version))
+		if err != nil {
+			return fmt.Errorf("failed to get referenced schema '%v': %w", reference.Name, err)
+		}
+		schemas[reference.Name] = schemaRes.Schema
+
+		err = s.collectReferencedSchemas(schemaRes.References, schemas)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getCachedSubjectMetadata is like getCachedSchema, but the entries expire after the configured subject TTL.
+func (s *Service) getCachedSubjectMetadata(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	if s.cfg.Cache.SubjectTTL == 0 {
+		return fetch()
+	}
+	if v, exists := s.subjectCache.get(key); exists {
+		return v, nil
+	}
+
+	v, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
+		v, err := fetch()
+		if err != nil {
+			return nil, err
 		}
+		s.subjectCache.set(key, v, s.cfg.Cache.SubjectTTL)
 
+		return v, nil
+	})
+
+	return v, err
+}
+
+// PurgeCache removes all cached schemas and subject metadata. It returns the number of removed entries.
+func (s *Service) PurgeCache() int {
+	return s.schemaCache.purge() + s.subjectCache.purge()
+}
+
+// CacheStats returns the stats of the schema cache and the subject metadata cache.
+func (s *Service) CacheStats() (schemas CacheStats, subjects CacheStats) {
+	return s.schemaCache.stats(), s.subjectCache.stats()
+}
+
+// GetSchemaByID returns the schema string along with its type and references.
+func (s *Service) GetSchemaByID(schemaID uint32) (*SchemaResponse, error) {
+	v, err := s.getCachedSchema(fmt.Sprintf("schema-%d", schemaID), func() (interface{}, error) {
 		schemaRes, err := s.registryClient.GetSchemaByID(schemaID)
 		if err != nil {
-			// If schema registry returns an error we want to retry it next time, so let's forget the key
-			s.requestGroup.Forget(key)
 			return nil, fmt.Errorf("failed to get schema from registry: %w", err)
 		}
+		return schemaRes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*SchemaResponse), nil
+}
+
+func (s *Service) GetAvroSchemaByID(schemaID uint32) (*goavro.Codec, error) {
+	v, err := s.getCachedSchema(fmt.Sprintf("avro-%d", schemaID), func() (interface{}, error) {
+		schemaRes, err := s.GetSchemaByID(schemaID)
+		if err != nil {
+			return nil, err
+		}
+		if schemaRes.SchemaType != SchemaTypeAvro {
+			return nil, fmt.Errorf("schema with id %d is of type '%v' and not an avro schema", schemaID, schemaRes.SchemaType)
+		}
 
 		codec, err := goavro.NewCodec(schemaRes.Schema)
 		if err != nil {
-			// If codec compilation returns an error we want to retry it next time (maybe the schema has changed or response
-			// was corrupted), so let's forget the key
-			s.requestGroup.Forget(key)
 			return nil, fmt.Errorf("failed to create codec from schema string: %w", err)
 		}
-
-		s.cacheByID[schemaID] = codec
-
 		return codec, nil
 	})
 	if err != nil {
@@ -76,11 +165,25 @@ func (s *Service) GetAvroSchemaByID(schemaID uint32) (*goavro.Codec, error) {
 }
 
 func (s *Service) GetSubjects() (*SubjectsResponse, error) {
-	return s.registryClient.GetSubjects()
+	v, err := s.getCachedSubjectMetadata("subjects", func() (interface{}, error) {
+		return s.registryClient.GetSubjects()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*SubjectsResponse), nil
 }
 
 func (s *Service) GetSubjectVersions(subject string) (*SubjectVersionsResponse, error) {
-	return s.registryClient.GetSubjectVersions(subject)
+	v, err := s.getCachedSubjectMetadata(subjectVersionsCacheKey(subject), func() (interface{}, error) {
+		return s.registryClient.GetSubjectVersions(subject)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*SubjectVersionsResponse), nil
 }
 
 func (s *Service) GetSchemaBySubject(subject string, version string) (*SchemaVersionedResponse, error) {
@@ -96,5 +199,62 @@ func (s *Service) GetConfig() (*ConfigResponse, error) {
 }
 
 func (s *Service) GetSubjectConfig(subject string) (*ConfigResponse, error) {
-	return s.registryClient.GetSubjectConfig(subject)
+	v, err := s.getCachedSubjectMetadata(subjectConfigCacheKey(subject), func() (interface{}, error) {
+		return s.registryClient.GetSubjectConfig(subject)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*ConfigResponse), nil
+}
+
+func (s *Service) RegisterSchema(subject string, req RegisterSchemaRequest) (*RegisterSchemaResponse, error) {
+	defer s.invalidateSubject(subject)
+	return s.registryClient.RegisterSchema(subject, req)
+}
+
+func (s *Service) DeleteSubject(subject string, permanent bool) (*DeleteSubjectResponse, error) {
+	defer s.invalidateSubject(subject)
+	return s.registryClient.DeleteSubject(subject, permanent)
+}
+
+func (s *Service) DeleteSubjectVersion(subject string, version string, permanent bool) (*DeleteSubjectVersionResponse, error) {
+	defer s.invalidateSubject(subject)
+	return s.registryClient.DeleteSubjectVersion(subject, version, permanent)
+}
+
+func (s *Service) PutConfig(req PutConfigRequest) (*PutConfigResponse, error) {
+	// Subjects without a subject specific config return the global config
+	defer s.subjectCache.removePrefix(subjectConfigCacheKey(""))
+	return s.registryClient.PutConfig(req)
+}
+
+func (s *Service) PutSubjectConfig(subject string, req PutConfigRequest) (*PutConfigResponse, error) {
+	defer s.subjectCache.remove(subjectConfigCacheKey(subject))
+	return s.registryClient.PutSubjectConfig(subject, req)
+}
+
+func (s *Service) PutMode(req PutModeRequest) (*ModeResponse, error) {
+	return s.registryClient.PutMode(req)
+}
+
+func (s *Service) CheckCompatibility(subject string, version string, req RegisterSchemaRequest) (*CompatibilityCheckResponse, error) {
+	return s.registryClient.CheckCompatibility(subject, version, req)
+}
+
+// invalidateSubject removes all cached metadata of the given subject, as well as the list of subjects, which may
+// have changed by registering or deleting schemas.
+func (s *Service) invalidateSubject(subject string) {
+	s.subjectCache.remove("subjects")
+	s.subjectCache.remove(subjectVersionsCacheKey(subject))
+	s.subjectCache.remove(subjectConfigCacheKey(subject))
+}
+
+func subjectVersionsCacheKey(subject string) string {
+	return "subject-versions/" + subject
+}
+
+func subjectConfigCacheKey(subject string) string {
+	return "subject-config/" + subject
 }